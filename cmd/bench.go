@@ -0,0 +1,151 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/terakilobyte/checker/internal/collectors"
+	"github.com/terakilobyte/checker/internal/sources"
+)
+
+var benchIterations int
+
+// benchCmd represents the bench command
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measures how fast checker parses and resolves the project.",
+	Long: `Runs the parse and resolve stages against the project repeatedly and reports their
+throughput and allocations per run.
+
+Nothing is fetched: refs resolve against the project's own labels and directives only,
+so the numbers measure checker rather than the network, and are comparable release to release.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if benchIterations < 1 {
+			configFatalf("--iterations must be at least 1")
+		}
+		basepath, err := filepath.Abs(path)
+		checkErr(err)
+		checkerConfig := loadCheckerConfig(basepath)
+		files := gatherFiles(basepath)
+
+		// a warm up run, so the first iteration doesn't pay for the page cache
+		benchResolve(benchParse(files, checkerConfig))
+
+		var parseTotal, resolveTotal benchResult
+		var roles int
+		for i := 0; i < benchIterations; i++ {
+			var parsed benchProject
+			parseTotal = parseTotal.add(measure(func() { parsed = benchParse(files, checkerConfig) }))
+			resolveTotal = resolveTotal.add(measure(func() { roles = benchResolve(parsed) }))
+		}
+
+		fmt.Printf("%d files, %d roles, %d iterations\n\n", len(files), roles, benchIterations)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "STAGE\tTIME/OP\tFILES/S\tALLOCS/OP\tBYTES/OP")
+		for _, stage := range []struct {
+			name   string
+			result benchResult
+		}{{"parse", parseTotal}, {"resolve", resolveTotal}} {
+			perOp := stage.result.elapsed / time.Duration(benchIterations)
+			fmt.Fprintf(w, "%s\t%v\t%.0f\t%d\t%d\n", stage.name, perOp.Round(time.Microsecond),
+				float64(len(files))/perOp.Seconds(),
+				stage.result.allocs/uint64(benchIterations), stage.result.bytes/uint64(benchIterations))
+		}
+		checkErr(w.Flush())
+	},
+}
+
+// benchProject is what the parse stage collects from the project.
+type benchProject struct {
+	files     []string
+	roles     collectors.RstRoleMap
+	localRefs collectors.RefTargetMap
+	targets   *collectors.TargetRegistry
+	config    *sources.CheckerConfig
+}
+
+type benchResult struct {
+	elapsed time.Duration
+	allocs  uint64
+	bytes   uint64
+}
+
+func (r benchResult) add(o benchResult) benchResult {
+	return benchResult{elapsed: r.elapsed + o.elapsed, allocs: r.allocs + o.allocs, bytes: r.bytes + o.bytes}
+}
+
+// measure runs f once, reporting how long it took and what it allocated.
+func measure(f func()) benchResult {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	f()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return benchResult{elapsed: elapsed, allocs: after.Mallocs - before.Mallocs, bytes: after.TotalAlloc - before.TotalAlloc}
+}
+
+// benchParse makes the same pass over every file that a check does.
+func benchParse(files []string, cfg *sources.CheckerConfig) benchProject {
+	project, err := collectors.GatherProject(files, collectors.Collect{})
+	checkErr(err)
+	return benchProject{
+		files:     files,
+		roles:     project.Roles,
+		localRefs: project.LocalRefs.Alias(cfg.RefAliases),
+		targets:   project.Targets,
+		config:    cfg,
+	}
+}
+
+// benchResolve resolves every ref, defined object, and doc role against the
+// project alone, returning how many roles it looked at.
+func benchResolve(p benchProject) int {
+	for role, filename := range p.roles {
+		kind := role.Name[strings.LastIndex(role.Name, ":")+1:]
+		switch {
+		case p.config.IsRefRole(role.Name):
+			p.localRefs.Get(&role)
+		case contains(definedRoles, kind):
+			if !p.targets.Defines(kind, role.Target) {
+				p.targets.Normalize(kind, role.Target)
+			}
+		case role.Name == "doc":
+			contains(p.files, filename)
+		}
+	}
+	return len(p.roles)
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+	benchCmd.Flags().IntVarP(&benchIterations, "iterations", "n", 5, "number of times to run each stage")
+}
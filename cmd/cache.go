@@ -0,0 +1,56 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"time"
+
+	"github.com/terakilobyte/checker/internal/cache"
+	"github.com/terakilobyte/checker/internal/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	cacheTTL time.Duration
+	noCache  bool
+)
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", 24*time.Hour, "how long the latest snooty-parser release is cached before it's looked up again")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "don't read or write checker's cache")
+}
+
+// specCache caches in checker's cache directory for ttl, or forever if it's 0.
+// It's nil, caching nothing, with --no-cache, and while recording or replaying
+// a cassette, so cassettes hold every request a run makes.
+func specCache(ttl time.Duration) *cache.Cache {
+	if noCache || recordFile != "" || replayFile != "" {
+		return nil
+	}
+	dir, err := utils.CacheDir()
+	if err != nil {
+		log.Debugf("not caching: %v", err)
+		return nil
+	}
+	return cache.New(dir, ttl)
+}
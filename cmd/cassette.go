@@ -0,0 +1,76 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/terakilobyte/checker/internal/cassette"
+	"github.com/terakilobyte/checker/internal/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	recordFile string
+	replayFile string
+)
+
+func init() {
+	rootCmd.Flags().StringVar(&recordFile, "record", "", "record every network request and response to this cassette file")
+	rootCmd.Flags().StringVar(&replayFile, "replay", "", "answer network requests from this cassette file instead of the network")
+}
+
+func validateCassette() error {
+	if recordFile != "" && replayFile != "" {
+		return fmt.Errorf("--record and --replay can't be used together")
+	}
+	return nil
+}
+
+// startCassette records or replays network requests, if asked to. It must be
+// started before anything else that wraps the transport, so everything else
+// sees replayed responses as if they came from the network. The returned func
+// saves the recording.
+func startCassette() func() {
+	switch {
+	case replayFile != "":
+		c, err := cassette.Load(replayFile)
+		if err != nil {
+			configFatalf("could not load cassette: %v", err)
+		}
+		utils.WrapTransport(func(http.RoundTripper) http.RoundTripper {
+			return c.Replay()
+		})
+		return func() {}
+	case recordFile != "":
+		c := &cassette.Cassette{}
+		utils.WrapTransport(c.Record)
+		return func() {
+			if err := c.Save(recordFile); err != nil {
+				log.Warnf("could not save cassette %s: %v", recordFile, err)
+			}
+		}
+	}
+	return func() {}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+var (
+	// certExpiryDays warns about links to hosts whose certificates expire
+	// within that many days, 0 for never
+	certExpiryDays int
+	// certWarned are the hosts expiring certificates were already reported
+	// for, so each is reported once
+	certWarned sync.Map
+)
+
+func init() {
+	rootCmd.PersistentFlags().IntVar(&certExpiryDays, "warn-cert-expiry", 0, "warn about linked hosts whose certificates expire within this many days, like sites the team runs")
+}
+
+// expiringCertificate warns about link when its host's certificate expires
+// within --warn-cert-expiry days, once per host.
+func expiringCertificate(filename string, link string, diags chan<- diagnostics.Diagnostic) {
+	if certExpiryDays == 0 {
+		return
+	}
+	u, err := url.Parse(utils.NormalizeURL(link))
+	if err != nil {
+		return
+	}
+	expiry, ok := utils.CertificateExpiry(u.Hostname())
+	if !ok || time.Until(expiry) > time.Duration(certExpiryDays)*24*time.Hour {
+		return
+	}
+	if _, warned := certWarned.LoadOrStore(u.Hostname(), true); warned {
+		return
+	}
+	days := int(time.Until(expiry).Hours() / 24)
+	diags <- diagnostics.Warnf(diagnostics.Certificate, filename, "the certificate of %s, linked to by %s, expires in %d days, on %s", u.Hostname(), link, days, expiry.Format("2006-01-02")).WithTarget(link).WithReason(diagnostics.CertExpiring)
+}
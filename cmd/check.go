@@ -0,0 +1,96 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/terakilobyte/checker/internal/collectors"
+)
+
+// stdinFilename is where in the project a snippet checked with check - is
+// treated as living, so relative doc refs resolve from there
+var stdinFilename string
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check -",
+	Short: "Checks a snippet read from stdin against the project.",
+	Long: `Checks a snippet of rst read from stdin, like a paragraph selected in an editor, against the project: its refs
+against the project's labels and intersphinx inventories, its roles against rstspec.toml, and its constants against
+snooty.toml. Only problems in the snippet are reported.
+
+checker check - < fragment.rst
+
+The snippet is treated as the file --stdin-filename in the project, replacing that file if it exists.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if args[0] != "-" {
+			configFatalf("check reads a snippet from stdin, run it as checker check - < fragment.rst")
+		}
+		snippet, err := ioutil.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			configFatalf("could not read the snippet from stdin: %v", err)
+		}
+		basepath, err := filepath.Abs(path)
+		checkErr(err)
+		name := filepath.Join(basepath, stdinFilename)
+
+		// the snippet is layered over the project, so it's collected like
+		// any other file without being written to disk
+		overlay := afero.NewMemMapFs()
+		checkErr(overlay.MkdirAll(filepath.Dir(name), 0755))
+		checkErr(afero.WriteFile(overlay, name, snippet, 0644))
+		collectors.FS = afero.NewCopyOnWriteFs(collectors.FS, overlay)
+		collectors.FSUtil = &afero.Afero{Fs: collectors.FS}
+
+		changes = []string{name}
+		run(runChecks)
+	},
+}
+
+// checkFileCmd represents the check-file command
+var checkFileCmd = &cobra.Command{
+	Use:   "check-file path",
+	Short: "Checks one file with the whole project as context.",
+	Long: `Reads the whole project, so refs resolve against every label and intersphinx inventory, but only reports
+problems in the named file, and only checks the links in it. For iterating on one page, it's quicker than checking
+everything and simpler than --changes.
+
+checker check-file source/reference/foo.txt
+
+The path is relative to the working directory or, if nothing is there, to the project.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		scope = args
+		run(runChecks)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(checkFileCmd)
+	checkCmd.Flags().StringVar(&stdinFilename, "stdin-filename", "source/stdin.txt", "the file in the project, relative to it, the snippet is checked as")
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checks are the categories of validation --only and --skip select from.
+var checks = []string{"refs", "docs", "roles", "links", "constants", "openapi", "deprecated", "duplicates", "syntax", "naming", "schemes", "metadata", "soft404", "parked", "walls", "canonical", "sharedinclude", "plugins"}
+
+var (
+	only []string
+	skip []string
+)
+
+func validateCheckSelection() error {
+	for _, selected := range append(append([]string{}, only...), skip...) {
+		if !containsExactly(checks, selected) {
+			return fmt.Errorf("%s is not a check, choose from %s", selected, strings.Join(checks, ", "))
+		}
+	}
+	return nil
+}
+
+// enabled reports whether check should run, given --only and --skip.
+func enabled(check string) bool {
+	if len(only) > 0 && !containsExactly(only, check) {
+		return false
+	}
+	return !containsExactly(skip, check)
+}
+
+func containsExactly(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
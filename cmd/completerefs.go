@@ -0,0 +1,53 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var completePrefix string
+
+// completeRefsCmd represents the complete-refs command
+var completeRefsCmd = &cobra.Command{
+	Use:   "complete-refs",
+	Short: "Prints the ref targets starting with a prefix, for editor autocompletion.",
+	Long: `Prints the ref targets starting with --prefix that :ref: can use, one a line, followed by a tab and where
+the target is defined: the file in the project, or the domain of the intersphinx inventory it's from.
+
+	checker complete-refs --prefix node-
+
+Editors that keep checker running can use serve's listRefs instead, which keeps the inventories between calls.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, c := range refCompletions(completePrefix) {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", c.Target, c.Source)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completeRefsCmd)
+	completeRefsCmd.Flags().StringVar(&completePrefix, "prefix", "", "only print ref targets starting with this")
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// completeList completes a comma separated list flag, like --only, offering
+// the choices not already listed.
+func completeList(choices []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		listed := strings.Split(toComplete, ",")
+		prefix := strings.Join(listed[:len(listed)-1], ",")
+		if prefix != "" {
+			prefix += ","
+		}
+		completions := make([]string, 0, len(choices))
+		for _, choice := range choices {
+			if !containsExactly(listed, choice) {
+				completions = append(completions, prefix+choice)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+}
+
+// completeChoice completes a flag that takes one of choices.
+func completeChoice(choices ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return choices, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeFormat completes --format names, then the file after a name and =.
+func completeFormat(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if strings.Contains(toComplete, "=") {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return formatNames, cobra.ShellCompDirectiveNoFileComp
+}
+
+// registerCompletions adds dynamic shell completion of flag values to the
+// completion scripts cobra's completion command generates. It runs once every
+// flag is defined.
+func registerCompletions() {
+	completions := []struct {
+		cmd  *cobra.Command
+		flag string
+		fn   func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)
+	}{
+		{rootCmd, "only", completeList(checks)},
+		{rootCmd, "skip", completeList(checks)},
+		{rootCmd, "format", completeFormat},
+		{rootCmd, "fail-on", completeChoice("error", "warning", "none")},
+		{rootCmd, "error-on", completeList(severityScopes())},
+		{rootCmd, "warn-on", completeList(severityScopes())},
+		{rootCmd, "log-level", completeChoice("trace", "debug", "info", "warn", "error", "fatal")},
+		{rootCmd, "log-format", completeChoice("text", "json")},
+		{graphCmd, "format", completeChoice("dot", "json")},
+	}
+	for _, c := range completions {
+		checkErr(c.cmd.RegisterFlagCompletionFunc(c.flag, c.fn))
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+var (
+	// checkMX looks up whether the domain of each mailto: address accepts mail
+	checkMX bool
+	// mailDomains memoizes the lookup of each domain checkMX checks
+	mailDomains sync.Map
+)
+
+// checkContactLink reports a mailto: or tel: link that's malformed, and with
+// --check-mx, mailto: addresses at domains that don't accept mail. A malformed
+// link is a typo in the docs, not a site that's down, so it's a syntax error
+// that --warn-external leaves alone.
+func checkContactLink(link rst.RstContactLink, filename string, diags chan<- diagnostics.Diagnostic, enqueue func(func())) {
+	if strings.HasPrefix(string(link), "tel:") {
+		if err := utils.CheckTel(string(link)); err != nil {
+			diags <- diagnostics.Errorf(diagnostics.Syntax, filename, "%s is not a valid phone number: %v", link, err).WithTarget(string(link))
+		}
+		return
+	}
+	if err := utils.CheckMailto(string(link)); err != nil {
+		diags <- diagnostics.Errorf(diagnostics.Syntax, filename, "%s is not a valid email link: %v", link, err).WithTarget(string(link))
+		return
+	}
+	if !checkMX {
+		return
+	}
+	// checked links are valid, so they have addresses
+	addresses, _ := utils.MailtoAddresses(string(link))
+	enqueue(func() {
+		for _, address := range addresses {
+			domain := strings.ToLower(address[strings.LastIndex(address, "@")+1:])
+			result, ok := mailDomains.Load(domain)
+			if !ok {
+				result, _ = mailDomains.LoadOrStore(domain, &mailDomain{err: utils.CheckMailDomain(address)})
+			}
+			if err := result.(*mailDomain).err; err != nil {
+				diags <- diagnostics.Errorf(diagnostics.Link, filename, "%s is not a valid email link: %v", link, err).WithTarget(string(link)).WithReason(utils.FailureReason(err))
+			}
+		}
+	})
+}
+
+// mailDomain is the result of looking up whether a domain accepts mail.
+type mailDomain struct {
+	err error
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&checkMX, "check-mx", false, "look up whether the domain of each mailto: address accepts mail, not just that the address is well-formed")
+}
@@ -0,0 +1,57 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/terakilobyte/checker/internal/httpdebug"
+	"github.com/terakilobyte/checker/internal/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// logOnly is --debug-http's value when it's given without a HAR file
+const logOnly = "-"
+
+var debugHTTP string
+
+func init() {
+	rootCmd.Flags().StringVar(&debugHTTP, "debug-http", "", "log every network request and response, and with --debug-http=FILE also write them to a HAR file")
+	rootCmd.Flags().Lookup("debug-http").NoOptDefVal = logOnly
+}
+
+// startDebugHTTP starts recording network requests if --debug-http was given.
+// The returned func writes the HAR file, if one was asked for.
+func startDebugHTTP() func() {
+	if debugHTTP == "" {
+		return func() {}
+	}
+	recorder := &httpdebug.Recorder{}
+	utils.WrapTransport(recorder.Transport)
+	return func() {
+		if debugHTTP == logOnly {
+			return
+		}
+		if err := recorder.WriteHAR(debugHTTP, version); err != nil {
+			log.Warnf("could not write HAR file %s: %v", debugHTTP, err)
+		}
+	}
+}
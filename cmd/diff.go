@@ -0,0 +1,97 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/terakilobyte/checker/internal/compare"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/formats"
+)
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff old-report new-report",
+	Short: "Compares the findings of two runs.",
+	Long: `Compares the findings of two runs, read from their --format jsonl or sarif reports, and prints
+what's newly broken, what was fixed, and what's still broken.
+
+It fails, like a run does, when the new report has findings the old one didn't, so CI can check
+that a change doesn't make things worse without fixing everything that was already broken.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		before := readReport(args[0])
+		after := readReport(args[1])
+		res := compare.Compare(before, after)
+
+		out := cmd.OutOrStdout()
+		printFindings(out, "Newly broken", res.New)
+		printFindings(out, "Fixed", res.Fixed)
+		printFindings(out, "Still broken", res.Still)
+
+		failures := 0
+		for _, d := range res.New {
+			if failing(d) {
+				failures++
+			}
+		}
+		if failures > 0 {
+			log.Error(len(res.New), " new, ", len(res.Fixed), " fixed, and ", len(res.Still), " still broken.\n")
+			exit(exitFindings)
+		}
+		log.Info(len(res.New), " new, ", len(res.Fixed), " fixed, and ", len(res.Still), " still broken.\n")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
+
+func readReport(filename string) []diagnostics.Diagnostic {
+	f, err := os.Open(filename)
+	if err != nil {
+		configFatalf("could not open report: %v", err)
+	}
+	defer f.Close()
+	found, err := formats.Read(f)
+	if err != nil {
+		configFatalf("could not read %s: %v", filename, err)
+	}
+	return found
+}
+
+func printFindings(out io.Writer, heading string, found []diagnostics.Diagnostic) {
+	if len(found) == 0 {
+		return
+	}
+	fmt.Fprintf(out, "%s (%d):\n", heading, len(found))
+	for _, d := range found {
+		fmt.Fprintf(out, "  %s: %s\n", d.Severity, d)
+	}
+	fmt.Fprintln(out)
+}
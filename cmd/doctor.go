@@ -0,0 +1,148 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/terakilobyte/checker/internal/sources"
+	"github.com/terakilobyte/checker/internal/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// doctorCheck is one thing checker needs from its environment, and what to do
+// when it's missing.
+type doctorCheck struct {
+	name   string
+	run    func() error
+	remedy string
+}
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Checks that checker can run here.",
+	Long: `Checks everything checker needs before it can check a project: a readable snooty.toml, reachable intersphinx
+inventories, a fetchable rstspec.toml, git, and a writable cache directory.
+
+Each failed check is printed with what to do about it, and doctor exits with an error if any failed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		basepath, err := filepath.Abs(path)
+		checkErr(err)
+
+		failed := 0
+		for _, check := range doctorChecks(basepath) {
+			if err := check.run(); err != nil {
+				failed++
+				fmt.Printf("FAIL %s: %v\n     %s\n", check.name, err, check.remedy)
+				continue
+			}
+			fmt.Printf("ok   %s\n", check.name)
+		}
+		if failed > 0 {
+			log.Errorf("%d of checker's requirements are not met", failed)
+			exit(exitInfrastructure)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func doctorChecks(basepath string) []doctorCheck {
+	snootyPath := filepath.Join(basepath, "snooty.toml")
+	var projectSnooty *sources.TomlConfig
+
+	return []doctorCheck{{
+		name: "snooty.toml is readable",
+		run: func() error {
+			data, err := ioutil.ReadFile(snootyPath)
+			if err != nil {
+				return err
+			}
+			projectSnooty, err = sources.NewTomlConfig(data)
+			return err
+		},
+		remedy: "run checker from inside the project or pass --path, and make sure snooty.toml is valid toml",
+	}, {
+		name: "intersphinx inventories are reachable",
+		run: func() error {
+			if projectSnooty == nil {
+				return fmt.Errorf("snooty.toml could not be read")
+			}
+			for _, inventory := range projectSnooty.Intersphinx {
+				if _, err := utils.FetchNetworkFile(inventory); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		remedy: "check your network connection and the intersphinx urls in snooty.toml",
+	}, {
+		name: "rstspec.toml is fetchable",
+		run: func() error {
+			if rstSpecFile != "" {
+				_, err := ioutil.ReadFile(rstSpecFile)
+				return err
+			}
+			rstSpec, err := rstSpecURL(rstSpecVersion(loadCheckerConfig(basepath)))
+			if err != nil {
+				return err
+			}
+			_, err = utils.FetchNetworkFile(rstSpec)
+			return err
+		},
+		remedy: "check that github.com and raw.githubusercontent.com are reachable, that you aren't rate limited (set GITHUB_TOKEN), and that any pinned --rstspec-version exists",
+	}, {
+		name: "git is available",
+		run: func() error {
+			_, err := exec.LookPath("git")
+			return err
+		},
+		remedy: "install git and make sure it's on your PATH",
+	}, {
+		name: "cache directory is writable",
+		run: func() error {
+			dir, err := utils.CacheDir()
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			f, err := ioutil.TempFile(dir, "doctor")
+			if err != nil {
+				return err
+			}
+			f.Close()
+			return os.Remove(f.Name())
+		},
+		remedy: "set XDG_CACHE_HOME (or HOME) to a directory you can write to",
+	}}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"time"
+)
+
+var dryRun bool
+
+// checkPlan is what a run would check, for --dry-run.
+type checkPlan struct {
+	refs int
+	urls map[string]bool
+	// jobs is how many jobs would be handed to the workers
+	jobs int
+}
+
+// plan is filled in by runChecks, across every project checked
+var plan = newCheckPlan()
+
+func init() {
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "collect everything and print what would be checked, without checking links")
+}
+
+func newCheckPlan() checkPlan {
+	return checkPlan{urls: make(map[string]bool)}
+}
+
+// hosts counts the distinct hosts of the planned urls.
+func (p checkPlan) hosts() int {
+	hosts := make(map[string]bool)
+	for u := range p.urls {
+		if parsed, err := url.Parse(u); err == nil {
+			hosts[parsed.Hostname()] = true
+		}
+	}
+	return len(hosts)
+}
+
+// estimateDuration is how long workers take to get through jobs at the
+// throttle, ignoring how long the requests themselves take. Each worker waits
+// workers/throttle seconds before each of its jobs.
+func estimateDuration(jobs int, workers int, throttle int) time.Duration {
+	if jobs == 0 || workers <= 0 || throttle <= 0 {
+		return 0
+	}
+	perWorker := math.Ceil(float64(jobs) / float64(workers))
+	return time.Duration(perWorker * float64(workers) / float64(throttle) * float64(time.Second))
+}
+
+// printPlan prints the plan to stderr. A dry run writes no report, so stdout
+// is left empty rather than holding something that isn't one.
+func printPlan() {
+	fmt.Fprintf(os.Stderr, "Would check %d refs and %d unique urls across %d hosts.\n", plan.refs, len(plan.urls), plan.hosts())
+	fmt.Fprintf(os.Stderr, "At %d workers and a throttle of %d, validating them would take at least %s.\n", workers, throttle, estimateDuration(plan.jobs, workers, throttle).Round(time.Second))
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Exit codes, so CI can tell broken docs from checker being unable to run.
+const (
+	exitClean = 0
+	// exitFindings means the docs have problems that fail the run
+	exitFindings = 1
+	// exitConfig means checker was run or configured wrong, like a bad flag or snooty.toml
+	exitConfig = 2
+	// exitInfrastructure means something checker depends on, like an
+	// intersphinx inventory or rstspec.toml, couldn't be reached
+	exitInfrastructure = 3
+)
+
+// exit runs logrus' exit handlers, like log.Fatal does, and exits with code.
+func exit(code int) {
+	log.StandardLogger().Exit(code)
+}
+
+// configFatalf logs a problem with how checker was run or configured, and exits.
+func configFatalf(format string, args ...interface{}) {
+	fatal(exitConfig, fmt.Sprintf(format, args...))
+}
+
+// infraFatalf logs a failure to reach something checker depends on, and exits.
+func infraFatalf(format string, args ...interface{}) {
+	fatal(exitInfrastructure, fmt.Sprintf(format, args...))
+}
+
+// fatalError is a failure that would have exited checker with code, raised
+// instead while catchFatal is running.
+type fatalError struct {
+	code    int
+	message string
+}
+
+func (e fatalError) Error() string {
+	return e.message
+}
+
+// catching is whether catchFatal is running, so fatal panics rather than
+// exiting. It's set with sync/atomic, since serve answers calls concurrently.
+var catching int32
+
+func fatal(code int, message string) {
+	if atomic.LoadInt32(&catching) == 1 {
+		panic(fatalError{code: code, message: message})
+	}
+	log.Error(message)
+	exit(code)
+}
+
+// catchFatal runs fn, returning what would have made checker exit, or panic,
+// as an error instead, for serve, which has to keep answering calls after one
+// fails. Only failures on fn's goroutine are caught.
+func catchFatal(fn func()) (err error) {
+	hooks := make(log.LevelHooks)
+	for level, levelHooks := range log.StandardLogger().Hooks {
+		hooks[level] = append([]log.Hook(nil), levelHooks...)
+	}
+	logger := log.StandardLogger()
+	exitFunc := logger.ExitFunc
+	// log.Fatal calls that haven't been replaced by configFatalf and
+	// infraFatalf yet exit through the logger
+	logger.ExitFunc = func(code int) {
+		panic(fatalError{code: code, message: "checker failed, see its log"})
+	}
+	atomic.StoreInt32(&catching, 1)
+	defer func() {
+		atomic.StoreInt32(&catching, 0)
+		logger.ExitFunc = exitFunc
+		r := recover()
+		if r == nil {
+			return
+		}
+		// fn didn't get to remove the hooks it added, like --strict's
+		logger.ReplaceHooks(hooks)
+		switch failure := r.(type) {
+		case fatalError:
+			err = failure
+		case *log.Entry:
+			err = fmt.Errorf("%s", failure.Message)
+		default:
+			err = fmt.Errorf("%v", failure)
+		}
+	}()
+	fn()
+	return nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+var (
+	failOn      string
+	maxFailures int
+	// errorOn and warnOn are reasons or categories whose findings are
+	// errors or warnings whatever their checks say
+	errorOn []string
+	warnOn  []string
+	// warnExternal reports failed external links as warnings, since a site
+	// going away isn't the fault of whoever's changing the docs
+	warnExternal bool
+	// aborted is set once maxFailures is reached, and remaining work is skipped
+	aborted int32
+)
+
+func validateFailOn() error {
+	switch failOn {
+	case "error", "warning", "none":
+		return nil
+	default:
+		return fmt.Errorf("--fail-on must be one of error, warning, or none, got %s", failOn)
+	}
+}
+
+// severityScopes are what --error-on and --warn-on choose from: every reason
+// and every category.
+func severityScopes() []string {
+	var scopes []string
+	for _, reason := range diagnostics.Reasons {
+		scopes = append(scopes, string(reason))
+	}
+	for _, category := range diagnostics.Categories {
+		scopes = append(scopes, string(category))
+	}
+	return scopes
+}
+
+func validateSeverityScopes() error {
+	scopes := severityScopes()
+	for _, scope := range append(append([]string{}, errorOn...), warnOn...) {
+		if !containsExactly(scopes, scope) {
+			return fmt.Errorf("%s is not a reason or category, choose from %s", scope, strings.Join(scopes, ", "))
+		}
+	}
+	for _, scope := range errorOn {
+		if containsExactly(warnOn, scope) {
+			return fmt.Errorf("%s can't be in both --error-on and --warn-on", scope)
+		}
+	}
+	return nil
+}
+
+// rescope sets d's severity from --error-on, --warn-on, and --warn-external. Its reason is
+// matched before its category, so --warn-on link --error-on 4xx only fails
+// on links that are gone.
+func rescope(d diagnostics.Diagnostic) diagnostics.Diagnostic {
+	warnOn := warnOn
+	if warnExternal {
+		warnOn = append([]string{string(diagnostics.Link), string(diagnostics.Certificate)}, warnOn...)
+	}
+	return d.Rescope(errorOn, warnOn)
+}
+
+// failing reports whether d should fail the run, given --fail-on.
+func failing(d diagnostics.Diagnostic) bool {
+	switch failOn {
+	case "none":
+		return false
+	case "warning":
+		return true
+	default:
+		return d.Severity == diagnostics.Error
+	}
+}
+
+// countFailure records a failing diagnostic, aborting the run at --max-failures.
+func countFailure(failures *int) {
+	*failures++
+	if maxFailures > 0 && *failures >= maxFailures {
+		atomic.StoreInt32(&aborted, 1)
+	}
+}
+
+func isAborted() bool {
+	return atomic.LoadInt32(&aborted) == 1
+}
@@ -0,0 +1,253 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/spf13/cobra"
+	"github.com/terakilobyte/checker/internal/collectors"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/fix"
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+var (
+	fixRedirects bool
+	fixDryRun    bool
+	interactive  bool
+)
+
+// fixCmd represents the fix command
+var fixCmd = &cobra.Command{
+	Use:   "fix",
+	Short: "Rewrites source files to fix common problems.",
+	Long: `Rewrites source files to fix common problems.
+
+With --redirects, every http link that is permanently redirected (301 or 308) is replaced
+with the url the redirects end up at, once that url is checked like any other link, so a link
+isn't replaced with a page that's gone or a login page. Use --dry-run to preview the changes
+without writing them.
+
+With -i, every finding that has a candidate fix (a suggested ref or role, a deprecated target's
+replacement, an https upgrade that was checked to work, or a redirect target when combined with
+--redirects) is presented with its context, and the fixes you accept are applied to the source
+files. A fix to a role changes only roles of the same name, and only on the finding's line when
+it has one.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !fixRedirects && !interactive {
+			configFatalf("nothing to fix, pass --redirects or -i")
+		}
+
+		basepath, err := filepath.Abs(path)
+		checkErr(err)
+		files := gatherFiles(basepath)
+		if len(changes) != 0 {
+			changed := make([]string, 0)
+			for _, file := range files {
+				if contains(changes, strings.TrimPrefix(strings.Replace(file, basepath, "", 1), "/")) {
+					changed = append(changed, file)
+				}
+			}
+			files = changed
+		}
+
+		moved := make(map[rst.RstHTTPLink]rst.RstHTTPLink)
+		if fixRedirects {
+			configureLinkChecks(loadCheckerConfig(basepath))
+			moved = resolveMoved(files)
+		}
+
+		if interactive {
+			found := movedDiagnostics(basepath, files, moved)
+			found = append(found, runChecks()...)
+			fixInteractively(cmd.InOrStdin(), cmd.OutOrStdout(), basepath, found)
+			return
+		}
+
+		fixed := 0
+		for _, file := range files {
+			data, err := ioutil.ReadFile(file)
+			checkErr(err)
+			filename := strings.Replace(file, basepath, "", 1)
+			updated := rst.ReplaceHTTPLinks(data, func(link rst.RstHTTPLink) rst.RstHTTPLink {
+				to, ok := moved[link]
+				if !ok {
+					return link
+				}
+				fmt.Printf("%s: %s -> %s\n", filename, link, to)
+				fixed++
+				return to
+			})
+			if fixDryRun || bytes.Equal(data, updated) {
+				continue
+			}
+			checkErr(ioutil.WriteFile(file, updated, 0644))
+		}
+
+		if fixDryRun {
+			log.Infof("%d links would be updated.\n", fixed)
+		} else {
+			log.Infof("%d links updated.\n", fixed)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fixCmd)
+	fixCmd.Flags().BoolVar(&fixRedirects, "redirects", false, "replace permanently redirected links with their destination")
+	fixCmd.Flags().BoolVar(&fixDryRun, "dry-run", false, "print the changes that would be made without writing them")
+	fixCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "review each finding and choose which fixes to apply")
+}
+
+// resolveMoved returns where each permanently redirected link in files ends
+// up, when that's a valid link. A redirect to a page that's gone, a soft 404,
+// or a login wall isn't somewhere to link to instead.
+func resolveMoved(files []string) map[rst.RstHTTPLink]rst.RstHTTPLink {
+	moved := sync.Map{}
+	workStack := make([]func(), 0)
+	links, err := collectors.GatherHTTPLinks(files)
+	checkErr(err)
+	for link := range links {
+		workFunc := func(link rst.RstHTTPLink) func() {
+			return func() {
+				to, err := utils.ResolvePermanentRedirect(string(link))
+				if err != nil {
+					log.Warnf("could not resolve %s: %v", link, err)
+					return
+				}
+				if to == string(link) {
+					return
+				}
+				if res := checkLink(to); !res.OK {
+					log.Warnf("not replacing %s, it redirects to %s, which isn't valid: %v", link, to, res.Err)
+					return
+				}
+				moved.Store(link, rst.RstHTTPLink(to))
+			}
+		}
+		workStack = append(workStack, workFunc(link))
+	}
+	validate(workStack)
+
+	result := make(map[rst.RstHTTPLink]rst.RstHTTPLink)
+	moved.Range(func(k, v interface{}) bool {
+		result[k.(rst.RstHTTPLink)] = v.(rst.RstHTTPLink)
+		return true
+	})
+	return result
+}
+
+// movedDiagnostics reports each use of a moved link, per file, so it can be fixed interactively.
+func movedDiagnostics(basepath string, files []string, moved map[rst.RstHTTPLink]rst.RstHTTPLink) []diagnostics.Diagnostic {
+	found := make([]diagnostics.Diagnostic, 0)
+	if len(moved) == 0 {
+		return found
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		checkErr(err)
+		filename := strings.Replace(file, basepath, "", 1)
+		seen := make(map[rst.RstHTTPLink]bool)
+		for _, link := range rst.ParseForHTTPLinks(data) {
+			to, ok := moved[link]
+			if !ok || seen[link] {
+				continue
+			}
+			seen[link] = true
+			found = append(found, diagnostics.Warnf(diagnostics.Link, filename, "%s is permanently redirected to %s", link, to).WithTarget(string(link)).WithFix(string(to)))
+		}
+	}
+	return found
+}
+
+// fixInteractively prompts for a fix for each diagnostic that has candidates, like git add -p.
+func fixInteractively(in io.Reader, out io.Writer, basepath string, found []diagnostics.Diagnostic) {
+	reader := bufio.NewReader(in)
+	applied := 0
+	defer func() {
+		log.Infof("%d fixes applied.\n", applied)
+	}()
+
+	for _, d := range found {
+		file := filepath.Join(basepath, d.File)
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			// shared includes and the like aren't in the project
+			continue
+		}
+		edits := make([]fix.Edit, 0)
+		for _, edit := range fix.Candidates(d) {
+			if !fix.Changed(data, edit) {
+				continue
+			}
+			// an https upgrade is only a fix if the site serves https
+			if edit.Upgrade() && !checkLink(edit.New).OK {
+				continue
+			}
+			edits = append(edits, edit)
+		}
+		if len(edits) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(out, "\n%s\n", d)
+		for _, line := range fix.Context(data, d.Target, 2) {
+			fmt.Fprintln(out, line)
+		}
+		for i, edit := range edits {
+			fmt.Fprintf(out, "  [%d] %s\n", i+1, edit)
+		}
+
+		for {
+			fmt.Fprintf(out, "Apply fix [1-%d], s to skip, q to quit? ", len(edits))
+			answer, err := reader.ReadString('\n')
+			answer = strings.TrimSpace(answer)
+			if answer == "q" || (err != nil && answer == "") {
+				return
+			}
+			if answer == "s" || answer == "" {
+				break
+			}
+			choice, convErr := strconv.Atoi(answer)
+			if convErr != nil || choice < 1 || choice > len(edits) {
+				fmt.Fprintf(out, "%q is not a choice\n", answer)
+				continue
+			}
+			updated, _ := fix.Apply(data, edits[choice-1])
+			checkErr(ioutil.WriteFile(file, updated, 0644))
+			applied++
+			break
+		}
+	}
+}
@@ -0,0 +1,219 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/mattn/go-isatty"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/events"
+	"github.com/terakilobyte/checker/internal/formats"
+	"github.com/terakilobyte/checker/internal/progress"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// formatNames are the --format values. human is another name for text.
+var formatNames = []string{"text", "human", "jsonl", "sarif", "junit", "template"}
+
+// sink is a report written at the end of the run, in format, to out.
+type sink struct {
+	format string
+	out    io.Writer
+	path   string
+	// tmpl renders the template format
+	tmpl *template.Template
+}
+
+var (
+	outputFormats []string
+	outputFile    string
+	// progressEvents is where progress is reported as JSON lines, if anywhere
+	progressEvents string
+	sinks          []sink
+	// logFindings is false when findings would get in the way of a machine
+	// readable report on stdout, unless text is asked for too
+	logFindings = true
+	// emitter is nil unless --format includes jsonl, and emits nothing when nil
+	emitter *events.Emitter
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringArrayVar(&outputFormats, "format", []string{"text"}, "output format, repeatable, with an optional file like sarif=report.sarif: text (or human) logs, jsonl to stream an event per finding and stage, sarif, junit, or template=report.tmpl to render a Go template")
+	rootCmd.PersistentFlags().StringVar(&progressEvents, "progress-events", "", "also report the progress of each stage as JSON lines to stderr, an inherited file descriptor like fd:3, or a file")
+	rootCmd.Flags().StringVar(&outputFile, "output", "", "write the report of the one --format without a file to this file, keeping the human summary on the console")
+}
+
+// configureOutput applies --format, --output, and --progress. Each format
+// goes to its own file, or to stdout, which only one can have. Text without a
+// file is the console logs. With a report on stdout, progress goes to stderr.
+func configureOutput() error {
+	var progressOut io.Writer = os.Stdout
+	pathless := 0
+	for _, value := range outputFormats {
+		if _, path := splitFormat(value); path == "" {
+			pathless++
+		}
+	}
+	if outputFile != "" && pathless != 1 {
+		return fmt.Errorf("--output needs exactly one --format without a file, got %d", pathless)
+	}
+	logFindings = false
+	onStdout := ""
+	for _, value := range outputFormats {
+		name, path := splitFormat(value)
+		if !containsExactly(formatNames, name) {
+			return fmt.Errorf("unknown format %q, expected %s", name, strings.Join(formatNames, ", "))
+		}
+		if name == "human" {
+			name = "text"
+		}
+		var tmpl *template.Template
+		if name == "template" {
+			_, rest, _ := strings.Cut(value, "=")
+			templateFile, _, _ := strings.Cut(rest, "=")
+			if templateFile == "" {
+				return fmt.Errorf("the template format needs a template, like template=report.tmpl")
+			}
+			var err error
+			if tmpl, err = formats.ParseTemplate(templateFile); err != nil {
+				return fmt.Errorf("could not parse template: %v", err)
+			}
+		}
+		if path == "" {
+			path = outputFile
+		}
+		if name == "text" && path == "" {
+			logFindings = true
+			continue
+		}
+		var out io.Writer = os.Stdout
+		if path != "" {
+			f, err := os.Create(path)
+			if err != nil {
+				return fmt.Errorf("could not create the %s report: %v", name, err)
+			}
+			out = f
+		} else {
+			if onStdout != "" {
+				return fmt.Errorf("%s and %s can't both write to stdout, give one a file like %s=report", onStdout, name, name)
+			}
+			onStdout = name
+			progressOut = os.Stderr
+		}
+		if name == "jsonl" {
+			if emitter != nil {
+				return fmt.Errorf("jsonl can only be asked for once")
+			}
+			emitter = events.New(out)
+			continue
+		}
+		sinks = append(sinks, sink{format: name, out: out, path: path, tmpl: tmpl})
+	}
+	if onStdout == "" {
+		logFindings = true
+	}
+	if showProgress {
+		f, ok := progressOut.(*os.File)
+		reporter = progress.New(progressOut, ok && (isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())))
+	}
+	if progressEvents != "" {
+		out, err := openProgressEvents(progressEvents)
+		if err != nil {
+			return err
+		}
+		if reporter != nil {
+			reporter.WithEvents(out)
+		} else {
+			reporter = progress.NewEvents(out)
+		}
+	}
+	return nil
+}
+
+// openProgressEvents opens where --progress-events go: stderr, an inherited
+// file descriptor like fd:3, or a file.
+func openProgressEvents(dest string) (io.Writer, error) {
+	if dest == "stderr" {
+		return os.Stderr, nil
+	}
+	if fd := strings.TrimPrefix(dest, "fd:"); fd != dest {
+		n, err := strconv.Atoi(fd)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("--progress-events needs a file descriptor number, like fd:3, got %s", dest)
+		}
+		return os.NewFile(uintptr(n), "fd"+fd), nil
+	}
+	f, err := os.Create(dest)
+	if err != nil {
+		return nil, fmt.Errorf("could not create the progress events file: %v", err)
+	}
+	return f, nil
+}
+
+// splitFormat splits a --format value into its format and file. The template
+// format's value names its template first, like template=wiki.tmpl=wiki.md.
+func splitFormat(value string) (name string, path string) {
+	name, path, _ = strings.Cut(value, "=")
+	if name == "template" {
+		_, path, _ = strings.Cut(path, "=")
+	}
+	return name, path
+}
+
+// writeReports writes every report asked for. Failing to is only a warning,
+// so a report can't fail a run that otherwise passed.
+func writeReports(found []diagnostics.Diagnostic) {
+	for _, s := range sinks {
+		var err error
+		switch s.format {
+		case "text":
+			err = formats.Text(s.out, found)
+		case "sarif":
+			err = formats.SARIF(s.out, version, found)
+		case "junit":
+			err = formats.JUnit(s.out, found)
+		case "template":
+			err = formats.Template(s.out, s.tmpl, version, found)
+		}
+		if closer, ok := s.out.(io.Closer); ok && s.path != "" {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			log.Warnf("could not write the %s report: %v", s.format, err)
+		}
+	}
+}
+
+// stage starts the next stage of the run.
+func stage(name string) {
+	tracer.Stage(name)
+	emitter.Stage(name)
+}
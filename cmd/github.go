@@ -0,0 +1,149 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/terakilobyte/checker/internal/compare"
+	"github.com/terakilobyte/checker/internal/githubreport"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	githubToken   string
+	githubRepo    string
+	githubSHA     string
+	githubPR      int
+	githubCheck   bool
+	githubComment bool
+	githubBase    string
+
+	pullRefRegex = regexp.MustCompile(`^refs/pull/(\d+)/`)
+)
+
+// githubCmd represents the github command
+var githubCmd = &cobra.Command{
+	Use:   "github",
+	Short: "Checks the project and reports the results on GitHub.",
+	Long: `Checks the project like checker does, then reports what it found on GitHub, so reviewers don't have to open CI logs.
+
+By default it creates a check run on the commit, annotating each problem inline. With --comment it also posts a comment
+summarizing the problems on the pull request, and updates that comment on later runs instead of adding another.
+
+In GitHub Actions the token, repository, commit, and pull request are read from GITHUB_TOKEN, GITHUB_REPOSITORY,
+GITHUB_SHA, and GITHUB_REF. Combine with --changes to only report problems in the files a pull request changed.
+
+With --baseline, a report from an earlier run like one written with --format jsonl, only the problems that report doesn't
+have are posted, so a pull request isn't blamed for links that were already broken. The exit code still covers every
+problem found; use checker diff to fail only on new ones.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if githubToken == "" {
+			// not a flag default, so --help doesn't print the token
+			githubToken = os.Getenv("GITHUB_TOKEN")
+		}
+		if githubToken == "" {
+			configFatalf("a GitHub token is required, set --token or GITHUB_TOKEN")
+		}
+		owner, repo, ok := strings.Cut(githubRepo, "/")
+		if !ok {
+			configFatalf("--repo must be owner/name, got %q", githubRepo)
+		}
+		if githubCheck && githubSHA == "" {
+			configFatalf("a commit is required for a check run, set --sha or GITHUB_SHA")
+		}
+		if githubComment && githubPR == 0 {
+			configFatalf("a pull request is required to comment, set --pr")
+		}
+
+		found := runChecks()
+		reported := found
+		if githubBase != "" {
+			reported = compare.Compare(readReport(githubBase), found).New
+		}
+		failed := false
+		for _, d := range reported {
+			failed = failed || failing(d)
+		}
+		r := githubreport.Report{Owner: owner, Repo: repo, Dir: repoDir(), Found: reported, New: githubBase != "", Failed: failed}
+		client := githubreport.NewClient(githubToken)
+		ctx := context.Background()
+		if githubCheck {
+			if err := githubreport.PublishCheckRun(ctx, client, githubSHA, r); err != nil {
+				infraFatalf("could not create the check run: %v", err)
+			}
+		}
+		if githubComment {
+			if err := githubreport.UpsertComment(ctx, client, githubPR, r); err != nil {
+				infraFatalf("could not comment on the pull request: %v", err)
+			}
+		}
+		report(found)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(githubCmd)
+	githubCmd.Flags().StringVar(&githubToken, "token", "", "GitHub token, defaults to GITHUB_TOKEN")
+	githubCmd.Flags().StringVar(&githubRepo, "repo", os.Getenv("GITHUB_REPOSITORY"), "repository as owner/name, defaults to GITHUB_REPOSITORY")
+	githubCmd.Flags().StringVar(&githubSHA, "sha", os.Getenv("GITHUB_SHA"), "commit to create the check run on, defaults to GITHUB_SHA")
+	githubCmd.Flags().IntVar(&githubPR, "pr", pullRequestFromRef(os.Getenv("GITHUB_REF")), "pull request to comment on, defaults to the one in GITHUB_REF")
+	githubCmd.Flags().BoolVar(&githubCheck, "check", true, "create a check run with inline annotations")
+	githubCmd.Flags().BoolVar(&githubComment, "comment", false, "post or update a summary comment on the pull request")
+	githubCmd.Flags().StringVar(&githubBase, "baseline", "", "report from an earlier run, only problems it doesn't have are posted")
+}
+
+// pullRequestFromRef returns the pull request number in a ref like
+// refs/pull/12/merge, or 0 if ref isn't a pull request.
+func pullRequestFromRef(ref string) int {
+	matches := pullRefRegex.FindStringSubmatch(ref)
+	if matches == nil {
+		return 0
+	}
+	number, _ := strconv.Atoi(matches[1])
+	return number
+}
+
+// repoDir is the project's directory relative to the root of its git
+// repository, where GitHub expects annotation paths to start.
+func repoDir() string {
+	basepath, err := filepath.Abs(path)
+	checkErr(err)
+	out, err := exec.Command("git", "-C", basepath, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		log.Warnf("could not find the git repository of %s, assuming it's the root: %v", basepath, err)
+		return ""
+	}
+	dir, err := filepath.Rel(strings.TrimSpace(string(out)), basepath)
+	if err != nil || dir == "." {
+		return ""
+	}
+	return filepath.ToSlash(dir)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/terakilobyte/checker/internal/collectors"
+)
+
+var graphFormat string
+
+// graphCmd represents the graph command
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Prints the include and doc reference graph of the project.",
+	Long: `Prints the graph of includes, shared includes, and doc references between files in the project, in DOT or JSON.
+
+Render the DOT output with graphviz, for example checker graph | dot -Tsvg > graph.svg. The JSON output lists each
+file with its fan-in, the number of files that depend on it, so heavily included files are easy to find.`,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		if graphFormat != "dot" && graphFormat != "json" {
+			configFatalf("unknown graph format %q, expected dot or json", graphFormat)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		basepath, err := filepath.Abs(path)
+		checkErr(err)
+		edges, err := collectors.GatherGraph(gatherFiles(basepath))
+		checkErr(err)
+		if graphFormat == "json" {
+			checkErr(writeGraphJSON(os.Stdout, edges))
+			return
+		}
+		checkErr(writeGraphDOT(os.Stdout, edges))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+	graphCmd.Flags().StringVar(&graphFormat, "format", "dot", "output format, dot or json")
+}
+
+type graphNode struct {
+	ID    string `json:"id"`
+	FanIn int    `json:"fanIn"`
+}
+
+func writeGraphJSON(w io.Writer, edges []collectors.Edge) error {
+	fanIn := make(map[string]int)
+	for _, edge := range edges {
+		if _, ok := fanIn[edge.From]; !ok {
+			fanIn[edge.From] = 0
+		}
+		fanIn[edge.To]++
+	}
+	nodes := make([]graphNode, 0, len(fanIn))
+	for id, n := range fanIn {
+		nodes = append(nodes, graphNode{ID: id, FanIn: n})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].FanIn != nodes[j].FanIn {
+			return nodes[i].FanIn > nodes[j].FanIn
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Nodes []graphNode       `json:"nodes"`
+		Edges []collectors.Edge `json:"edges"`
+	}{nodes, edges})
+}
+
+var graphEdgeStyles = map[string]string{
+	"include":       "solid",
+	"sharedinclude": "bold",
+	"doc":           "dashed",
+}
+
+func writeGraphDOT(w io.Writer, edges []collectors.Edge) error {
+	if _, err := fmt.Fprintln(w, "digraph checker {"); err != nil {
+		return err
+	}
+	for _, edge := range edges {
+		if _, err := fmt.Fprintf(w, "\t%q -> %q [label=%q, style=%s];\n", edge.From, edge.To, edge.Kind, graphEdgeStyles[edge.Kind]); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/history"
+)
+
+var (
+	historyFile string
+	// flakyURLs are urls that alternated between passing and failing in the
+	// runs in --history
+	flakyURLs    = make(map[string]bool)
+	flakyRetries int
+	historyKeep  int
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history url-or-ref",
+	Short: "Shows how a url or ref fared in each recorded run.",
+	Long: `Shows how a url or ref target fared in each run recorded with --history, and since when it's
+been failing or passing, to tell whether a site broke recently or has been broken all along.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if historyFile == "" {
+			configFatalf("pass the --history file runs were recorded in")
+		}
+		runs, err := openHistory().Runs()
+		if err != nil {
+			configFatalf("could not read history: %v", err)
+		}
+		item := args[0]
+		timeline := history.Timeline(runs, item)
+		if len(timeline) == 0 {
+			log.Infof("%s isn't in any of the %d recorded runs.\n", item, len(runs))
+			return
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TIME\tSTATUS\tFINDING")
+		for _, status := range timeline {
+			if status.Failing {
+				fmt.Fprintf(w, "%s\tfailing\t%s\n", status.Time.Format(time.RFC3339), status.Finding.Message)
+			} else {
+				fmt.Fprintf(w, "%s\tpassing\t\n", status.Time.Format(time.RFC3339))
+			}
+		}
+		checkErr(w.Flush())
+
+		since, streak := history.Since(timeline)
+		state := "passing"
+		if since.Failing {
+			state = "failing"
+		}
+		log.Infof("%s has been %s since %s, %d runs.\n", item, state, since.Time.Format(time.RFC3339), streak)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.PersistentFlags().StringVar(&historyFile, "history", "", "record each run's findings in this file, and read them from it for checker history")
+	rootCmd.PersistentFlags().IntVar(&historyKeep, "history-keep", 100, "with --history, keep only this many of the latest runs, 0 keeps every run")
+	rootCmd.PersistentFlags().IntVar(&flakyRetries, "flaky-retries", 0, "with --history, check links that have been flaky in recent runs up to this many more times before reporting them")
+}
+
+// openHistory opens --history, keeping --history-keep runs.
+func openHistory() *history.Store {
+	store := history.Open(historyFile)
+	store.Keep = historyKeep
+	return store
+}
+
+// loadFlaky finds the urls that have been flaky in the runs in --history.
+func loadFlaky() {
+	if historyFile == "" {
+		return
+	}
+	runs, err := openHistory().Runs()
+	if err != nil {
+		log.Warnf("could not read history: %v", err)
+		return
+	}
+	flakyURLs = history.Flaky(runs)
+}
+
+// flaky reports d, a failure of url, as a flaky warning instead when url has
+// been flaky in recent runs, so nobody chases a breakage that isn't there.
+func flaky(url string, d diagnostics.Diagnostic) diagnostics.Diagnostic {
+	if !flakyURLs[url] || d.Severity != diagnostics.Error {
+		return d
+	}
+	d.Severity = diagnostics.Warning
+	d.Message += ", but it's been flaky in recent runs"
+	return d.WithReason(diagnostics.Flaky)
+}
+
+// recordHistory adds the run's findings to --history, if it's set. scoped is
+// whether only some files were checked.
+func recordHistory(found []diagnostics.Diagnostic, scoped bool) {
+	if historyFile == "" {
+		return
+	}
+	checked := make([]string, 0, len(plan.urls))
+	for u := range plan.urls {
+		checked = append(checked, u)
+	}
+	sort.Strings(checked)
+	run := history.Run{Time: time.Now().UTC(), Scoped: scoped, Checked: checked, Findings: found}
+	if err := openHistory().Record(run); err != nil {
+		log.Warnf("could not record history: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/terakilobyte/checker/internal/parsers/intersphinx"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+// inventory is an intersphinx inventory and the domain it documents.
+type inventory struct {
+	domain  string
+	entries intersphinx.SphinxMap
+}
+
+// fetchedInventories holds each inventory fetched by this process, by url, so
+// a long-lived checker like checker serve only fetches them once
+var fetchedInventories sync.Map
+
+// fetchInventories fetches and parses the intersphinx inventories at urls,
+// all at once, in the order they're given.
+func fetchInventories(urls []string) []inventory {
+	inventories := make([]inventory, len(urls))
+	// failures are reported here, after every fetch is done, so catchFatal
+	// can catch them on its goroutine
+	errs := make([]error, len(urls))
+	var wg sync.WaitGroup
+	fetching := reporter.Start("intersphinx", len(urls))
+	for i, phx := range urls {
+		wg.Add(1)
+		go func(i int, phx string) {
+			defer wg.Done()
+			defer fetching.Increment()
+			if inv, ok := fetchedInventories.Load(phx); ok {
+				inventories[i] = inv.(inventory)
+				return
+			}
+			domain := strings.Split(phx, "objects.inv")[0]
+			file, err := utils.FetchNetworkFile(phx)
+			if err != nil {
+				errs[i] = fmt.Errorf("could not fetch intersphinx inventory %s: %v", phx, err)
+				return
+			}
+			inv := inventory{domain: domain, entries: intersphinx.Intersphinx(file, domain)}
+			fetchedInventories.Store(phx, inv)
+			inventories[i] = inv
+		}(i, phx)
+	}
+	wg.Wait()
+	fetching.Finish()
+	for _, err := range errs {
+		if err != nil {
+			infraFatalf("%v", err)
+		}
+	}
+	return inventories
+}
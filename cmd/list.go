@@ -0,0 +1,138 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/terakilobyte/checker/internal/collectors"
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+)
+
+var (
+	listDomain string
+	listUnused bool
+	listFile   string
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists what checker finds in the project, without checking it.",
+}
+
+// listLinksCmd represents the list links command
+var listLinksCmd = &cobra.Command{
+	Use:   "links",
+	Short: "Lists every external link and the files that use it.",
+	Long: `Lists every external link in the project, followed by the files that use it, without fetching anything.
+
+Use --domain to only list links to a domain or its subdomains, for example when auditing or replacing a domain.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		basepath, err := filepath.Abs(path)
+		checkErr(err)
+		uses, err := collectors.GatherHTTPLinkUses(gatherFiles(basepath))
+		checkErr(err)
+
+		links := make([]rst.RstHTTPLink, 0, len(uses))
+		for link := range uses {
+			if listDomain == "" || onDomain(string(link), listDomain) {
+				links = append(links, link)
+			}
+		}
+		sort.Slice(links, func(i, j int) bool { return links[i] < links[j] })
+
+		for _, link := range links {
+			fmt.Println(link)
+			for _, filename := range uses[link] {
+				fmt.Printf("\t%s\n", filename)
+			}
+		}
+	},
+}
+
+// listRefsCmd represents the list refs command
+var listRefsCmd = &cobra.Command{
+	Use:   "refs",
+	Short: "Lists every local ref target and the files that refer to it.",
+	Long: `Lists every ref target defined in the project, where it is defined, and the files that refer to it with :ref:.
+
+Use --file to only list the targets defined in one file, for example before renaming its anchors, and --unused to
+only list targets nothing refers to.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		basepath, err := filepath.Abs(path)
+		checkErr(err)
+		files := gatherFiles(basepath)
+		targets, err := collectors.GatherLocalRefs(files)
+		checkErr(err)
+		uses, err := collectors.GatherRefUses(files)
+		checkErr(err)
+		definedIn := ""
+		if listFile != "" {
+			definedIn = "/" + strings.TrimPrefix(filepath.ToSlash(listFile), "/")
+		}
+
+		names := make([]string, 0, len(targets))
+		for target, filename := range targets {
+			if definedIn != "" && filename != definedIn {
+				continue
+			}
+			if listUnused && len(uses[target.Name]) > 0 {
+				continue
+			}
+			names = append(names, target.Name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			fmt.Printf("%s (%s)\n", name, targets[rst.RefTarget{Name: name}])
+			for _, filename := range uses[name] {
+				fmt.Printf("\t%s\n", filename)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	listCmd.AddCommand(listLinksCmd)
+	listLinksCmd.Flags().StringVar(&listDomain, "domain", "", "only list links to this domain and its subdomains")
+	listCmd.AddCommand(listRefsCmd)
+	listRefsCmd.Flags().BoolVar(&listUnused, "unused", false, "only list ref targets nothing refers to")
+	listRefsCmd.Flags().StringVar(&listFile, "file", "", "only list ref targets defined in this file, relative to the project")
+}
+
+// onDomain reports whether link points at domain or one of its subdomains.
+func onDomain(link string, domain string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
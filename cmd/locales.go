@@ -0,0 +1,77 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/terakilobyte/checker/internal/collectors"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+var locales []string
+
+// localesCmd represents the locales command
+var localesCmd = &cobra.Command{
+	Use:   "locales",
+	Short: "Compares the project's source with its translations.",
+	Long: `Compares the project's source tree with translated trees that mirror it, like source/zh-cn,
+reporting pages and labels that exist in one but not the other, and links a page has that its
+translation doesn't, or the other way around.
+
+Name each translated tree's directory with --locale.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(locales) == 0 {
+			configFatalf("name at least one translated tree with --locale, like --locale zh-cn")
+		}
+		basepath, err := filepath.Abs(path)
+		checkErr(err)
+		for _, locale := range locales {
+			if _, err := os.Stat(filepath.Join(basepath, "source", locale)); err != nil {
+				configFatalf("could not find the %s translation: %v", locale, err)
+			}
+		}
+		files := gatherFiles(basepath)
+		source, err := collectors.GatherLocaleTree(files, "", locales)
+		checkErr(err)
+
+		diags, finish := newDiagnostics()
+		for _, locale := range locales {
+			translated, err := collectors.GatherLocaleTree(files, locale, locales)
+			checkErr(err)
+			for _, d := range source.Missing(translated) {
+				diags <- diagnostics.Warnf(diagnostics.Locale, d.File, "%s %s is not in the %s translation", d.Kind, d.Name, locale).WithTarget(d.Name)
+			}
+			for _, d := range translated.Missing(source) {
+				diags <- diagnostics.Warnf(diagnostics.Locale, d.File, "%s %s is in the %s translation but not the source", d.Kind, d.Name, locale).WithTarget(d.Name)
+			}
+		}
+		report(finish())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(localesCmd)
+	localesCmd.Flags().StringSliceVar(&locales, "locale", nil, "directory of source holding a translated tree, like zh-cn; repeat or separate with commas for several")
+}
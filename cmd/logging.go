@@ -0,0 +1,102 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	logLevel  string
+	logFormat string
+	logFile   string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "the least severe logs to show: trace, debug, info, warn, error, or fatal")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "log format, text or json. With --log-file, only the file uses it")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "also write logs to this file, in --log-format, while the terminal keeps text logs")
+}
+
+// fileHook writes every log entry to a file, in its own format.
+type fileHook struct {
+	mu        sync.Mutex
+	file      *os.File
+	formatter log.Formatter
+}
+
+func (h *fileHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *fileHook) Fire(entry *log.Entry) error {
+	line, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err = h.file.Write(line)
+	return err
+}
+
+func formatter(format string) (log.Formatter, error) {
+	switch format {
+	case "text":
+		return &log.TextFormatter{}, nil
+	case "json":
+		return &log.JSONFormatter{}, nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, expected text or json", format)
+}
+
+// configureLogging applies --log-level, --log-format, and --log-file.
+func configureLogging() error {
+	level, err := log.ParseLevel(logLevel)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(level)
+
+	f, err := formatter(logFormat)
+	if err != nil {
+		return err
+	}
+	if logFile == "" {
+		log.SetFormatter(f)
+		return nil
+	}
+	if _, ok := f.(*log.TextFormatter); ok {
+		// no colors in files
+		f = &log.TextFormatter{DisableColors: true}
+	}
+	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	log.AddHook(&fileHook{file: file, formatter: f})
+	return nil
+}
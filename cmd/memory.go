@@ -0,0 +1,91 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+var (
+	memoryLimit string
+	// batchSize is how many files are checked at a time, or 0 for all of them
+	batchSize int
+)
+
+// bytesPerFile is roughly what checking a file holds onto, generously, so
+// batches sized by it leave room under the limit for the index of every
+// file's refs and for responses being read.
+const bytesPerFile = 256 << 10
+
+func init() {
+	rootCmd.Flags().StringVar(&memoryLimit, "memory-limit", "", "soft memory limit, like 512MiB or 2GiB, defaults to GOMEMLIMIT. Large projects are checked in batches of files to stay under it")
+}
+
+// configureMemoryLimit applies --memory-limit, or else GOMEMLIMIT, and sizes
+// the batches files are checked in to fit under it.
+func configureMemoryLimit() error {
+	limit, name := memoryLimit, "--memory-limit"
+	if limit == "" {
+		limit, name = os.Getenv("GOMEMLIMIT"), "GOMEMLIMIT"
+	}
+	if limit == "" || limit == "off" {
+		return nil
+	}
+	bytes, err := utils.ParseByteSize(limit)
+	if err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	if memoryLimit != "" {
+		// the runtime reads GOMEMLIMIT itself
+		setMemoryLimit(bytes)
+	}
+	batchSize = int(bytes / bytesPerFile)
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return nil
+}
+
+// fileBatches splits files into batches of size, or one batch if size is 0.
+func fileBatches(files []string, size int) [][]string {
+	if size <= 0 || len(files) <= size {
+		return [][]string{files}
+	}
+	batches := make([][]string, 0, len(files)/size+1)
+	for len(files) > size {
+		batches = append(batches, files[:size])
+		files = files[size:]
+	}
+	return append(batches, files)
+}
+
+// releaseMemory returns what a finished batch used to the OS, when running
+// under a memory limit.
+func releaseMemory() {
+	if batchSize > 0 {
+		debug.FreeOSMemory()
+	}
+}
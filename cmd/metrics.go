@@ -0,0 +1,66 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/metrics"
+	"github.com/terakilobyte/checker/internal/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	metricsFile string
+	metricsPush string
+	metricsJob  string
+)
+
+func init() {
+	rootCmd.Flags().StringVar(&metricsFile, "metrics-file", "", "write Prometheus metrics for the run to this file, for a textfile collector")
+	rootCmd.Flags().StringVar(&metricsPush, "metrics-push", "", "push Prometheus metrics for the run to the Pushgateway at this url")
+	rootCmd.Flags().StringVar(&metricsJob, "metrics-job", "checker", "the job to group pushed metrics under")
+}
+
+// startMetrics starts recording metrics if they were asked for. The returned
+// func records what the run found and exports the metrics. Failing to export
+// them is only a warning, so metrics can't fail a run that otherwise passed.
+func startMetrics() func(found []diagnostics.Diagnostic) {
+	if metricsFile == "" && metricsPush == "" {
+		return func([]diagnostics.Diagnostic) {}
+	}
+	recorder := metrics.NewRecorder()
+	utils.WrapTransport(recorder.Transport)
+	return func(found []diagnostics.Diagnostic) {
+		recorder.Finish(found)
+		if metricsFile != "" {
+			if err := recorder.WriteFile(metricsFile); err != nil {
+				log.Warnf("could not write metrics to %s: %v", metricsFile, err)
+			}
+		}
+		if metricsPush != "" {
+			if err := recorder.Push(metricsPush, metricsJob); err != nil {
+				log.Warnf("could not push metrics to %s: %v", metricsPush, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+var (
+	// ip4Only and ip6Only connect over one IP version, for networks where
+	// the other is broken
+	ip4Only bool
+	ip6Only bool
+	// maxResponseSize and maxDownload cap how much is read of each response,
+	// and of all of them together, so links to large downloads don't use up
+	// CI time and bandwidth
+	maxResponseSize string
+	maxDownload     string
+	// insecureHosts are hosts whose certificates aren't verified, like
+	// internal sites with self-signed ones
+	insecureHosts []string
+)
+
+// configureNetwork makes requests connect over only the IP version asked for,
+// if one was, without verifying the certificates of --insecure-hosts, and read
+// no more than the caps on responses allow, recording certificates for
+// --warn-cert-expiry.
+func configureNetwork() error {
+	switch {
+	case ip4Only && ip6Only:
+		return errors.New("--ip4only and --ip6only can't be used together")
+	case ip4Only:
+		utils.UseNetwork("tcp4")
+	case ip6Only:
+		utils.UseNetwork("tcp6")
+	}
+	if len(insecureHosts) > 0 {
+		utils.AllowInsecureHosts(insecureHosts)
+	}
+	perResponse, err := utils.ParseByteSize(maxResponseSize)
+	if err != nil {
+		return fmt.Errorf("--max-response-size: %v", err)
+	}
+	var total int64
+	if maxDownload != "" {
+		if total, err = utils.ParseByteSize(maxDownload); err != nil {
+			return fmt.Errorf("--max-download: %v", err)
+		}
+	}
+	utils.LimitResponses(perResponse, total)
+	if certExpiryDays < 0 {
+		return errors.New("--warn-cert-expiry can't be negative")
+	}
+	if certExpiryDays > 0 {
+		utils.RecordCertificates()
+	}
+	return nil
+}
+
+// linkCategory is the category of a link that failed with err: a certificate
+// problem is its own, since it's fixed by the site's owner rather than by
+// changing the link.
+func linkCategory(err error) diagnostics.Category {
+	if utils.CertificateReason(utils.FailureReason(err)) {
+		return diagnostics.Certificate
+	}
+	return diagnostics.Link
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&ip4Only, "ip4only", false, "connect to hosts over IPv4 only, for networks where IPv6 is broken and links to dual-stack hosts time out")
+	rootCmd.PersistentFlags().BoolVar(&ip6Only, "ip6only", false, "connect to hosts over IPv6 only")
+	rootCmd.PersistentFlags().StringVar(&maxResponseSize, "max-response-size", "64MiB", "the most to read of any response, like a page or an intersphinx inventory, or 0 for no limit")
+	rootCmd.PersistentFlags().StringSliceVar(&insecureHosts, "insecure-hosts", nil, "hosts, and their subdomains, whose certificates aren't verified, like internal sites with self-signed ones")
+	rootCmd.PersistentFlags().StringVar(&maxDownload, "max-download", "", "the most to read of every response together, like 500MiB, after which links are checked by their status alone")
+}
@@ -0,0 +1,52 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/plugins"
+	"github.com/terakilobyte/checker/internal/sources"
+)
+
+// runPlugins runs each of the project's plugins on files, one file at a
+// time, and reports what they find. runChecks parses the changed files for it
+// in the same pass it collects everything else in.
+func runPlugins(configured []sources.Plugin, basepath string, files []plugins.File, diags chan<- diagnostics.Diagnostic) {
+	for _, p := range configured {
+		remaining := files
+		next := func() (plugins.File, bool) {
+			if len(remaining) == 0 {
+				return plugins.File{}, false
+			}
+			f := remaining[0]
+			remaining = remaining[1:]
+			return f, true
+		}
+		found, err := plugins.Run(p.Name, p.Command, basepath, next)
+		for _, d := range found {
+			diags <- d
+		}
+		if err != nil {
+			diags <- diagnostics.Errorf(diagnostics.Checker, "", "%v", err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Profiling is for bug reports about slow or bloated runs, so its flags are
+// hidden from --help.
+var (
+	cpuProfile  string
+	memProfile  string
+	pprofListen string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&cpuProfile, "cpuprofile", "", "write a CPU profile of the run to this file")
+	rootCmd.PersistentFlags().StringVar(&memProfile, "memprofile", "", "write a heap profile to this file when the run ends")
+	rootCmd.PersistentFlags().StringVar(&pprofListen, "pprof-listen", "", "serve net/http/pprof on this address, like localhost:6060, while running")
+	for _, name := range []string{"cpuprofile", "memprofile", "pprof-listen"} {
+		checkErr(rootCmd.PersistentFlags().MarkHidden(name))
+	}
+}
+
+// startProfiling starts whatever profiling was asked for. The returned func
+// stops it and writes the profiles; it's also run if checker exits early, and
+// only does anything the first time it's called.
+func startProfiling() func() {
+	if pprofListen != "" {
+		listener, err := net.Listen("tcp", pprofListen)
+		if err != nil {
+			configFatalf("could not listen on %s for pprof: %v", pprofListen, err)
+		}
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		log.Infof("serving pprof on http://%s/debug/pprof/", listener.Addr())
+		go func() {
+			if err := http.Serve(listener, mux); err != nil {
+				log.Warnf("pprof server stopped: %v", err)
+			}
+		}()
+	}
+
+	var cpu *os.File
+	if cpuProfile != "" {
+		var err error
+		if cpu, err = os.Create(cpuProfile); err != nil {
+			configFatalf("could not create CPU profile: %v", err)
+		}
+		if err := runtimepprof.StartCPUProfile(cpu); err != nil {
+			configFatalf("could not start CPU profile: %v", err)
+		}
+	}
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			if cpu != nil {
+				runtimepprof.StopCPUProfile()
+				if err := cpu.Close(); err != nil {
+					log.Warnf("could not write CPU profile: %v", err)
+				}
+			}
+			if memProfile != "" {
+				writeHeapProfile(memProfile)
+			}
+		})
+	}
+	log.RegisterExitHandler(stop)
+	return stop
+}
+
+func writeHeapProfile(filename string) {
+	f, err := os.Create(filename)
+	if err != nil {
+		log.Warnf("could not create heap profile: %v", err)
+		return
+	}
+	defer f.Close()
+	// collect garbage first, so the profile shows what's live at the end
+	runtime.GC()
+	if err := runtimepprof.WriteHeapProfile(f); err != nil {
+		log.Warnf("could not write heap profile: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+var (
+	// paths are the projects given with --path, checked one after another
+	paths []string
+	// projectPrefix is prepended to the files of findings when more than one
+	// project is checked, so the combined report says which project each is in
+	projectPrefix string
+	// reachability remembers the result of every url requested, so projects
+	// checked in the same run share their links' results
+	reachability sync.Map
+	// warnSlow is --warn-slow, how long a link can take to respond before
+	// it's warned about, 0 to never warn
+	warnSlow time.Duration
+)
+
+// checkLink is utils.CheckLink, requesting each url at most once per run.
+func checkLink(url string) utils.LinkResult {
+	if res, ok := reachability.Load(url); ok {
+		return res.(utils.LinkResult)
+	}
+	res := utils.CheckLink(url)
+	reachability.Store(url, res)
+	return res
+}
+
+// slow reports whether a link took longer than --warn-slow to check.
+func slow(res utils.LinkResult) bool {
+	return warnSlow > 0 && res.Elapsed > warnSlow
+}
+
+// checkProjects runs the checks against each project in paths and returns
+// everything that was found, with files prefixed by their project when there
+// is more than one.
+func checkProjects() []diagnostics.Diagnostic {
+	found := make([]diagnostics.Diagnostic, 0)
+	given := changes
+	for _, p := range paths {
+		path, changes = p, given
+		if len(paths) > 1 {
+			projectPrefix = filepath.ToSlash(filepath.Clean(p))
+		}
+		found = append(found, runChecks()...)
+	}
+	projectPrefix = ""
+	return found
+}
+
+// findProject walks up from dir to the nearest directory with a snooty.toml,
+// the way git finds its repository.
+func findProject(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "snooty.toml")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
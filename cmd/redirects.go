@@ -0,0 +1,114 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/terakilobyte/checker/internal/collectors"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/parsers/redirects"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+var redirectsFile string
+
+// redirectsCmd represents the redirects command
+var redirectsCmd = &cobra.Command{
+	Use:   "redirects",
+	Short: "Checks the project's redirect definitions.",
+	Long: `Checks the redirects defined in the project's redirects file (config/redirects by default).
+
+A redirect source must not be a page that exists in the project, since the redirect would hide it.
+Redirect targets within the project must resolve to a page, and external targets must be reachable.
+The prefix define and version segment are stripped from redirects before they are compared to pages.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		basepath, err := filepath.Abs(path)
+		checkErr(err)
+		data, err := ioutil.ReadFile(filepath.Join(basepath, redirectsFile))
+		if err != nil {
+			configFatalf("could not read %s: %v", redirectsFile, err)
+		}
+		defined, err := redirects.Parse(data)
+		if err != nil {
+			configFatalf("could not parse %s: %v", redirectsFile, err)
+		}
+		pages := collectors.GatherPageRoutes(gatherFiles(basepath))
+
+		diags, finish := newDiagnostics()
+		collided := make(map[string]bool)
+		checked := make(map[string]bool)
+		workStack := make([]func(), 0)
+		prefix := defined.Defines["prefix"]
+
+		for _, redirect := range defined.Redirects {
+			from := redirectRoute(redirect.From, prefix, redirect.Version)
+			if page, ok := pages[from]; ok && !collided[redirect.From] {
+				diags <- diagnostics.Errorf(diagnostics.Redirect, redirectsFile, "redirect from %s collides with the page %s", redirect.From, page).AtLine(redirect.Line)
+				collided[redirect.From] = true
+			}
+
+			if checked[redirect.To] {
+				continue
+			}
+			checked[redirect.To] = true
+			if utils.IsHTTPLink(redirect.To) {
+				workFunc := func(redirect redirects.Redirect) func() {
+					return func() {
+						if resp, ok := utils.IsReachable(redirect.To); !ok {
+							diags <- diagnostics.Errorf(diagnostics.Redirect, redirectsFile, "redirect target %s is not a valid http link. Got response %s", redirect.To, resp).AtLine(redirect.Line)
+						}
+					}
+				}
+				workStack = append(workStack, workFunc(redirect))
+				continue
+			}
+			if _, ok := pages[redirectRoute(redirect.To, prefix, redirect.Version)]; !ok {
+				diags <- diagnostics.Errorf(diagnostics.Redirect, redirectsFile, "redirect target %s is not a page in this docset", redirect.To).AtLine(redirect.Line)
+			}
+		}
+
+		validate(workStack)
+		report(finish())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(redirectsCmd)
+	redirectsCmd.Flags().StringVar(&redirectsFile, "file", "config/redirects", "path to the redirects file, relative to the project")
+}
+
+// redirectRoute converts a redirect path, like docs/drivers/go/v1.8/faq/, to
+// the route of the page in the project it refers to, like /faq.
+func redirectRoute(target string, prefix string, version string) string {
+	route := strings.Trim(target, "/")
+	if prefix != "" {
+		route = strings.TrimPrefix(strings.TrimPrefix(route, strings.Trim(prefix, "/")), "/")
+	}
+	if version != "" {
+		route = strings.TrimPrefix(strings.TrimPrefix(route, version), "/")
+	}
+	return "/" + strings.TrimSuffix(route, "/")
+}
@@ -0,0 +1,78 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/terakilobyte/checker/internal/collectors"
+	"github.com/terakilobyte/checker/internal/sources"
+)
+
+// refCompletion is a ref target and where it's defined: the file in the
+// project, or the domain of the intersphinx inventory.
+type refCompletion struct {
+	Target string `json:"target"`
+	Source string `json:"source"`
+}
+
+// refCompletions returns the ref targets starting with prefix that the
+// project at path can refer to, from its own files and its intersphinx
+// inventories, sorted by target.
+func refCompletions(prefix string) []refCompletion {
+	basepath, err := filepath.Abs(path)
+	checkErr(err)
+	snootyToml, err := ioutil.ReadFile(filepath.Join(basepath, "snooty.toml"))
+	if err != nil {
+		configFatalf("could not read snooty.toml: %v", err)
+	}
+	projectSnooty, err := sources.NewTomlConfig(snootyToml)
+	if err != nil {
+		configFatalf("could not parse snooty.toml: %v", err)
+	}
+
+	completions := make([]refCompletion, 0)
+	refs, err := collectors.GatherLocalRefs(gatherFiles(basepath))
+	checkErr(err)
+	for target, filename := range refs {
+		if strings.HasPrefix(target.Name, prefix) {
+			completions = append(completions, refCompletion{Target: target.Name, Source: filename})
+		}
+	}
+	for _, inv := range fetchInventories(projectSnooty.Intersphinx) {
+		for name, types := range inv.entries {
+			if types["std:label"] && strings.HasPrefix(name, prefix) {
+				completions = append(completions, refCompletion{Target: name, Source: inv.domain})
+			}
+		}
+	}
+	sort.Slice(completions, func(i, j int) bool {
+		if completions[i].Target != completions[j].Target {
+			return completions[i].Target < completions[j].Target
+		}
+		return completions[i].Source < completions[j].Source
+	})
+	return completions
+}
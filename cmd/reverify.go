@@ -0,0 +1,106 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/terakilobyte/checker/internal/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// reverifyDelay is how long to wait before checking failed links again, so
+// a blip, like a host restarting, has time to pass.
+const reverifyDelay = 2 * time.Second
+
+var (
+	// reverify is --reverify, whether failed links are checked once more
+	// before they're reported
+	reverify bool
+	// reverified remembers the links already checked again, so projects
+	// checked in the same run don't check them a third time
+	reverified sync.Map
+)
+
+func init() {
+	rootCmd.PersistentFlags().BoolVar(&reverify, "reverify", true, "check every failed link once more at the end of the run, one at a time, and only report those that fail again")
+}
+
+// failedLinks collects the links that failed while validating, so they can
+// be checked again before they're reported.
+type failedLinks struct {
+	mu      sync.Mutex
+	urls    []string
+	reports map[string][]func(utils.LinkResult)
+}
+
+func newFailedLinks() *failedLinks {
+	return &failedLinks{reports: make(map[string][]func(utils.LinkResult))}
+}
+
+// Add holds on to report, which reports that url failed, until Reverify. It's
+// called right away if failed links aren't checked again, or url already was.
+func (f *failedLinks) Add(url string, res utils.LinkResult, report func(utils.LinkResult)) {
+	if !reverify {
+		report(res)
+		return
+	}
+	if _, ok := reverified.Load(url); ok {
+		report(res)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.reports[url]; !ok {
+		f.urls = append(f.urls, url)
+	}
+	f.reports[url] = append(f.reports[url], report)
+}
+
+// Reverify checks each failed link again, one at a time after a short delay,
+// and reports the ones that still fail.
+func (f *failedLinks) Reverify() {
+	if len(f.urls) == 0 {
+		return
+	}
+	stage("reverify")
+	time.Sleep(reverifyDelay)
+	for _, url := range f.urls {
+		res := utils.CheckLink(url)
+		// flaky links get more chances, since they've failed for a moment before
+		for retry := 0; !res.OK && flakyURLs[url] && retry < flakyRetries; retry++ {
+			time.Sleep(reverifyDelay)
+			res = utils.CheckLink(url)
+		}
+		reachability.Store(url, res)
+		reverified.Store(url, true)
+		if res.OK {
+			log.Debugf("%s worked when checked again, so it isn't reported", url)
+			continue
+		}
+		for _, report := range f.reports[url] {
+			report(res)
+		}
+	}
+}
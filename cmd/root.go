@@ -25,6 +25,7 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math"
@@ -37,33 +38,58 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
-	"github.com/cheggaaa/pb/v3"
 	"github.com/spf13/cobra"
+	"github.com/terakilobyte/checker/internal/badge"
+	"github.com/terakilobyte/checker/internal/cache"
 	"github.com/terakilobyte/checker/internal/collectors"
+	"github.com/terakilobyte/checker/internal/diagnostics"
 	"github.com/terakilobyte/checker/internal/parsers/intersphinx"
 	"github.com/terakilobyte/checker/internal/parsers/rst"
+	"github.com/terakilobyte/checker/internal/plugins"
+	"github.com/terakilobyte/checker/internal/progress"
 	"github.com/terakilobyte/checker/internal/sources"
+	"github.com/terakilobyte/checker/internal/suggest"
 	"github.com/terakilobyte/checker/internal/utils"
 )
 
+// definedRoles reference targets defined by directives of the same name, like
+// :option: references .. option::, even if rstspec.toml doesn't know them.
+// rstspec.toml's objects, like :method: and :dbcommand:, are checked the same
+// way when the project or its intersphinx inventories define any.
+var definedRoles = []string{"option", "setting", "parameter"}
+
+// version is checker's release
+const version = "0.1.5"
+
 var (
-	path     string
-	refs     bool
-	docs     bool
-	changes  []string
-	progress bool
-	workers  int
-	throttle int
+	path         string
+	config       string
+	changes      []string
+	showProgress bool
+	workers      int
+	throttle     int
+	badgeFile    string
+	// rstSpecPin is --rstspec-version, "" to use checker.toml's or the latest
+	rstSpecPin string
+	// rstSpecFile is --rstspec-file, a local rstspec.toml used instead of any
+	// release's
+	rstSpecFile string
+	// reporter is nil unless --progress is set, and reports nothing when nil
+	reporter *progress.Reporter
+	// stopProfiling writes any profiles asked for with --cpuprofile or --memprofile
+	stopProfiling = func() {}
 )
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:     "checker",
-	Version: "0.1.5",
-	Short:   "Checks links, and optionally :ref:s, :doc:s, and other :role:s in a docs project.",
+	Use:     "checker [paths...]",
+	Version: version,
+	Short:   "Checks links, :ref:s, :doc:s, and other :role:s in a docs project.",
 	Long: `Checker is a tool for checking links in a docs project.
 It will check refs against locally found refs and those found in intersphinx targets,
-and checks roles against the latest RELEASE of rstspec.toml. Once they are validated,
+and checks roles against the latest RELEASE of rstspec.toml, or the one pinned
+with --rstspec-version or rstspec_version in checker.toml, or a local one given
+with --rstspec-file. Once they are validated,
 all links are checked for validity.
 
 This is mostly intended to be run on changed files only, as checking all of the links in a project
@@ -74,13 +100,61 @@ From a git branch, run the following:
 git diff --name-only HEAD master | tr "\n" "," | xargs checker -p --path . --changes
 
 This is (nearly) the same command that should be run in CI (just omit the -p flag).
+
+To only check some files or directories, pass them as arguments. Refs still
+resolve against the whole project:
+
+checker --path . source/tutorials/ source/reference/foo.txt
 `,
-	Run: func(cmd *cobra.Command, args []string) {
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if err := configureLogging(); err != nil {
+			configFatalf("%v", err)
+		}
+		if len(paths) == 0 {
+			paths = []string{"."}
+		}
+		if !cmd.Flags().Changed("path") {
+			if project, ok := findProject("."); ok {
+				log.Debugf("checking the project at %s", project)
+				paths = []string{project}
+			}
+		}
+		if len(paths) > 1 && cmd.HasParent() {
+			configFatalf("only checker itself can check more than one --path")
+		}
+		path = paths[0]
+		if err := validateCheckSelection(); err != nil {
+			configFatalf("%v", err)
+		}
+		if rstSpecFile != "" && rstSpecPin != "" {
+			configFatalf("--rstspec-file and --rstspec-version can't be used together")
+		}
+		if err := validateFailOn(); err != nil {
+			configFatalf("%v", err)
+		}
+		if err := validateSeverityScopes(); err != nil {
+			configFatalf("%v", err)
+		}
+		if err := configureNetwork(); err != nil {
+			configFatalf("%v", err)
+		}
+
+		for _, glob := range append(append([]string{}, collectors.Exclude...), collectors.Include...) {
+			if err := utils.ValidateGlob(glob); err != nil {
+				configFatalf("%v", err)
+			}
+		}
+		collectors.Extensions = normalizeExtensions(collectors.Extensions)
+		collectors.ExcludeExtensions = normalizeExtensions(collectors.ExcludeExtensions)
+
+		if err := configureOutput(); err != nil {
+			configFatalf("%v", err)
+		}
 
 		if val, ok := os.LookupEnv("CHECKER_WORKERS"); ok {
 			v, err := strconv.Atoi(val)
 			if err != nil {
-				log.Panicf("couldn't convert %s to an int: %v", val, err)
+				configFatalf("couldn't convert CHECKER_WORKERS %s to an int: %v", val, err)
 			}
 			workers = v
 		}
@@ -88,75 +162,360 @@ This is (nearly) the same command that should be run in CI (just omit the -p fla
 		if val, ok := os.LookupEnv("CHECKER_THROTTLE"); ok {
 			v, err := strconv.Atoi(val)
 			if err != nil {
-				log.Panicf("couldn't convert %s to an int: %v", val, err)
+				configFatalf("couldn't convert CHECKER_THROTTLE %s to an int: %v", val, err)
 			}
 			throttle = v
 		}
 
-		diagnostics := make([]string, 0)
-		diags := make(chan string)
-		go func() {
-			for d := range diags {
-				diagnostics = append(diagnostics, d)
+		stopProfiling = startProfiling()
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		stopProfiling()
+	},
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) > 0 && len(changes) > 0 {
+			configFatalf("paths to check can't be given with --changes")
+		}
+		if len(args) > 0 && len(paths) > 1 {
+			configFatalf("paths to check can't be given with more than one --path")
+		}
+		scope = args
+		run(checkProjects)
+	},
+}
+
+// run wraps check in the setup and reporting every checking command shares:
+// the cassette, memory limit, metrics, tracing, flaky list, badge and history.
+func run(check func() []diagnostics.Diagnostic) {
+	if err := validateCassette(); err != nil {
+		configFatalf("%v", err)
+	}
+	if err := configureMemoryLimit(); err != nil {
+		configFatalf("%v", err)
+	}
+	finishCassette := startCassette()
+	finishMetrics := startMetrics()
+	finishTracing := startTracing()
+	finishDebugHTTP := startDebugHTTP()
+	scoped := len(changes) > 0 || len(scope) > 0
+	loadFlaky()
+	found := check()
+	finishDebugHTTP()
+	finishCassette()
+	finishTracing()
+	printTimings()
+	finishMetrics(found)
+	if dryRun {
+		printPlan()
+		return
+	}
+	if badgeFile != "" {
+		writeBadge(found)
+	}
+	recordHistory(found, scoped)
+	report(found)
+}
+
+// runChecks collects everything checkable in the project at path, validates
+// it, and returns what was found.
+func runChecks() []diagnostics.Diagnostic {
+	diags, finish := newDiagnostics()
+	stopWatching := watchWarnings(diags)
+	stage("config")
+
+	basepath, err := filepath.Abs(path)
+	checkErr(err)
+	snootyToml, err := ioutil.ReadFile(filepath.Join(basepath, "snooty.toml"))
+	if err != nil {
+		configFatalf("could not read snooty.toml: %v", err)
+	}
+	projectSnooty, err := sources.NewTomlConfig(snootyToml)
+	if err != nil {
+		configFatalf("could not parse snooty.toml: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(basepath, "source")); err != nil {
+		configFatalf("could not find the project's source directory: %v", err)
+	}
+	checkerConfig := loadCheckerConfig(basepath)
+	configureLinkChecks(checkerConfig)
+	warnExpiredSkips(checkerConfig)
+	// deprecated reports target, a url or the target of the role named role,
+	// if checker.toml has a replacement for it
+	deprecated := func(filename string, target string, role string) {
+		if !enabled("deprecated") {
+			return
+		}
+		if replacement, ok := checkerConfig.Replacement(target); ok {
+			diags <- diagnostics.Warnf(diagnostics.Deprecated, filename, "%s is deprecated, use %s instead", target, replacement).WithTarget(target).WithRole(role).WithFix(replacement)
+		}
+	}
+	stage("intersphinx")
+	inventories := fetchInventories(projectSnooty.Intersphinx)
+	intersphinxes := make([]intersphinx.SphinxMap, 0, len(inventories))
+	for _, inv := range inventories {
+		intersphinxes = append(intersphinxes, inv.entries)
+	}
+	sphinxMap := intersphinx.JoinSphinxes(intersphinxes)
+	stage("collect")
+	files := gatherFiles(basepath)
+	if len(scope) > 0 {
+		scoped, err := scopeFiles(files, scope, basepath)
+		if err != nil {
+			configFatalf("%v", err)
+		}
+		changes = scoped
+	}
+	if len(changes) == 0 {
+		changes = files
+	}
+	batches := fileBatches(files, batchSize)
+	rstSpecRoles := loadRstSpec(checkerConfig)
+	rstSpecRoles.AddRoles(checkerConfig.Roles)
+
+	// the files plugins are run on are parsed in the same pass as everything
+	// else, and held until the batch they're in is checked
+	var pluginFiles []plugins.File
+	collect := collectors.Collect{
+		Callables:      rstSpecRoles.Callables,
+		Labels:         enabled("naming"),
+		SyntaxWarnings: enabled("syntax"),
+		LinkTargets:    enabled("schemes"),
+		PageFields:     enabled("metadata"),
+	}
+	if enabled("plugins") && len(checkerConfig.Plugins) > 0 {
+		// changes are relative to the project or, when they default to
+		// files, absolute, and filenames are relative with a leading slash
+		changed := make([]string, 0, len(changes))
+		for _, change := range changes {
+			changed = append(changed, projectPath(basepath, change))
+		}
+		collect.Parse = func(filename string) bool {
+			return containsExactly(changed, projectPath(basepath, filename))
+		}
+		collect.EachDocument = func(filename string, data []byte, doc *rst.Document) {
+			pluginFiles = append(pluginFiles, plugins.NewFile(filename, data, doc))
+		}
+	}
+
+	// when every file is one batch, each is read and lexed just once, here.
+	// Otherwise what files resolve against is gathered in one pass, and the
+	// rest a batch at a time.
+	var project, index *collectors.Project
+	if len(batches) == 1 {
+		project, err = collectors.GatherProject(files, collect)
+		index = project
+	} else {
+		index, err = collectors.GatherIndex(files, rstSpecRoles.Callables)
+	}
+	checkErr(err)
+
+	sharedRefs := make(collectors.RstRoleMap)
+	sharedLocals := make(collectors.RefTargetMap)
+
+	for _, sharedFile := range readShareds(checkerConfig, projectSnooty, basepath, index.SharedIncludes, diags) {
+		sharedRefs.Union(collectors.GatherSharedRefs(sharedFile, *projectSnooty))
+		sharedLocals.Union(collectors.GatherSharedLocalRefs(sharedFile, *projectSnooty))
+	}
+
+	stage("parse")
+	// refs and doc routes resolve against every file, whichever batch they're
+	// checked in
+	allLocalRefs := index.LocalRefs.Alias(checkerConfig.RefAliases)
+	allLocalRefs.Union(sharedLocals)
+	allMDXRoutes := index.MDXRoutes
+
+	var refCandidates []string
+	var candidatesOnce sync.Once
+	// refsByLowercase maps lowercased ref targets to the targets, for
+	// case_insensitive_refs
+	var refsByLowercase map[string][]string
+	var lowercaseOnce sync.Once
+	checkRef := func(role rst.RstRole, filename string) {
+		plan.refs++
+		// domain roles like :py:meth:`~a.b.c` show only c, but target a.b.c
+		target := strings.TrimPrefix(role.Target, "~")
+		if sphinxMap.Resolves(role.Name, target) {
+			return
+		}
+		if _, ok := allLocalRefs.Get(&role); ok {
+			return
+		}
+		if checkerConfig.CaseInsensitiveRefs {
+			lowercaseOnce.Do(func() {
+				refsByLowercase = make(map[string][]string, len(sphinxMap)+len(allLocalRefs))
+				for name := range sphinxMap {
+					refsByLowercase[strings.ToLower(name)] = append(refsByLowercase[strings.ToLower(name)], name)
+				}
+				for ref := range allLocalRefs {
+					refsByLowercase[strings.ToLower(ref.Name)] = append(refsByLowercase[strings.ToLower(ref.Name)], ref.Name)
+				}
+			})
+			for _, name := range refsByLowercase[strings.ToLower(target)] {
+				if _, ok := allLocalRefs[rst.RefTarget{Name: name}]; ok || sphinxMap.Resolves(role.Name, name) {
+					diags <- diagnostics.Warnf(diagnostics.Ref, filename, "%+v only matches %s ignoring case", role, name).WithTarget(target).WithRole(role.Name).WithFix(name)
+					return
+				}
+			}
+		}
+		candidatesOnce.Do(func() {
+			refCandidates = make([]string, 0, len(sphinxMap)+len(allLocalRefs))
+			for target := range sphinxMap {
+				refCandidates = append(refCandidates, target)
 			}
-		}()
-
-		type intersphinxResult struct {
-			domain string
-			file   []byte
-		}
-
-		basepath, err := filepath.Abs(path)
-		checkErr(err)
-		snootyToml := utils.GetLocalFile(filepath.Join(basepath, "snooty.toml"))
-		projectSnooty, err := sources.NewTomlConfig(snootyToml)
-		checkErr(err)
-		intersphinxes := make([]intersphinx.SphinxMap, len(projectSnooty.Intersphinx))
-		var wgSetup sync.WaitGroup
-		ixs := make(chan intersphinxResult, len(projectSnooty.Intersphinx))
-		for _, intersphinx := range projectSnooty.Intersphinx {
-			wgSetup.Add(1)
-			go func(phx string) {
-				domain := strings.Split(phx, "objects.inv")[0]
-				file := utils.GetNetworkFile(phx)
-				ixs <- intersphinxResult{domain: domain, file: file}
-			}(intersphinx)
-		}
-		go func() {
-			for res := range ixs {
-				intersphinxes = append(intersphinxes, intersphinx.Intersphinx(res.file, res.domain))
-				wgSetup.Done()
+			for target := range allLocalRefs {
+				refCandidates = append(refCandidates, target.Name)
 			}
-		}()
-		wgSetup.Wait()
-		close(ixs)
-		sphinxMap := intersphinx.JoinSphinxes(intersphinxes)
-		files := collectors.GatherFiles(basepath)
+		})
+		diags <- diagnostics.Errorf(diagnostics.Ref, filename, "%+v is not a valid ref", role).WithTarget(role.Target).WithRole(role.Name).WithSuggestions(suggest.Closest(role.Target, refCandidates, 3)).WithReason(diagnostics.UnknownRef)
+	}
 
-		allShared := collectors.GatherSharedIncludes(files)
+	stage("check")
+	// checkedUrls are keyed by urlKey, so each link is checked once however
+	// it's spelled
+	checkedUrls := sync.Map{}
+	// failed holds link failures until they're checked again at the end
+	failed := newFailedLinks()
+	allTargets := index.Targets
+	// definedKinds caches the kind of object, like method, that a role
+	// references, if its targets are defined by directives of that kind
+	definedKinds := make(map[string]string)
+	definedKind := func(name string) string {
+		if kind, ok := definedKinds[name]; ok {
+			return kind
+		}
+		kind := name[strings.LastIndex(name, ":")+1:]
+		if !contains(definedRoles, kind) && !(rstSpecRoles.RstObjects[name] && (allTargets.Has(kind) || sphinxMap.HasKind(kind))) {
+			kind = ""
+		}
+		definedKinds[name] = kind
+		return kind
+	}
+	checkDefined := func(role rst.RstRole, filename string, kind string) {
+		plan.refs++
+		if allTargets.Defines(kind, role.Target) {
+			return
+		}
+		target := allTargets.Normalize(kind, role.Target)
+		if sphinxMap.Resolves(role.Name, strings.TrimPrefix(role.Target, "~")) || sphinxMap.Resolves(role.Name, target) {
+			return
+		}
+		diags <- diagnostics.Errorf(diagnostics.Ref, filename, "%+v is not a defined %s", role, kind).WithTarget(role.Target).WithRole(role.Name).WithSuggestions(suggest.Closest(target, allTargets.Targets(kind), 3)).WithReason(diagnostics.UnknownRef)
+	}
+	// roles checker handles itself, and project extlinks, are valid too
+	roleNames := append(rstSpecRoles.RoleNames(), "ref", "doc", "guilabel")
+	roleNames = append(roleNames, checkerConfig.RefRoleNames()...)
+	for name := range projectSnooty.Extlinks {
+		roleNames = append(roleNames, name)
+	}
 
-		sharedRefs := make(collectors.RstRoleMap)
-		sharedLocals := make(collectors.RefTargetMap)
+	if enabled("duplicates") {
+		for _, kind := range allTargets.Kinds() {
+			if !contains(definedRoles, kind) && !rstSpecRoles.RstObjects[kind] {
+				continue
+			}
+			for target, definedIn := range allTargets.Duplicates(kind) {
+				for _, filename := range definedIn {
+					if !contains(changes, strings.TrimPrefix(filename, "/")) {
+						continue
+					}
+					diags <- diagnostics.Warnf(diagnostics.Duplicate, filename, "%s %s is defined in %s, so its anchor is ambiguous", kind, target, strings.Join(definedIn, ", ")).WithTarget(target)
+				}
+			}
+		}
+	}
 
-		for _, share := range allShared {
-			sharedFile := utils.GetNetworkFile(projectSnooty.SharedPath + share.Path)
-			sharedRefs.Union(collectors.GatherSharedRefs(sharedFile, *projectSnooty))
-			sharedLocals.Union(collectors.GatherSharedLocalRefs(sharedFile, *projectSnooty))
+	// links are validated as they're found, so only as many are held as
+	// there are workers ready for them
+	var queue *workQueue
+	if !dryRun {
+		queue = startValidating()
+	}
+	enqueue := func(job func()) {
+		plan.jobs++
+		if queue != nil {
+			queue.Add(job)
 		}
+	}
 
-		allConstants := collectors.GatherConstants(files)
-		allRoleTargets := collectors.GatherRoles(files)
-		allHTTPLinks := collectors.GatherHTTPLinks(files)
-		allLocalRefs := collectors.GatherLocalRefs(files).SSLToTLS()
+	// everything else is checked a batch of files at a time, so memory stays
+	// bounded under --memory-limit. Without one, every file is one batch.
+	for i, batch := range batches {
+		batchProject := project
+		if batchProject == nil {
+			var err error
+			batchProject, err = collectors.GatherProject(batch, collect)
+			checkErr(err)
+		}
+		if len(pluginFiles) > 0 {
+			runPlugins(checkerConfig.Plugins, basepath, pluginFiles, diags)
+			pluginFiles = nil
+		}
+		allConstants := batchProject.Constants
+		allRoleTargets := batchProject.Roles
+		allHTTPLinks := batchProject.HTTPLinks
+		allDirectives := batchProject.Directives
+		allMDXDocRefs := batchProject.MDXDocRefs
 
-		allRoleTargets.Union(sharedRefs)
-		allLocalRefs.Union(sharedLocals)
+		if enabled("naming") {
+			for filename, labels := range batchProject.Labels {
+				if !contains(changes, strings.TrimPrefix(filename, "/")) {
+					continue
+				}
+				for _, label := range labels {
+					name := label.Value.(rst.RefTarget).Name
+					if problems := checkerConfig.TargetNameProblems(name); len(problems) > 0 {
+						diags <- diagnostics.Warnf(diagnostics.Naming, filename, "ref target %s %s", name, strings.Join(problems, " and ")).AtLine(label.Span.Line).WithTarget(name).WithSuggestions([]string{checkerConfig.ConventionalTargetName(name)})
+					}
+				}
+			}
+		}
+
+		if enabled("syntax") {
+			for filename, warnings := range batchProject.SyntaxWarnings {
+				if !contains(changes, strings.TrimPrefix(filename, "/")) {
+					continue
+				}
+				for _, w := range warnings {
+					diags <- diagnostics.Warnf(diagnostics.Syntax, filename, "%s", w.Message).AtLine(w.Span.Line)
+				}
+			}
+		}
 
+		if enabled("schemes") {
+			for filename, targets := range batchProject.LinkTargets {
+				if !contains(changes, strings.TrimPrefix(filename, "/")) {
+					continue
+				}
+				for _, target := range targets {
+					if problem := checkerConfig.SchemeProblem(target); problem != "" {
+						diags <- diagnostics.Warnf(diagnostics.Scheme, filename, "%s", problem).WithTarget(target)
+					}
+				}
+			}
+		}
+
+		if enabled("metadata") {
+			for filename, fields := range batchProject.PageFields {
+				if !contains(changes, strings.TrimPrefix(filename, "/")) {
+					continue
+				}
+				for _, field := range fields {
+					if problem := field.Problem(rstSpecRoles.DirectiveOptions[field.Directive]); problem != "" {
+						diags <- diagnostics.Warnf(diagnostics.Metadata, filename, "%s", problem).AtLine(field.Span.Line).WithTarget(field.Name).WithSuggestions(pageFieldSuggestions(field, rstSpecRoles.DirectiveOptions))
+					}
+				}
+			}
+		}
+
+		if i == 0 {
+			allRoleTargets.Union(sharedRefs)
+		}
 		allRoleTargets = allRoleTargets.ConvertConstants(projectSnooty)
 
 		for con, filename := range allConstants {
-			if _, ok := projectSnooty.Constants[con.Name]; !ok {
-				diags <- fmt.Sprintf("%s is not defined in config", con)
+			if _, ok := projectSnooty.Constants[con.Name]; !ok && enabled("constants") {
+				diags <- diagnostics.Errorf(diagnostics.Constant, filename, "%s is not defined in config", con)
 			}
 			testCon := rst.RstConstant{Name: con.Name, Target: projectSnooty.Constants[filename] + con.Name}
 			if testCon.IsHTTPLink() {
@@ -164,75 +523,70 @@ This is (nearly) the same command that should be run in CI (just omit the -p fla
 			}
 		}
 
-		checkedUrls := sync.Map{}
-		workStack := make([]func(), 0)
-		rstSpecRoles := sources.NewRoleMap(utils.GetNetworkFile(utils.GetLatestSnootyParserTag()))
-
-		if len(changes) == 0 {
-			changes = files
-		}
-
+		validatingRefs := reporter.Start("ref validation", len(allRoleTargets))
 		for role, filename := range allRoleTargets {
+			validatingRefs.Increment()
 
 			if !contains(changes, strings.TrimPrefix(filename, "/")) {
 				continue
 			}
+			deprecated(filename, role.Target, role.Name)
 
-			switch role.Name {
-			case "guilabel":
+			switch {
+			case role.Name == "guilabel":
 				break
-			case "ref":
-				if refs {
-					if _, ok := sphinxMap[role.Target]; !ok {
-						if _, ok := allLocalRefs.Get(&role); !ok {
-							diags <- fmt.Sprintf("in %s: %+v is not a valid ref", filename, role)
-						}
-					}
+			case checkerConfig.IsRefRole(role.Name): // :ref:, or a fancy magic ref like :py:meth:
+				if enabled("refs") {
+					checkRef(role, filename)
+					break
+				}
+			case definedKind(role.Name) != "":
+				if enabled("refs") {
+					checkDefined(role, filename, definedKind(role.Name))
 					break
 				}
-			case "doc":
-				if docs {
+			case role.Name == "doc":
+				if enabled("docs") {
 					if !contains(files, filename) {
-						diags <- fmt.Sprintf("in %s: %s is not a valid file found in this docset", filename, role)
+						diags <- diagnostics.Errorf(diagnostics.Doc, filename, "%s is not a valid file found in this docset", role).WithTarget(role.Target)
 					}
 					break
 				}
 
-			case "py:meth": // this is a fancy magic ref
-				if refs {
-					if _, ok := sphinxMap[role.Target]; !ok {
-						if _, ok := allLocalRefs.Get(&role); !ok {
-							diags <- fmt.Sprintf("in %s: %+v is not a valid ref", filename, role)
-						}
-					}
+			default:
+				if !enabled("roles") {
 					break
 				}
-			case "py:class": // this is a fancy magic ref
-				if refs {
-					if _, ok := sphinxMap[role.Target]; !ok {
-						if _, ok := allLocalRefs.Get(&role); !ok {
-							diags <- fmt.Sprintf("in %s: %+v is not a valid ref", filename, role)
+				// extlinks are project defined, and take precedence over rstspec
+				url, ok := projectSnooty.ExtlinkURL(role.Name, role.Target)
+				if !ok {
+					if _, ok := rstSpecRoles.Roles[role.Name]; !ok {
+						if _, ok := rstSpecRoles.RawRoles[role.Name]; !ok {
+							if _, ok := rstSpecRoles.RstObjects[role.Name]; !ok {
+								diags <- diagnostics.Errorf(diagnostics.Role, filename, "%s is not a valid role", role).WithTarget(role.Name).WithSuggestions(suggest.Closest(role.Name, roleNames, 3)).WithReason(diagnostics.UnknownRole)
+							}
 						}
+						break
 					}
-					break
+					url = fmt.Sprintf(rstSpecRoles.Roles[role.Name], role.Target)
 				}
-			default:
-				if _, ok := rstSpecRoles.Roles[role.Name]; !ok {
-					if _, ok := rstSpecRoles.RawRoles[role.Name]; !ok {
-						if _, ok := rstSpecRoles.RstObjects[role.Name]; !ok {
-							diags <- fmt.Sprintf("in %s: %s is not a valid role", filename, role)
-						}
-					}
+				deprecated(filename, url, "")
+				// the url a role interpolates is a link like any other
+				if !enabled("links") || skippedLink(checkerConfig, filename, url, diags) {
 					break
 				}
-				workFunc := func(role rst.RstRole, filename string) func() {
-					url := fmt.Sprintf(rstSpecRoles.Roles[role.Name], role.Target)
-					if _, ok := checkedUrls.Load(url); !ok {
+				workFunc := func(role rst.RstRole, filename string, url string) func() {
+					if _, ok := checkedUrls.LoadOrStore(urlKey(url), true); !ok {
 						return func() {
-							checkedUrls.Store(url, true)
-							if resp, ok := utils.IsReachable(url); !ok {
-								errmsg := fmt.Sprintf("in %s: interpeted url %s from  %+v was not valid. Got response %s", filename, url, role, resp)
-								diags <- errmsg
+							if res := checkLink(url); !res.OK {
+								failed.Add(url, res, func(res utils.LinkResult) {
+									diags <- flaky(url, diagnostics.Errorf(linkCategory(res.Err), filename, "interpeted url %s from  %+v was not valid. Got response %s", url, role, res.Err).WithTarget(url).WithReason(utils.FailureReason(res.Err)))
+								})
+							} else {
+								if slow(res) {
+									diags <- diagnostics.Warnf(diagnostics.Slow, filename, "interpreted url %s from %+v took %s to respond", url, role, res.Elapsed.Round(time.Millisecond)).WithTarget(url)
+								}
+								expiringCertificate(filename, url, diags)
 							}
 						}
 					} else {
@@ -240,22 +594,52 @@ This is (nearly) the same command that should be run in CI (just omit the -p fla
 
 					}
 				}
-				workStack = append(workStack, workFunc(role, filename))
+				enqueue(workFunc(role, filename, url))
+				plan.urls[url] = true
+			}
+		}
+
+		validatingRefs.Finish()
+
+		if enabled("docs") {
+			for ref, filename := range allMDXDocRefs {
+				if !contains(changes, strings.TrimPrefix(filename, "/")) {
+					continue
+				}
+				if !collectors.ResolveMDXDocRef(ref, filename, allMDXRoutes, projectSnooty.MDXRouteBase) {
+					diags <- diagnostics.Errorf(diagnostics.Doc, filename, "%s is not a valid doc or route found in this docset", ref.Target)
+				}
 			}
 		}
 
 		for link, filename := range allHTTPLinks {
 
-			if !contains(changes, strings.TrimPrefix(filename, "/")) {
+			if !enabled("links") || !contains(changes, strings.TrimPrefix(filename, "/")) {
+				continue
+			}
+			deprecated(filename, string(link), "")
+			if skippedLink(checkerConfig, filename, string(link), diags) {
 				continue
 			}
 			workFunc := func(link rst.RstHTTPLink, filename string) func() {
-				if _, ok := checkedUrls.Load(link); !ok {
+				if _, ok := checkedUrls.LoadOrStore(urlKey(string(link)), true); !ok {
 					return func() {
-						checkedUrls.Store(link, true)
-						if resp, ok := utils.IsReachable(string(link)); !ok {
-							errmsg := fmt.Sprintf("in %s: %s is not a valid http link. Got response %s", filename, link, resp)
-							diags <- errmsg
+						res := checkLink(string(link))
+						if !res.OK {
+							failed.Add(string(link), res, func(res utils.LinkResult) {
+								diags <- flaky(string(link), diagnostics.Errorf(linkCategory(res.Err), filename, "%s is not a valid http link. Got response %s", link, res.Err).WithTarget(string(link)).WithReason(utils.FailureReason(res.Err)))
+							})
+							return
+						}
+						if slow(res) {
+							diags <- diagnostics.Warnf(diagnostics.Slow, filename, "%s took %s to respond", link, res.Elapsed.Round(time.Millisecond)).WithTarget(string(link))
+						}
+						expiringCertificate(filename, string(link), diags)
+						if res.Refreshed != "" {
+							diags <- diagnostics.Warnf(diagnostics.Redirect, filename, "%s redirects with a meta refresh to %s", link, res.Refreshed).WithTarget(string(link)).WithFix(res.Refreshed)
+						}
+						if res.Canonical != "" && enabled("canonical") {
+							diags <- diagnostics.Warnf(diagnostics.Canonical, filename, "%s says its canonical url is %s", link, res.Canonical).WithTarget(string(link)).WithFix(res.Canonical)
 						}
 					}
 				} else {
@@ -263,78 +647,247 @@ This is (nearly) the same command that should be run in CI (just omit the -p fla
 				}
 			}
 
-			workStack = append(workStack, workFunc(link, filename))
-		}
-
-		jobChannel := make(chan func())
-		doneChannel := make(chan struct{})
-
-		var wgValidate sync.WaitGroup
-		wgValidate.Add(workers)
-		for i := 0; i < workers; i++ {
-			go worker(&wgValidate, jobChannel, doneChannel)
+			enqueue(workFunc(link, filename))
+			plan.urls[string(link)] = true
 		}
 
-		bar := pb.StartNew(len(workStack)).SetMaxWidth(120)
-		if progress {
-			bar.SetWriter(os.Stdout)
-		} else {
-			bar.SetWriter(ioutil.Discard)
-		}
-		go func() {
-			for range doneChannel {
-				bar.Increment()
+		for directive, filename := range allDirectives {
+			if directive.Name != "openapi" || !enabled("openapi") {
+				continue
 			}
-		}()
-
-		for _, f := range workStack {
-			jobChannel <- f
+			if !contains(changes, strings.TrimPrefix(filename, "/")) {
+				continue
+			}
+			spec := strings.TrimSpace(directive.Target)
+			if !utils.IsHTTPLink(spec) {
+				// local specs are relative to the source directory
+				if err := checkOpenAPISpec(ioutil.ReadFile(filepath.Join(basepath, "source", spec))); err != nil {
+					diags <- diagnostics.Errorf(diagnostics.OpenAPI, filename, "openapi spec %s is not valid: %v", spec, err)
+				}
+				continue
+			}
+			workFunc := func(spec string, filename string) func() {
+				return func() {
+					if err := checkOpenAPISpec(utils.FetchNetworkFile(spec)); err != nil {
+						diags <- diagnostics.Errorf(diagnostics.OpenAPI, filename, "openapi spec %s is not valid: %v", spec, err)
+					}
+				}
+			}
+			enqueue(workFunc(spec, filename))
+			plan.urls[spec] = true
 		}
 
-		close(jobChannel)
-		wgValidate.Wait()
-		bar.Finish()
-		for _, msg := range diagnostics {
-			log.Error(msg)
+		if enabled("links") {
+			for link, filename := range batchProject.ContactLinks {
+				if contains(changes, strings.TrimPrefix(filename, "/")) {
+					checkContactLink(link, filename, diags, enqueue)
+				}
+			}
 		}
 
-		if len(diagnostics) > 0 {
-			log.Fatal(len(diagnostics), " errors found.\n")
-		} else {
-			log.Info("No errors found.\n")
-		}
-	},
+		// let the batch's roles and links go before parsing the next
+		releaseMemory()
+	}
+	if queue != nil {
+		stage("validate")
+		queue.Wait()
+		failed.Reverify()
+	}
+	stopWatching()
+	return finish()
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	registerCompletions()
+	// the checking subcommands take the root's run flags, like --replay and
+	// --output, since they share its setup
+	checkCmd.Flags().AddFlagSet(rootCmd.Flags())
+	checkFileCmd.Flags().AddFlagSet(rootCmd.Flags())
 	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(exitConfig)
 	}
 
 }
 
+// normalizeExtensions gives each extension a leading dot, so --extensions
+// accepts rst as well as .rst.
+func normalizeExtensions(exts []string) []string {
+	normalized := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized = append(normalized, ext)
+	}
+	return normalized
+}
+
 func init() {
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	rootCmd.SetVersionTemplate("checker {{.Version}}\n")
+	rootCmd.SetVersionTemplate("checker {{.Version}}\n{{buildInfo}}")
 
-	rootCmd.PersistentFlags().StringVar(&path, "path", ".", "path to the project")
-	rootCmd.PersistentFlags().BoolVarP(&refs, "refs", "r", false, "check :refs:")
-	rootCmd.PersistentFlags().BoolVarP(&docs, "docs", "d", false, "check :docs:")
+	rootCmd.PersistentFlags().StringArrayVar(&paths, "path", []string{"."}, "path to the project, repeatable to check several projects in one combined report. Defaults to the nearest directory above the working directory with a snooty.toml")
+	rootCmd.PersistentFlags().StringSliceVar(&collectors.Extensions, "extensions", collectors.DefaultExtensions, "extensions of the source files to check")
+	rootCmd.PersistentFlags().StringSliceVar(&collectors.ExcludeExtensions, "exclude-extensions", []string{}, "skip files and directories ending in these extensions, like .generated.rst, even if --extensions includes them")
+	rootCmd.PersistentFlags().StringSliceVar(&collectors.Exclude, "exclude", []string{}, "skip files and directories matching these globs, relative to the project, where ** matches any number of directories, like 'source/archive/**'")
+	rootCmd.PersistentFlags().StringSliceVar(&collectors.Include, "include", []string{}, "only collect files matching these globs, relative to the project, like 'source/atlas/**'")
+	rootCmd.PersistentFlags().BoolVar(&collectors.FollowSymlinks, "follow-symlinks", false, "descend into symlinked directories, reporting each file once however many links reach it")
+	rootCmd.PersistentFlags().StringVar(&config, "config", "checker.toml", "path to checker's config, relative to the project")
+	rootCmd.PersistentFlags().StringVar(&rstSpecPin, "rstspec-version", "", "validate roles against the rstspec.toml of this snooty-parser release, like v0.13.7, instead of the latest")
+	rootCmd.PersistentFlags().StringVar(&rstSpecFile, "rstspec-file", "", "validate roles against this local rstspec.toml instead of fetching one")
+	rootCmd.PersistentFlags().StringSliceVar(&only, "only", []string{}, "only run these checks: "+strings.Join(checks, ", "))
+	rootCmd.PersistentFlags().StringSliceVar(&skip, "skip", []string{}, "skip these checks: "+strings.Join(checks, ", "))
+	rootCmd.PersistentFlags().StringVar(&failOn, "fail-on", "error", "the severity that fails the run: error, warning, or none")
+	rootCmd.PersistentFlags().IntVar(&maxFailures, "max-failures", 0, "stop checking after this many failures, 0 for no limit")
+	rootCmd.PersistentFlags().StringSliceVar(&errorOn, "error-on", []string{}, "report findings with these reasons or categories, like 4xx,unknown-ref, as errors")
+	rootCmd.PersistentFlags().StringSliceVar(&warnOn, "warn-on", []string{}, "report findings with these reasons or categories, like timeout,5xx, as warnings")
+	rootCmd.PersistentFlags().BoolVar(&warnExternal, "warn-external", false, "report failed external links as warnings, so only refs, roles, and docs fail the run; same as --warn-on link,certificate")
+	rootCmd.PersistentFlags().BoolVar(&strict, "strict", false, "treat every warning, including those logged while collecting, as an error")
+	rootCmd.PersistentFlags().BoolP("refs", "r", false, "check :refs:")
+	rootCmd.PersistentFlags().BoolP("docs", "d", false, "check :docs:")
+	checkErr(rootCmd.PersistentFlags().MarkDeprecated("refs", "refs are checked by default, use --only and --skip to choose checks"))
+	checkErr(rootCmd.PersistentFlags().MarkDeprecated("docs", "docs are checked by default, use --only and --skip to choose checks"))
 	rootCmd.PersistentFlags().StringSliceVar(&changes, "changes", []string{}, "The list of files to check")
-	rootCmd.PersistentFlags().BoolVarP(&progress, "progress", "p", false, "show progress bar")
+	rootCmd.PersistentFlags().BoolVarP(&showProgress, "progress", "p", false, "show the progress of each stage, as status lines when not on a terminal")
+	rootCmd.PersistentFlags().DurationVar(&warnSlow, "warn-slow", 0, "warn about links that work but take longer than this to respond, like 5s")
 	rootCmd.PersistentFlags().IntVarP(&workers, "workers", "w", 10, "The number of workers to spawn to do work.")
+	rootCmd.Flags().StringVar(&badgeFile, "badge", "", "write a shields.io endpoint badge summarizing link health to this file")
 	rootCmd.PersistentFlags().IntVarP(&throttle, "throttle", "t", 10, "The throttle factor. Each worker will process at most (1e9 / (throttle / workers)) jobs per second.")
 }
 
+// checkErr exits if err, from something like writing output, isn't nil.
 func checkErr(err error) {
 	if err != nil {
-		log.Panic(err)
+		infraFatalf("%v", err)
+	}
+}
+
+// gatherFiles returns the files of the project at basepath that are checked.
+// A path that isn't a docs project is a config error, and one that can't be
+// walked an infrastructure one.
+func gatherFiles(basepath string) []string {
+	files, err := collectors.GatherFiles(basepath)
+	if errors.Is(err, collectors.ErrNotProject) {
+		configFatalf("%v", err)
+	}
+	checkErr(err)
+	return files
+}
+
+// loadCheckerConfig reads checker.toml from the project, if there is one.
+func loadCheckerConfig(basepath string) *sources.CheckerConfig {
+	input, err := ioutil.ReadFile(filepath.Join(basepath, config))
+	if err != nil && !os.IsNotExist(err) {
+		configFatalf("could not read %s: %v", config, err)
+	}
+	cfg, err := sources.NewCheckerConfig(input)
+	if err != nil {
+		configFatalf("could not parse %s: %v", config, err)
+	}
+	return cfg
+}
+
+// rstSpecVersion is the snooty-parser release to validate roles against, from
+// --rstspec-version or else checker.toml, or "" for the latest.
+func rstSpecVersion(cfg *sources.CheckerConfig) string {
+	if rstSpecPin != "" {
+		return rstSpecPin
+	}
+	return cfg.RstSpecVersion
+}
+
+// rstSpecURL is where the rstspec.toml of the snooty-parser release version
+// is, looking up the latest release if version is "".
+func rstSpecURL(version string) (string, error) {
+	if version != "" {
+		return utils.RstSpecURL(version), nil
+	}
+	return utils.LatestRstSpecURL()
+}
+
+// configureLinkChecks sets up how checkLink tells that a page that loads
+// isn't the one linked to, from the checks that are enabled and cfg.
+func configureLinkChecks(cfg *sources.CheckerConfig) {
+	utils.SoftNotFound = enabled("soft404")
+	utils.SoftNotFoundPhrases = cfg.Soft404.Phrases
+	utils.ParkedDomains = enabled("parked")
+	utils.LoginWalls = enabled("walls")
+	utils.LoginWallsAllowed = cfg.LoginWalls.Allow
+	utils.RenderDomains = cfg.Render.Domains
+	utils.ChromePath = cfg.Render.Chrome
+	utils.LimitRenders(workers)
+}
+
+// loadRstSpec reads the rstspec.toml given with --rstspec-file, or else
+// fetches the pinned or latest release's.
+func loadRstSpec(cfg *sources.CheckerConfig) *sources.RstSpec {
+	if rstSpecFile == "" {
+		spec, err := sources.NewRoleMap(fetchRstSpec(rstSpecVersion(cfg)))
+		if err != nil {
+			infraFatalf("could not parse the fetched rstspec.toml, rerun with --no-cache if the cached copy is corrupt: %v", err)
+		}
+		return spec
+	}
+	data, err := ioutil.ReadFile(rstSpecFile)
+	if err != nil {
+		configFatalf("could not read rstspec.toml: %v", err)
+	}
+	spec, err := sources.NewRoleMap(data)
+	if err != nil {
+		configFatalf("could not parse %s: %v", rstSpecFile, err)
+	}
+	return spec
+}
+
+// fetchRstSpec fetches the rstspec.toml of the snooty-parser release version,
+// or the latest if version is "", which defines the roles snooty knows about.
+func fetchRstSpec(version string) []byte {
+	if version == "" {
+		tag, err := specCache(cacheTTL).Fetch("snooty-parser-latest-tag", func() ([]byte, error) {
+			tag, err := utils.LatestSnootyParserTag()
+			return []byte(tag), err
+		})
+		if errors.Is(err, cache.ErrStale) {
+			log.Warnf("could not find the latest rstspec.toml: %v", err)
+		} else if err != nil {
+			infraFatalf("could not find the latest rstspec.toml: %v", err)
+		}
+		version = string(tag)
+	}
+	// a release's rstspec.toml never changes, so it's cached for good
+	data, err := specCache(0).Fetch("rstspec-"+version+".toml", func() ([]byte, error) {
+		return utils.FetchNetworkFile(utils.RstSpecURL(version))
+	})
+	if err != nil {
+		infraFatalf("could not fetch rstspec.toml: %v", err)
+	}
+	return data
+}
+
+func checkOpenAPISpec(spec []byte, err error) error {
+	if err != nil {
+		return err
 	}
+	return sources.ValidateOpenAPI(spec)
+}
+
+// projectPath returns path, absolute or relative to the project at basepath,
+// as a clean path relative to the project.
+func projectPath(basepath, path string) string {
+	if filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(basepath, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return strings.TrimPrefix(filepath.Clean("/"+path), "/")
 }
 
 func contains(s []string, e string) bool {
@@ -346,11 +899,144 @@ func contains(s []string, e string) bool {
 	return false
 }
 
+// newDiagnostics returns a channel to send diagnostics to, and a func that
+// closes the channel and returns everything that was sent.
+func newDiagnostics() (chan<- diagnostics.Diagnostic, func() []diagnostics.Diagnostic) {
+	found := make([]diagnostics.Diagnostic, 0)
+	diags := make(chan diagnostics.Diagnostic)
+	done := make(chan struct{})
+	go func() {
+		failures := 0
+		for d := range diags {
+			if projectPrefix != "" {
+				d.File = projectPrefix + d.File
+			}
+			d = promote(rescope(d))
+			emitter.Finding(d)
+			found = append(found, d)
+			if failing(d) {
+				countFailure(&failures)
+			}
+		}
+		close(done)
+	}()
+	return diags, func() []diagnostics.Diagnostic {
+		close(diags)
+		<-done
+		return found
+	}
+}
+
+// workQueue runs jobs on the throttled worker pool as they're added. Adding
+// waits while every worker is busy, so jobs are never all held at once.
+type workQueue struct {
+	jobs       chan func()
+	done       chan struct{}
+	counted    chan struct{}
+	wg         sync.WaitGroup
+	validating *progress.Stage
+}
+
+// startValidating starts the worker pool, waiting for jobs.
+func startValidating() *workQueue {
+	q := &workQueue{
+		jobs:       make(chan func(), workers),
+		done:       make(chan struct{}),
+		counted:    make(chan struct{}),
+		validating: reporter.Start("link validation", 0),
+	}
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go worker(&q.wg, q.jobs, q.done)
+	}
+	go func() {
+		for range q.done {
+			q.validating.Increment()
+		}
+		close(q.counted)
+	}()
+	return q
+}
+
+// Add queues job for the next free worker.
+func (q *workQueue) Add(job func()) {
+	q.validating.AddTotal(1)
+	q.jobs <- job
+}
+
+// Wait waits for every queued job to finish, and stops the workers.
+func (q *workQueue) Wait() {
+	close(q.jobs)
+	q.wg.Wait()
+	close(q.done)
+	<-q.counted
+	q.validating.Finish()
+}
+
+// validate runs every job in workStack on the throttled worker pool.
+func validate(workStack []func()) {
+	q := startValidating()
+	for _, f := range workStack {
+		q.Add(f)
+	}
+	q.Wait()
+}
+
+// writeBadge writes the shields.io endpoint JSON for found to the --badge file,
+// so scheduled runs can publish a docs health badge.
+func writeBadge(found []diagnostics.Diagnostic) {
+	data, err := badge.Links(found).JSON()
+	checkErr(err)
+	checkErr(ioutil.WriteFile(badgeFile, data, 0644))
+}
+
+func report(found []diagnostics.Diagnostic) {
+	errors, warnings, failures := 0, 0, 0
+	for _, d := range found {
+		// with --format jsonl, findings were already streamed as events
+		if d.Severity == diagnostics.Warning {
+			warnings++
+			if logFindings {
+				log.Warn(d)
+			}
+		} else {
+			errors++
+			if logFindings {
+				log.Error(d)
+			}
+		}
+		if failing(d) {
+			failures++
+		}
+	}
+	emitter.Finish(errors, warnings)
+	writeReports(found)
+	if logFindings {
+		printSummary(found)
+	}
+
+	if isAborted() {
+		log.Warnf("Stopped early after reaching %d failures, not everything was checked.\n", maxFailures)
+	}
+	if failures > 0 {
+		log.Error(errors, " errors and ", warnings, " warnings found.\n")
+		exit(exitFindings)
+	} else if errors > 0 || warnings > 0 {
+		log.Info(errors, " errors and ", warnings, " warnings found.\n")
+	} else {
+		log.Info("No errors found.\n")
+	}
+}
+
 func worker(wg *sync.WaitGroup, jobChannel <-chan func(), doneChannel chan<- struct{}) {
 	defer wg.Done()
 	lastExecutionTime := time.Now()
 	minimumTimeBetweenEachExecution := time.Duration(math.Ceil(1e9 / (float64(throttle) / float64(workers))))
 	for job := range jobChannel {
+		if isAborted() {
+			doneChannel <- struct{}{}
+			continue
+		}
 		timeUntilNextExecution := -(time.Since(lastExecutionTime) - minimumTimeBetweenEachExecution)
 		if timeUntilNextExecution > 0 {
 			time.Sleep(timeUntilNextExecution)
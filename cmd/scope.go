@@ -0,0 +1,62 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// scope is the files and directories given as arguments to checker, which
+// limit what's reported without limiting what refs resolve against.
+var scope []string
+
+// scopeFiles returns the files under each path in paths. A path is relative
+// to the working directory or, if nothing is there, to the project.
+func scopeFiles(files []string, paths []string, basepath string) ([]string, error) {
+	scoped := make([]string, 0)
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(abs); err != nil {
+			abs = filepath.Join(basepath, p)
+			if _, err := os.Stat(abs); err != nil {
+				return nil, fmt.Errorf("%s doesn't exist in the working directory or the project", p)
+			}
+		}
+		found := false
+		for _, file := range files {
+			if file == abs || strings.HasPrefix(file, abs+string(filepath.Separator)) {
+				scoped = append(scoped, file)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("there are no files to check in %s", p)
+		}
+	}
+	return scoped, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/terakilobyte/checker/internal/collectors"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/rpc"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Answers checks as JSON-RPC over stdin and stdout.",
+	Long: `Keeps checker running for editors and bots, answering JSON-RPC 2.0 calls read from stdin, one a line,
+with responses written to stdout, one a line, until stdin is closed. Link results and intersphinx
+inventories are kept between calls, so only the first call waits for them. A call that can't be
+answered, like one made before snooty.toml is fixed or while offline, gets an error, and serve keeps
+running.
+
+check checks a file with the whole project as context, optionally with unsaved content instead of
+what's on disk, and returns the problems in it:
+
+{"jsonrpc": "2.0", "id": 1, "method": "check", "params": {"file": "source/index.txt", "content": "..."}}
+
+listRefs returns the ref targets starting with a prefix, and where they're defined:
+
+{"jsonrpc": "2.0", "id": 2, "method": "listRefs", "params": {"prefix": "node-"}}`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if showProgress || emitter != nil {
+			configFatalf("serve answers on stdout, so it can't show progress or stream --format jsonl")
+		}
+		basepath, err := filepath.Abs(path)
+		checkErr(err)
+		base := collectors.FS
+		handlers := map[string]rpc.Handler{
+			"check": func(params json.RawMessage) (interface{}, error) {
+				var p struct {
+					File    string  `json:"file"`
+					Content *string `json:"content"`
+				}
+				if err := json.Unmarshal(params, &p); err != nil || p.File == "" {
+					return nil, &rpc.Error{Code: rpc.InvalidParams, Message: "check needs a file"}
+				}
+				name := p.File
+				if !filepath.IsAbs(name) {
+					name = filepath.Join(basepath, name)
+				}
+				collectors.FS = base
+				if p.Content != nil {
+					// unsaved content is layered over the project, like check -
+					overlay := afero.NewMemMapFs()
+					if err := overlay.MkdirAll(filepath.Dir(name), 0755); err != nil {
+						return nil, err
+					}
+					if err := afero.WriteFile(overlay, name, []byte(*p.Content), 0644); err != nil {
+						return nil, err
+					}
+					collectors.FS = afero.NewCopyOnWriteFs(base, overlay)
+				}
+				collectors.FSUtil = &afero.Afero{Fs: collectors.FS}
+				changes, scope = []string{name}, nil
+				var found []diagnostics.Diagnostic
+				if err := catchFatal(func() { found = runChecks() }); err != nil {
+					return nil, &rpc.Error{Code: rpc.InternalError, Message: err.Error()}
+				}
+				return struct {
+					Diagnostics []diagnostics.Diagnostic `json:"diagnostics"`
+				}{found}, nil
+			},
+			"listRefs": func(params json.RawMessage) (interface{}, error) {
+				var p struct {
+					Prefix string `json:"prefix"`
+				}
+				if len(params) > 0 {
+					if err := json.Unmarshal(params, &p); err != nil {
+						return nil, &rpc.Error{Code: rpc.InvalidParams, Message: "listRefs takes a prefix"}
+					}
+				}
+				var refs []refCompletion
+				if err := catchFatal(func() { refs = refCompletions(p.Prefix) }); err != nil {
+					return nil, &rpc.Error{Code: rpc.InternalError, Message: err.Error()}
+				}
+				return struct {
+					Refs []refCompletion `json:"refs"`
+				}{refs}, nil
+			},
+		}
+		checkErr(rpc.Serve(cmd.InOrStdin(), cmd.OutOrStdout(), handlers))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,135 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/terakilobyte/checker/internal/cache"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+	"github.com/terakilobyte/checker/internal/sources"
+	"github.com/terakilobyte/checker/internal/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// readShared reads the sharedinclude at path from its shared source in
+// checker.toml, or else from snooty.toml's sharedinclude_root. Local sources
+// are relative to the project at basepath. It also returns where it read it
+// from, if it has a shared source.
+func readShared(cfg *sources.CheckerConfig, snooty *sources.TomlConfig, basepath string, path string) ([]byte, string, error) {
+	source, rest := cfg.SharedSource(path, snooty.SharedPath)
+	if source.Root == "" {
+		return nil, "", fmt.Errorf("neither sharedinclude_root in snooty.toml nor shared_sources in %s covers it", config)
+	}
+	if !source.Local() {
+		uri := source.Root + rest
+		var fetchErr error
+		// shared content changes whenever it's edited, so the cached copy is
+		// revalidated every run
+		data, err := specCache(0).Revalidate("shared-"+uri, func(v cache.Validator) ([]byte, cache.Validator, error) {
+			data, etag, lastModified, err := utils.FetchNetworkFileIfChanged(uri, v.ETag, v.LastModified)
+			fetchErr = err
+			return data, cache.Validator{ETag: etag, LastModified: lastModified}, err
+		})
+		// a cached copy of what's since been removed doesn't count
+		if missingShared(fetchErr) {
+			return nil, uri, fetchErr
+		}
+		return data, uri, err
+	}
+	location := source.Root
+	if !filepath.IsAbs(location) {
+		location = filepath.Join(basepath, location)
+	}
+	location = filepath.Join(location, filepath.FromSlash(rest))
+	data, err := ioutil.ReadFile(location)
+	return data, location, err
+}
+
+// missingShared reports whether err is from reading a sharedinclude that
+// isn't at its shared source, rather than one that couldn't be read.
+func missingShared(err error) bool {
+	var status *utils.StatusError
+	if errors.As(err, &status) {
+		return status.Code == http.StatusNotFound || status.Code == http.StatusGone
+	}
+	return os.IsNotExist(err)
+}
+
+// readShareds reads every sharedinclude in shared, each path once, with up to
+// --workers at a time, and returns their contents. Each one that isn't at
+// its shared source is an error in every checked file that includes it. A stale
+// cached copy is used, with a warning, if a shared include can't be fetched
+// again, and otherwise failing to read one exits.
+func readShareds(cfg *sources.CheckerConfig, snooty *sources.TomlConfig, basepath string, shared map[rst.SharedInclude][]string, diags chan<- diagnostics.Diagnostic) [][]byte {
+	paths := make([]string, 0, len(shared))
+	for share := range shared {
+		paths = append(paths, share.Path)
+	}
+	sort.Strings(paths)
+
+	contents := make([][]byte, len(paths))
+	locations := make([]string, len(paths))
+	errs := make([]error, len(paths))
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			contents[i], locations[i], errs[i] = readShared(cfg, snooty, basepath, path)
+		}(i, path)
+	}
+	wg.Wait()
+
+	for i, path := range paths {
+		switch {
+		case missingShared(errs[i]):
+			if !enabled("sharedinclude") {
+				break
+			}
+			for _, filename := range shared[rst.SharedInclude{Path: path}] {
+				if len(changes) > 0 && !contains(changes, strings.TrimPrefix(filename, "/")) {
+					continue
+				}
+				diags <- diagnostics.Errorf(diagnostics.SharedInclude, filename, "sharedinclude %s doesn't exist at %s", path, locations[i]).WithTarget(path).WithReason(diagnostics.MissingShared)
+			}
+		case errors.Is(errs[i], cache.ErrStale):
+			log.Warnf("could not fetch shared include %s again: %v", path, errs[i])
+		case errs[i] != nil:
+			infraFatalf("could not read shared include %s: %v", path, errs[i])
+		}
+	}
+	return contents
+}
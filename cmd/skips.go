@@ -0,0 +1,63 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/sources"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+// skippedLink reports link as a warning instead of it being checked when
+// checker.toml skips its domain for now, so the skip stays in sight until it
+// ends, and returns whether it did.
+func skippedLink(cfg *sources.CheckerConfig, filename string, link string, diags chan<- diagnostics.Diagnostic) bool {
+	u, err := url.Parse(utils.NormalizeURL(link))
+	if err != nil {
+		return false
+	}
+	skip, ok := cfg.SkippedDomain(u.Hostname(), time.Now())
+	if !ok {
+		return false
+	}
+	why := ""
+	if skip.Reason != "" {
+		why = ": " + skip.Reason
+	}
+	diags <- diagnostics.Warnf(diagnostics.Link, filename, "%s wasn't checked, since %s is skipped until %s%s", link, skip.Domain, skip.Until.Format("2006-01-02"), why).WithTarget(link).WithReason(diagnostics.Skipped)
+	return true
+}
+
+// warnExpiredSkips logs the skips in checker.toml that have ended, whose
+// links are checked again, so they can be removed.
+func warnExpiredSkips(cfg *sources.CheckerConfig) {
+	for _, skip := range cfg.SkipDomains {
+		if skip.Expired(time.Now()) {
+			log.Warnf("the skip of %s in checker.toml ended on %s, so its links are checked again; remove it", skip.Domain, skip.Until.Format("2006-01-02"))
+		}
+	}
+}
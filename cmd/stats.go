@@ -0,0 +1,87 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/terakilobyte/checker/internal/collectors"
+)
+
+var topDomains int
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Prints counts of what checker would check.",
+	Long: `Prints per-file and project-wide counts of refs, roles, external links, includes, and constants,
+followed by the most linked external domains.
+
+Nothing is fetched, so stats is a quick way to estimate how long a full check will take.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		basepath, err := filepath.Abs(path)
+		checkErr(err)
+		files := gatherFiles(basepath)
+		stats, err := collectors.GatherStats(files)
+		checkErr(err)
+
+		filenames := make([]string, 0, len(stats))
+		for filename := range stats {
+			filenames = append(filenames, filename)
+		}
+		sort.Strings(filenames)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FILE\tREFS\tROLES\tLINKS\tINCLUDES\tCONSTANTS")
+		var total collectors.FileStats
+		for _, filename := range filenames {
+			s := stats[filename]
+			total = total.Add(s)
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\n", filename, s.Refs, s.Roles, s.Links, s.Includes, s.Constants)
+		}
+		fmt.Fprintf(w, "total (%d files)\t%d\t%d\t%d\t%d\t%d\n", len(filenames), total.Refs, total.Roles, total.Links, total.Includes, total.Constants)
+		checkErr(w.Flush())
+
+		domains, err := collectors.GatherTopDomains(files, topDomains)
+		checkErr(err)
+		if len(domains) == 0 {
+			return
+		}
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DOMAIN\tLINKS")
+		for _, domain := range domains {
+			fmt.Fprintf(w, "%s\t%d\n", domain.Domain, domain.Count)
+		}
+		checkErr(w.Flush())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().IntVar(&topDomains, "top", 10, "number of external domains to list, or 0 for all")
+}
@@ -0,0 +1,78 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+var strict bool
+
+// strictHook captures warnings logged while checking, like files that couldn't
+// be parsed, so --strict can report them as errors.
+type strictHook struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (h *strictHook) Levels() []log.Level {
+	return []log.Level{log.WarnLevel}
+}
+
+func (h *strictHook) Fire(entry *log.Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry.Message)
+	return nil
+}
+
+// watchWarnings starts capturing logged warnings when --strict is set. The returned
+// func stops capturing and sends what was captured to diags as errors.
+func watchWarnings(diags chan<- diagnostics.Diagnostic) func() {
+	if !strict {
+		return func() {}
+	}
+	previous := make(log.LevelHooks)
+	for level, hooks := range log.StandardLogger().Hooks {
+		previous[level] = append([]log.Hook(nil), hooks...)
+	}
+	hook := &strictHook{}
+	log.AddHook(hook)
+	return func() {
+		log.StandardLogger().ReplaceHooks(previous)
+		for _, entry := range hook.entries {
+			diags <- diagnostics.Errorf(diagnostics.Checker, "", "%s", entry)
+		}
+	}
+}
+
+// promote makes d an error when --strict is set.
+func promote(d diagnostics.Diagnostic) diagnostics.Diagnostic {
+	if strict && d.Severity == diagnostics.Warning {
+		d.Severity = diagnostics.Error
+	}
+	return d
+}
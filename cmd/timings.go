@@ -0,0 +1,80 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// slowest is how many hosts and urls the timing report lists
+const slowest = 10
+
+var timings bool
+
+func init() {
+	rootCmd.Flags().BoolVar(&timings, "timings", false, "print how long each stage took and the slowest hosts and urls")
+}
+
+// printTimings reports where the run spent its time, so it's clear whether to
+// tune --workers and --throttle or skip slow hosts. It goes to stderr, so it
+// doesn't end up in a report written to stdout.
+func printTimings() {
+	if !timings {
+		return
+	}
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tTIME")
+	for _, stage := range tracer.Stages() {
+		fmt.Fprintf(w, "%s\t%s\n", stage.Name, stage.Duration.Round(time.Millisecond))
+	}
+	checkErr(w.Flush())
+
+	hosts := tracer.Hosts()
+	if len(hosts) == 0 {
+		return
+	}
+	if len(hosts) > slowest {
+		hosts = hosts[:slowest]
+	}
+	fmt.Fprintln(os.Stderr)
+	w = tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tREQUESTS\tTOTAL\tP50\tP95\tSLOWEST")
+	for _, host := range hosts {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", host.Host, host.Requests, host.Total.Round(time.Millisecond), host.P50.Round(time.Millisecond), host.P95.Round(time.Millisecond), host.Slowest.Round(time.Millisecond))
+	}
+	checkErr(w.Flush())
+
+	requests := tracer.Requests()
+	if len(requests) > slowest {
+		requests = requests[:slowest]
+	}
+	fmt.Fprintln(os.Stderr)
+	w = tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "URL\tTIME")
+	for _, request := range requests {
+		fmt.Fprintf(w, "%s\t%s\n", request.Name, request.Duration.Round(time.Millisecond))
+	}
+	checkErr(w.Flush())
+}
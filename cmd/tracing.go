@@ -0,0 +1,93 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/terakilobyte/checker/internal/tracing"
+	"github.com/terakilobyte/checker/internal/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	traceEndpoint string
+	// tracer is nil unless tracing is enabled, and records nothing when nil
+	tracer *tracing.Tracer
+)
+
+func init() {
+	rootCmd.Flags().StringVar(&traceEndpoint, "trace-endpoint", "", "export a trace of the run to this OTLP/HTTP traces endpoint, defaults to OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+}
+
+// otlpEndpoint finds where to export traces, following the OpenTelemetry
+// environment variables when --trace-endpoint isn't set.
+func otlpEndpoint() string {
+	if traceEndpoint != "" {
+		return traceEndpoint
+	}
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"); endpoint != "" {
+		return endpoint
+	}
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	}
+	return ""
+}
+
+// otlpHeaders parses OTEL_EXPORTER_OTLP_HEADERS, like "api-key=secret,team=docs".
+func otlpHeaders() map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return headers
+}
+
+// startTracing starts tracing the run if an endpoint is configured or timings
+// were asked for. The returned func ends the trace and exports it. Failing to
+// export it is only a warning.
+func startTracing() func() {
+	endpoint := otlpEndpoint()
+	if endpoint == "" && !timings {
+		return func() {}
+	}
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "checker"
+	}
+	tracer = tracing.NewTracer("checker")
+	utils.WrapTransport(tracer.Transport)
+	return func() {
+		tracer.End()
+		if endpoint == "" {
+			return
+		}
+		if err := tracer.Export(endpoint, service, otlpHeaders()); err != nil {
+			log.Warnf("could not export trace to %s: %v", endpoint, err)
+		}
+	}
+}
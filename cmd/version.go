@@ -0,0 +1,97 @@
+/*
+Copyright © 2021 Nathan Leniz <terakilobyte@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/terakilobyte/checker/internal/sources"
+)
+
+// commit and buildDate are injected at build time with
+//
+//	go build -ldflags "-X github.com/terakilobyte/checker/cmd.commit=$(git rev-parse HEAD) -X github.com/terakilobyte/checker/cmd.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// and otherwise come from the version control information go build embeds.
+var (
+	commit    string
+	buildDate string
+)
+
+func init() {
+	cobra.AddTemplateFunc("buildInfo", buildInfo)
+}
+
+// buildInfo describes exactly what's running, for bug reports: the commit and
+// date it was built from, the Go version, and the rstspec.toml it validates
+// roles against.
+func buildInfo() string {
+	revision, date := commit, buildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && revision == "":
+				revision = setting.Value
+			case setting.Key == "vcs.time" && date == "":
+				date = setting.Value
+			}
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "commit: %s\n", orUnknown(revision))
+	fmt.Fprintf(&b, "built: %s\n", orUnknown(date))
+	fmt.Fprintf(&b, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "rstspec: %s\n", rstSpecInUse())
+	return b.String()
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// rstSpecInUse describes the rstspec.toml a run with the same flags would
+// use, without fetching anything. An unreadable checker.toml is ignored, since
+// the run would report it.
+func rstSpecInUse() string {
+	if rstSpecFile != "" {
+		return rstSpecFile + " (--rstspec-file)"
+	}
+	if rstSpecPin != "" {
+		return rstSpecPin + " (--rstspec-version)"
+	}
+	input, err := ioutil.ReadFile(filepath.Join(path, config))
+	if err == nil {
+		if cfg, err := sources.NewCheckerConfig(input); err == nil && cfg.RstSpecVersion != "" {
+			return cfg.RstSpecVersion + " (" + config + ")"
+		}
+	}
+	return "latest release"
+}
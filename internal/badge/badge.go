@@ -0,0 +1,51 @@
+// Package badge summarizes a run as a shields.io endpoint badge.
+package badge
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+// Endpoint is the JSON shields.io reads to render a badge, see
+// https://shields.io/endpoint.
+type Endpoint struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// Links summarizes the health of a project's links: how many are broken, or
+// passing if none are. A link is broken whatever its finding's severity, so
+// --warn-external doesn't make the badge pass, but links that weren't checked,
+// have been flaky, or have a certificate that's only expiring aren't.
+func Links(found []diagnostics.Diagnostic) Endpoint {
+	broken := 0
+	for _, d := range found {
+		if d.Category != diagnostics.Link && d.Category != diagnostics.Certificate {
+			continue
+		}
+		switch d.Reason {
+		case diagnostics.Skipped, diagnostics.Flaky, diagnostics.CertExpiring:
+		default:
+			broken++
+		}
+	}
+	e := Endpoint{SchemaVersion: 1, Label: "links", Message: "passing", Color: "brightgreen"}
+	if broken > 0 {
+		e.Message = fmt.Sprintf("%d broken", broken)
+		e.Color = "red"
+	}
+	return e
+}
+
+// JSON encodes e as shields.io expects it.
+func (e Endpoint) JSON() ([]byte, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
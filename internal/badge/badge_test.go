@@ -0,0 +1,50 @@
+package badge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+func TestLinks(t *testing.T) {
+	cases := []struct {
+		name     string
+		found    []diagnostics.Diagnostic
+		expected Endpoint
+	}{{
+		name:     "no diagnostics",
+		found:    nil,
+		expected: Endpoint{SchemaVersion: 1, Label: "links", Message: "passing", Color: "brightgreen"},
+	}, {
+		name: "only counts broken links",
+		found: []diagnostics.Diagnostic{
+			diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "broken"),
+			diagnostics.Errorf(diagnostics.Link, "/source/faq.txt", "broken"),
+			diagnostics.Warnf(diagnostics.Link, "/source/faq.txt", "wasn't checked").WithReason(diagnostics.Skipped),
+			diagnostics.Errorf(diagnostics.Ref, "/source/faq.txt", "bad ref"),
+		},
+		expected: Endpoint{SchemaVersion: 1, Label: "links", Message: "2 broken", Color: "red"},
+	}, {
+		name: "counts failures reported as warnings",
+		found: []diagnostics.Diagnostic{
+			diagnostics.Warnf(diagnostics.Link, "/source/index.txt", "broken").WithReason(diagnostics.ClientError),
+			diagnostics.Warnf(diagnostics.Certificate, "/source/index.txt", "expired").WithReason(diagnostics.CertExpired),
+			diagnostics.Warnf(diagnostics.Certificate, "/source/faq.txt", "expires soon").WithReason(diagnostics.CertExpiring),
+			diagnostics.Warnf(diagnostics.Link, "/source/faq.txt", "broken, but flaky").WithReason(diagnostics.Flaky),
+		},
+		expected: Endpoint{SchemaVersion: 1, Label: "links", Message: "2 broken", Color: "red"},
+	}}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Links(test.found))
+		})
+	}
+}
+
+func TestEndpointJSON(t *testing.T) {
+	data, err := Endpoint{SchemaVersion: 1, Label: "links", Message: "3 broken", Color: "red"}.JSON()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"schemaVersion":1,"label":"links","message":"3 broken","color":"red"}`+"\n", string(data))
+}
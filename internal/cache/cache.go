@@ -0,0 +1,140 @@
+// Package cache keeps fetched files on disk between runs, so checker doesn't
+// refetch what rarely changes, like rstspec.toml, on every run.
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrStale is wrapped by the error Fetch returns along with cached data that
+// couldn't be revalidated.
+var ErrStale = errors.New("using stale cached copy")
+
+// Cache is a directory of cached files, each fresh for a TTL after it's
+// written. A nil Cache caches nothing.
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+// New caches in dir, creating it when needed. Files are fresh for ttl, or
+// forever if ttl is 0, for things that never change once published.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl}
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, url.PathEscape(key))
+}
+
+// Get returns what's cached for key, if anything, and whether it's still
+// fresh.
+func (c *Cache) Get(key string) (data []byte, fresh bool, ok bool) {
+	if c == nil {
+		return nil, false, false
+	}
+	info, err := os.Stat(c.path(key))
+	if err != nil {
+		return nil, false, false
+	}
+	data, err = ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false, false
+	}
+	return data, c.ttl == 0 || time.Since(info.ModTime()) < c.ttl, true
+}
+
+// Put caches data for key, replacing what was there.
+func (c *Cache) Put(key string, data []byte) error {
+	if c == nil {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(c.dir, url.PathEscape(key))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// Fetch returns what's cached for key while it's fresh, and otherwise calls
+// fetch and caches the result. If fetch fails and a stale copy is cached, the
+// stale copy is returned with an error wrapping ErrStale, so a flaky network
+// doesn't fail a run that has something to go on. Failing to write the cache
+// isn't an error.
+func (c *Cache) Fetch(key string, fetch func() ([]byte, error)) ([]byte, error) {
+	cached, fresh, ok := c.Get(key)
+	if fresh {
+		return cached, nil
+	}
+	data, err := fetch()
+	if err != nil {
+		if ok {
+			return cached, fmt.Errorf("%w for %s: %v", ErrStale, key, err)
+		}
+		return nil, err
+	}
+	_ = c.Put(key, data)
+	return data, nil
+}
+
+// Validator identifies the version of a file that was fetched, like an http
+// ETag, so a cached copy can be revalidated without fetching it again.
+type Validator struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+func validatorKey(key string) string {
+	return key + ".validator"
+}
+
+// Revalidate is Fetch for files that can change at any time, but are cheap to
+// ask about. fetch is given the Validator of what's cached for key, the zero
+// Validator if nothing is, and returns nil data if that's still current, or
+// else the new data and its Validator, which are cached. A cached copy is
+// returned with ErrStale if fetch fails, like Fetch.
+func (c *Cache) Revalidate(key string, fetch func(Validator) ([]byte, Validator, error)) ([]byte, error) {
+	cached, _, ok := c.Get(key)
+	var v Validator
+	if ok {
+		if raw, _, found := c.Get(validatorKey(key)); found {
+			_ = json.Unmarshal(raw, &v)
+		}
+	}
+	data, next, err := fetch(v)
+	if err != nil {
+		if ok {
+			return cached, fmt.Errorf("%w for %s: %v", ErrStale, key, err)
+		}
+		return nil, err
+	}
+	if data == nil && ok {
+		return cached, nil
+	}
+	if err := c.Put(key, data); err == nil {
+		raw, _ := json.Marshal(next)
+		_ = c.Put(validatorKey(key), raw)
+	}
+	return data, nil
+}
@@ -0,0 +1,112 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchCaches(t *testing.T) {
+	c := New(t.TempDir(), time.Hour)
+	calls := 0
+	fetch := func() ([]byte, error) {
+		calls++
+		return []byte("v0.13.7"), nil
+	}
+
+	for i := 0; i < 2; i++ {
+		data, err := c.Fetch("snooty-parser/latest", fetch)
+		assert.NoError(t, err)
+		assert.Equal(t, "v0.13.7", string(data))
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestFetchRevalidatesStale(t *testing.T) {
+	c := New(t.TempDir(), time.Hour)
+	assert.NoError(t, c.Put("tag", []byte("v1")))
+	old := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(c.path("tag"), old, old))
+
+	_, fresh, ok := c.Get("tag")
+	assert.True(t, ok)
+	assert.False(t, fresh)
+
+	data, err := c.Fetch("tag", func() ([]byte, error) { return []byte("v2"), nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", string(data))
+
+	data, fresh, _ = c.Get("tag")
+	assert.True(t, fresh)
+	assert.Equal(t, "v2", string(data))
+}
+
+func TestFetchFallsBackToStale(t *testing.T) {
+	c := New(t.TempDir(), time.Hour)
+	assert.NoError(t, c.Put("tag", []byte("v1")))
+	old := time.Now().Add(-2 * time.Hour)
+	assert.NoError(t, os.Chtimes(c.path("tag"), old, old))
+
+	data, err := c.Fetch("tag", func() ([]byte, error) { return nil, errors.New("rate limited") })
+	assert.True(t, errors.Is(err, ErrStale))
+	assert.Equal(t, "v1", string(data))
+
+	_, err = c.Fetch("missing", func() ([]byte, error) { return nil, errors.New("rate limited") })
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrStale))
+}
+
+func TestNoTTLNeverExpires(t *testing.T) {
+	c := New(t.TempDir(), 0)
+	assert.NoError(t, c.Put("rstspec", []byte("[role.guilabel]")))
+	old := time.Now().Add(-24 * 365 * time.Hour)
+	assert.NoError(t, os.Chtimes(c.path("rstspec"), old, old))
+
+	_, fresh, ok := c.Get("rstspec")
+	assert.True(t, ok)
+	assert.True(t, fresh)
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+	_, _, ok := c.Get("tag")
+	assert.False(t, ok)
+	assert.NoError(t, c.Put("tag", nil))
+	data, err := c.Fetch("tag", func() ([]byte, error) { return []byte("v1"), nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+}
+
+func TestRevalidate(t *testing.T) {
+	c := New(t.TempDir(), 0)
+	var sent []Validator
+	fetch := func(changed bool) func(Validator) ([]byte, Validator, error) {
+		return func(v Validator) ([]byte, Validator, error) {
+			sent = append(sent, v)
+			if !changed && v.ETag == `"v1"` {
+				return nil, Validator{}, nil
+			}
+			return []byte("shared v1"), Validator{ETag: `"v1"`}, nil
+		}
+	}
+
+	data, err := c.Revalidate("shared", fetch(false))
+	assert.NoError(t, err)
+	assert.Equal(t, "shared v1", string(data))
+	data, err = c.Revalidate("shared", fetch(false))
+	assert.NoError(t, err)
+	assert.Equal(t, "shared v1", string(data))
+	assert.Equal(t, []Validator{{}, {ETag: `"v1"`}}, sent)
+
+	data, err = c.Revalidate("shared", func(Validator) ([]byte, Validator, error) { return nil, Validator{}, errors.New("offline") })
+	assert.True(t, errors.Is(err, ErrStale))
+	assert.Equal(t, "shared v1", string(data))
+
+	var none *Cache
+	data, err = none.Revalidate("shared", fetch(false))
+	assert.NoError(t, err)
+	assert.Equal(t, "shared v1", string(data))
+}
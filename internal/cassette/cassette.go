@@ -0,0 +1,184 @@
+// Package cassette records checker's network requests and their responses,
+// and replays them later without the network, so runs can be repeated exactly.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+// Interaction is a request and what came back: a response, or an error.
+type Interaction struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	// Body is as much of the response as checker read, which for a link is
+	// only the start of a page, and none of a download
+	Body  []byte `json:"body,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Reason is why the request failed, for triage, so it isn't lost when the
+	// error is replayed as text
+	Reason diagnostics.Reason `json:"reason,omitempty"`
+}
+
+// Cassette is a sequence of interactions. It's safe for concurrent use.
+type Cassette struct {
+	mu           sync.Mutex
+	Interactions []Interaction `json:"interactions"`
+	// played counts how many interactions for each request have been replayed
+	played map[string]int
+}
+
+func key(method string, url string) string {
+	return method + " " + url
+}
+
+// Load reads a cassette written by Save.
+func Load(filename string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%s is not a cassette: %v", filename, err)
+	}
+	return &c, nil
+}
+
+// Save writes the recorded interactions to filename.
+func (c *Cassette) Save(filename string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+func (c *Cassette) add(interaction Interaction) {
+	c.mu.Lock()
+	c.Interactions = append(c.Interactions, interaction)
+	c.mu.Unlock()
+}
+
+// Record wraps next, recording every request made through it. A response is
+// recorded once its body is closed, with as much of the body as was read, so
+// recording never reads more than checker would have, or fails a request.
+func (c *Cassette) Record(next http.RoundTripper) http.RoundTripper {
+	return utils.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		interaction := Interaction{Method: req.Method, URL: req.URL.String()}
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			interaction.Error = err.Error()
+			interaction.Reason = utils.FailureReason(err)
+			c.add(interaction)
+			return resp, err
+		}
+		interaction.Status = resp.StatusCode
+		interaction.Header = resp.Header
+		resp.Body = &recordedBody{ReadCloser: resp.Body, done: func(read []byte) {
+			interaction.Body = read
+			c.add(interaction)
+		}}
+		return resp, nil
+	})
+}
+
+// recordedBody keeps what's read of a response body, and hands it to done
+// when it's closed.
+type recordedBody struct {
+	io.ReadCloser
+	read   bytes.Buffer
+	done   func([]byte)
+	closed sync.Once
+}
+
+func (b *recordedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.read.Write(p[:n])
+	return n, err
+}
+
+func (b *recordedBody) Close() error {
+	b.closed.Do(func() { b.done(b.read.Bytes()) })
+	return b.ReadCloser.Close()
+}
+
+// ReplayedError is a recorded request's error, replayed with the reason it
+// failed.
+type ReplayedError struct {
+	Message string
+	Reason  diagnostics.Reason
+}
+
+func (e *ReplayedError) Error() string {
+	return e.Message
+}
+
+// FailureReason is why the recorded request failed.
+func (e *ReplayedError) FailureReason() diagnostics.Reason {
+	return e.Reason
+}
+
+// ErrNotRecorded is returned when replaying a request that isn't on the cassette.
+var ErrNotRecorded = errors.New("no recorded response")
+
+// Replay returns a transport that answers requests from the cassette instead
+// of the network. Repeated requests get the recorded responses in order, and
+// the last one once those run out.
+func (c *Cassette) Replay() http.RoundTripper {
+	return utils.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		k := key(req.Method, req.URL.String())
+		c.mu.Lock()
+		if c.played == nil {
+			c.played = make(map[string]int)
+		}
+		matches := make([]Interaction, 0, 1)
+		for _, interaction := range c.Interactions {
+			if key(interaction.Method, interaction.URL) == k {
+				matches = append(matches, interaction)
+			}
+		}
+		n := c.played[k]
+		c.played[k]++
+		c.mu.Unlock()
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%w for %s", ErrNotRecorded, k)
+		}
+		if n >= len(matches) {
+			n = len(matches) - 1
+		}
+		interaction := matches[n]
+		if interaction.Error != "" {
+			return nil, &ReplayedError{Message: interaction.Error, Reason: interaction.Reason}
+		}
+		header := interaction.Header
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+			StatusCode:    interaction.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header.Clone(),
+			Body:          ioutil.NopCloser(bytes.NewReader(interaction.Body)),
+			ContentLength: int64(len(interaction.Body)),
+			Request:       req,
+		}, nil
+	})
+}
@@ -0,0 +1,131 @@
+package cassette
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+func get(t *testing.T, transport http.RoundTripper, url string) (int, string, error) {
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return resp.StatusCode, string(body), nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, "call %d", calls)
+	}))
+
+	recording := &Cassette{}
+	transport := recording.Record(http.DefaultTransport)
+	for _, path := range []string{"/", "/", "/missing"} {
+		_, _, err := get(t, transport, server.URL+path)
+		assert.NoError(t, err)
+	}
+	server.Close()
+	_, _, err := get(t, transport, server.URL+"/closed")
+	assert.Error(t, err)
+
+	filename := filepath.Join(t.TempDir(), "cassette.json")
+	assert.NoError(t, recording.Save(filename))
+	loaded, err := Load(filename)
+	assert.NoError(t, err)
+	replay := loaded.Replay()
+
+	cases := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{path: "/", status: 200, body: "call 1"},
+		{path: "/", status: 200, body: "call 2"},
+		{path: "/", status: 200, body: "call 2"},
+		{path: "/missing", status: 404, body: ""},
+	}
+	for _, test := range cases {
+		status, body, err := get(t, replay, server.URL+test.path)
+		assert.NoError(t, err)
+		assert.Equal(t, test.status, status, test.path)
+		assert.Equal(t, test.body, body, test.path)
+	}
+
+	_, _, err = get(t, replay, server.URL+"/closed")
+	assert.Error(t, err)
+	_, _, err = get(t, replay, server.URL+"/never")
+	assert.True(t, errors.Is(err, ErrNotRecorded), err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestLoadRejectsOtherFiles(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cassette.json")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("not json"), 0644))
+
+	_, err := Load(filename)
+	assert.Error(t, err)
+}
+
+func TestRecordKeepsWhatWasRead(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 1000)))
+	}))
+	defer server.Close()
+
+	recording := &Cassette{}
+	client := &http.Client{Transport: recording.Record(http.DefaultTransport)}
+	resp, err := client.Get(server.URL + "/download.tar.gz")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	resp, err = client.Get(server.URL + "/page")
+	assert.NoError(t, err)
+	_, err = io.ReadFull(resp.Body, make([]byte, 10))
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Len(t, recording.Interactions, 2)
+	assert.Empty(t, recording.Interactions[0].Body, "a body that wasn't read isn't recorded")
+	assert.Equal(t, strings.Repeat("a", 10), string(recording.Interactions[1].Body))
+}
+
+func TestReplayKeepsFailureReason(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	recording := &Cassette{}
+	_, _, err := get(t, recording.Record(http.DefaultTransport), server.URL)
+	assert.Equal(t, diagnostics.CertUnknownAuthority, utils.FailureReason(err))
+	assert.Equal(t, diagnostics.CertUnknownAuthority, recording.Interactions[0].Reason)
+
+	_, _, err = get(t, recording.Replay(), server.URL)
+	var replayed *ReplayedError
+	assert.True(t, errors.As(err, &replayed))
+	assert.Equal(t, diagnostics.CertUnknownAuthority, utils.FailureReason(err))
+}
@@ -0,0 +1,105 @@
+package collectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+// dataFile returns the json or yaml file a directive like literalinclude,
+// output, or charts points at, relative to the project, or "" if its
+// argument isn't one.
+func dataFile(filename string, directive rst.RstDirective) string {
+	target := strings.TrimSpace(directive.Target)
+	if utils.IsHTTPLink(target) {
+		return ""
+	}
+	switch strings.ToLower(filepath.Ext(target)) {
+	case ".json", ".yaml", ".yml":
+		return sourcePath(filename, target)
+	}
+	return ""
+}
+
+// dataLinks returns the http(s) links in the string values of a json or
+// yaml file, in the order they're found.
+func dataLinks(name string, input []byte) ([]rst.RstHTTPLink, error) {
+	values := make([]interface{}, 0, 1)
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		var v interface{}
+		if err := json.Unmarshal(input, &v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	} else {
+		// yaml files may be a stream of documents
+		dec := yaml.NewDecoder(bytes.NewReader(input))
+		for {
+			var v interface{}
+			err := dec.Decode(&v)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+	}
+	links := make([]rst.RstHTTPLink, 0)
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case string:
+			links = append(links, rst.ParseForHTTPLinks([]byte(v))...)
+		case map[string]interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	for _, v := range values {
+		walk(v)
+	}
+	return links, nil
+}
+
+// directiveDataLinks calls fn with every http(s) link in the json and yaml
+// files that directives in filename point at. Files that are missing or
+// can't be parsed are skipped.
+func directiveDataLinks(filename string, directives []rst.RstDirective, fn func(link rst.RstHTTPLink)) {
+	seen := make(map[string]bool)
+	for _, directive := range directives {
+		name := dataFile(filename, directive)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		data, err := FSUtil.ReadFile(filepath.Join(basepath, filepath.FromSlash(name)))
+		if err != nil {
+			log.Debugf("could not read %s, included by %s: %v", name, filename, err)
+			continue
+		}
+		links, err := dataLinks(name, data)
+		if err != nil {
+			log.Warnf("could not parse %s, included by %s, for links: %v", name, filename, err)
+			continue
+		}
+		for _, link := range links {
+			fn(link)
+		}
+	}
+}
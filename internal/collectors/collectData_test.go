@@ -0,0 +1,53 @@
+package collectors
+
+import (
+	"path/filepath"
+	"testing"
+
+	iowrap "github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+)
+
+func TestDataFile(t *testing.T) {
+	assert.Equal(t, "/source/includes/chart.json", dataFile("/source/index.txt", rst.RstDirective{Name: "charts", Target: "/includes/chart.json "}))
+	assert.Equal(t, "/source/fundamentals/out.yaml", dataFile("/source/fundamentals/crud.txt", rst.RstDirective{Name: "output", Target: "out.yaml"}))
+	assert.Equal(t, "", dataFile("/source/index.txt", rst.RstDirective{Name: "include", Target: "/includes/intro.rst"}))
+	assert.Equal(t, "", dataFile("/source/index.txt", rst.RstDirective{Name: "openapi", Target: "https://example.com/spec.json"}))
+}
+
+func TestDataLinks(t *testing.T) {
+	links, err := dataLinks("chart.json", []byte(`{"source": "https://example.com/data", "series": [{"docs": "see https://example.com/docs"}], "width": 4}`))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []rst.RstHTTPLink{"https://example.com/data", "https://example.com/docs"}, links)
+
+	links, err = dataLinks("out.yaml", []byte("- url: https://example.com/a\n---\nnested:\n  more: [https://example.com/b]\n"))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []rst.RstHTTPLink{"https://example.com/a", "https://example.com/b"}, links)
+
+	_, err = dataLinks("bad.json", []byte(`{"url": `))
+	assert.Error(t, err)
+}
+
+func TestGatherDirectiveDataLinks(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "includes"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), []byte(".. literalinclude:: /includes/output.json\n\n.. literalinclude:: /includes/missing.json\n"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "includes", "output.json"), []byte(`{"_id": 1, "homepage": "https://example.com/home"}`), 0644))
+
+	expected := map[rst.RstHTTPLink]string{"https://example.com/home": "/source/index.txt"}
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	links, err := GatherHTTPLinks(files)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, links)
+	project, err := GatherProject(files, Collect{})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, project.HTTPLinks)
+	uses, err := GatherHTTPLinkUses(files)
+	assert.NoError(t, err)
+	assert.Equal(t, map[rst.RstHTTPLink][]string{"https://example.com/home": {"/source/index.txt"}}, uses)
+}
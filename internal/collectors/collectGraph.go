@@ -0,0 +1,75 @@
+package collectors
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+)
+
+// Edge is a dependency from one file to another, by include, sharedinclude, or doc reference.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// sourcePath resolves an include or doc path the way snooty does: absolute
+// paths are relative to the source directory, others to the referencing file.
+func sourcePath(filename string, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return path.Join("/source", target)
+	}
+	return path.Join(path.Dir(filename), target)
+}
+
+// GatherGraph returns the include, sharedinclude, and doc reference edges
+// between files in the project, sorted and without duplicates. Doc references
+// are resolved to the file of the page they point to when it exists, shared
+// includes keep their shared path.
+func GatherGraph(files []string) ([]Edge, error) {
+	pages := GatherPageRoutes(files)
+	seen := make(map[Edge]bool)
+	edges := make([]Edge, 0)
+	add := func(e Edge) {
+		if !seen[e] {
+			seen[e] = true
+			edges = append(edges, e)
+		}
+	}
+
+	if err := gather(files, func(filename string, data []byte) {
+		for _, directive := range rst.ParseForDirectives(data) {
+			switch directive.Name {
+			case "include":
+				add(Edge{From: filename, To: sourcePath(filename, strings.TrimSpace(directive.Target)), Kind: "include"})
+			case "sharedinclude":
+				add(Edge{From: filename, To: strings.TrimSpace(directive.Target), Kind: "sharedinclude"})
+			}
+		}
+		for _, role := range rst.ParseForRoles(data) {
+			if role.Name != "doc" {
+				continue
+			}
+			to := docRoute(sourcePath(filename, role.Target))
+			if page, ok := pages[to]; ok {
+				to = page
+			}
+			add(Edge{From: filename, To: to, Kind: "doc"})
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].Kind < edges[j].Kind
+	})
+	return edges, nil
+}
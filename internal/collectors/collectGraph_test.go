@@ -0,0 +1,41 @@
+package collectors
+
+import (
+	"path/filepath"
+	"testing"
+
+	iowrap "github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourcePath(t *testing.T) {
+	assert.Equal(t, "/source/includes/foo.rst", sourcePath("/source/fundamentals/crud.txt", "/includes/foo.rst"))
+	assert.Equal(t, "/source/fundamentals/includes/foo.rst", sourcePath("/source/fundamentals/crud.txt", "includes/foo.rst"))
+	assert.Equal(t, "/source/faq", sourcePath("/source/fundamentals/crud.txt", "../faq"))
+}
+
+func TestGatherGraph(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "fundamentals"), 0755))
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "includes"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), []byte(".. include:: /includes/intro.rst\n\n:doc:`CRUD </fundamentals/crud>` and :doc:`/missing`\n"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "crud.txt"), []byte(".. include:: /includes/intro.rst\n.. sharedinclude:: dbx/about-compatibility.rst\n\n:doc:`../index`\n"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "includes", "intro.rst"), []byte("intro\n"), 0644))
+
+	expected := []Edge{
+		{From: "/source/fundamentals/crud.txt", To: "/source/includes/intro.rst", Kind: "include"},
+		{From: "/source/fundamentals/crud.txt", To: "/source/index.txt", Kind: "doc"},
+		{From: "/source/fundamentals/crud.txt", To: "dbx/about-compatibility.rst", Kind: "sharedinclude"},
+		{From: "/source/index.txt", To: "/missing", Kind: "doc"},
+		{From: "/source/index.txt", To: "/source/fundamentals/crud.txt", Kind: "doc"},
+		{From: "/source/index.txt", To: "/source/includes/intro.rst", Kind: "include"},
+	}
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	edges, err := GatherGraph(files)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, edges)
+}
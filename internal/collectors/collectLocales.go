@@ -0,0 +1,116 @@
+package collectors
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+)
+
+// LocaleTree is one tree of a project: its source, or a translation of it in
+// a directory of source named for the locale, like source/zh-cn.
+type LocaleTree struct {
+	// Pages maps the routes of the tree's pages, relative to the tree, to
+	// their files
+	Pages map[string]string
+	// Labels maps the labels defined in the tree to the files defining them
+	Labels map[string]string
+	// Links maps the routes of the tree's pages to the urls they link to
+	Links map[string]map[string]bool
+}
+
+// LocaleDifference is a page, label, or link in one tree that its
+// counterpart doesn't have.
+type LocaleDifference struct {
+	Kind string
+	Name string
+	File string
+}
+
+// GatherLocaleTree gathers the tree of locale from files. The source tree,
+// when locale is "", leaves out the trees of every one of locales.
+func GatherLocaleTree(files []string, locale string, locales []string) (*LocaleTree, error) {
+	root := "/source"
+	if locale != "" {
+		root = "/source/" + locale
+	}
+	tree := &LocaleTree{Pages: make(map[string]string), Labels: make(map[string]string), Links: make(map[string]map[string]bool)}
+	inTree := make([]string, 0)
+	for _, file := range files {
+		fileName := filepath.ToSlash(strings.Replace(file, basepath, "", 1))
+		if !strings.HasPrefix(fileName, root+"/") || (locale == "" && inLocale(fileName, locales)) {
+			continue
+		}
+		inTree = append(inTree, file)
+	}
+	if err := gather(inTree, func(filename string, data []byte) {
+		filename = filepath.ToSlash(filename)
+		entities := rst.Lex(data)
+		for _, label := range entities.LocalRefs {
+			tree.Labels[label.Name] = filename
+		}
+		relative := strings.TrimPrefix(filename, root)
+		if strings.HasPrefix(relative, "/includes/") {
+			return
+		}
+		switch path.Ext(filename) {
+		case ".txt", ".rst", ".md", ".mdx":
+		default:
+			return
+		}
+		route := docRoute("/source" + relative)
+		tree.Pages[route] = filename
+		tree.Links[route] = make(map[string]bool)
+		for _, link := range entities.HTTPLinks {
+			tree.Links[route][string(link)] = true
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+func inLocale(fileName string, locales []string) bool {
+	for _, locale := range locales {
+		if strings.HasPrefix(fileName, "/source/"+locale+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Missing returns the pages and labels in tree that other doesn't have, and
+// the links from pages in both that other's page doesn't have, sorted by
+// file.
+func (tree *LocaleTree) Missing(other *LocaleTree) []LocaleDifference {
+	missing := make([]LocaleDifference, 0)
+	for route, file := range tree.Pages {
+		otherLinks, ok := other.Links[route]
+		if !ok {
+			missing = append(missing, LocaleDifference{Kind: "page", Name: route, File: file})
+			continue
+		}
+		for link := range tree.Links[route] {
+			if !otherLinks[link] {
+				missing = append(missing, LocaleDifference{Kind: "link", Name: link, File: file})
+			}
+		}
+	}
+	for label, file := range tree.Labels {
+		if _, ok := other.Labels[label]; !ok {
+			missing = append(missing, LocaleDifference{Kind: "label", Name: label, File: file})
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].File != missing[j].File {
+			return missing[i].File < missing[j].File
+		}
+		if missing[i].Kind != missing[j].Kind {
+			return missing[i].Kind < missing[j].Kind
+		}
+		return missing[i].Name < missing[j].Name
+	})
+	return missing
+}
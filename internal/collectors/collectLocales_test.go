@@ -0,0 +1,41 @@
+package collectors
+
+import (
+	"path/filepath"
+	"testing"
+
+	iowrap "github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocaleTrees(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "zh-cn", "includes"), 0755))
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "includes"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), []byte(".. _home:\n\nSee https://www.mongodb.com/docs and https://example.com/en today.\n"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "install.txt"), []byte(".. _install:\n"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "includes", "note.rst"), []byte(".. _note:\n"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "zh-cn", "index.txt"), []byte(".. _home:\n\nSee https://www.mongodb.com/docs today.\n\n.. _zh-only:\n"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "zh-cn", "includes", "note.rst"), []byte(".. _note:\n"), 0644))
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	locales := []string{"zh-cn"}
+
+	source, err := GatherLocaleTree(files, "", locales)
+	assert.NoError(t, err)
+	translated, err := GatherLocaleTree(files, "zh-cn", locales)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"/": "/source/index.txt", "/install": "/source/install.txt"}, source.Pages)
+	assert.Equal(t, map[string]string{"/": "/source/zh-cn/index.txt"}, translated.Pages)
+
+	assert.Equal(t, []LocaleDifference{
+		{Kind: "link", Name: "https://example.com/en", File: "/source/index.txt"},
+		{Kind: "label", Name: "install", File: "/source/install.txt"},
+		{Kind: "page", Name: "/install", File: "/source/install.txt"},
+	}, source.Missing(translated))
+	assert.Equal(t, []LocaleDifference{
+		{Kind: "label", Name: "zh-only", File: "/source/zh-cn/index.txt"},
+	}, translated.Missing(source))
+}
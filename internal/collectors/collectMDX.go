@@ -0,0 +1,83 @@
+package collectors
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/terakilobyte/checker/internal/parsers/mdx"
+)
+
+type MDXDocRefMap map[mdx.DocRef]string
+
+func isMDX(filename string) bool {
+	ext := filepath.Ext(filename)
+	return ext == ".md" || ext == ".mdx"
+}
+
+func mdxFiles(files []string) []string {
+	filtered := make([]string, 0)
+	for _, file := range files {
+		if isMDX(file) {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered
+}
+
+func GatherMDXDocRefs(files []string) (MDXDocRefMap, error) {
+	refs := make(MDXDocRefMap, len(files))
+	if err := gather(mdxFiles(files), func(filename string, data []byte) {
+		for _, ref := range mdx.ParseForDocRefs(data) {
+			refs[ref] = filename
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// GatherMDXRoutes returns every route served by an MDX file, including
+// front-matter slugs, mapped to the file that serves it.
+func GatherMDXRoutes(files []string) (map[string]string, error) {
+	routes := make(map[string]string, len(files))
+	if err := gather(mdxFiles(files), func(filename string, data []byte) {
+		addMDXRoutes(routes, filename, data)
+	}); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+func addMDXRoutes(routes map[string]string, filename string, data []byte) {
+	route := docRoute(filename)
+	routes[route] = filename
+	if slug := mdx.ParseForFrontMatter(data).Slug; slug != "" {
+		if !strings.HasPrefix(slug, "/") {
+			slug = path.Join(path.Dir(route), slug)
+		}
+		routes[slug] = filename
+	}
+}
+
+// ResolveMDXDocRef reports whether ref, found in filename, points at an existing
+// MDX file or route. Absolute routes may include the site's routeBase (/docs).
+func ResolveMDXDocRef(ref mdx.DocRef, filename string, routes map[string]string, routeBase string) bool {
+	target := ref.Path()
+	if target == "" {
+		return true
+	}
+	if ref.IsRelative() {
+		target = path.Join(path.Dir(filepath.ToSlash(filename)), target)
+		if isMDX(target) {
+			target = docRoute(target)
+		} else {
+			target = docRoute(target + ".mdx")
+		}
+	} else if routeBase != "" && routeBase != "/" {
+		target = strings.TrimPrefix(target, strings.TrimSuffix(routeBase, "/"))
+	}
+	target = "/" + strings.Trim(target, "/")
+	_, ok := routes[target]
+	return ok
+}
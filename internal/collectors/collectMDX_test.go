@@ -0,0 +1,122 @@
+package collectors
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/terakilobyte/checker/internal/parsers/mdx"
+
+	iowrap "github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	introMDX = `---
+title: Introduction
+slug: /
+---
+
+Start with the [quick start](./quick-start.mdx) or <Link to="/docs/fundamentals/crud">CRUD</Link>.
+`
+	quickStartMDX = `---
+slug: getting-started
+---
+
+Read about [CRUD](./fundamentals/crud.md#insert) and the [missing page](./nope.mdx).
+`
+	crudMD = `# CRUD
+
+![How a write flows](./img/write-path.png)
+
+Back to [the start](../getting-started), or download [the cheat sheet](/files/crud.pdf).
+`
+)
+
+func writeMDXProject() {
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "fundamentals"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.mdx"), []byte(introMDX), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "quick-start.mdx"), []byte(quickStartMDX), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "crud.md"), []byte(crudMD), 0644))
+}
+
+func TestGatherMDXDocRefs(t *testing.T) {
+	defer afterTest(t)
+	writeMDXProject()
+
+	expected := MDXDocRefMap{
+		{Target: "./quick-start.mdx"}:             "/source/index.mdx",
+		{Target: "/docs/fundamentals/crud"}:       "/source/index.mdx",
+		{Target: "./fundamentals/crud.md#insert"}: "/source/quick-start.mdx",
+		{Target: "./nope.mdx"}:                    "/source/quick-start.mdx",
+		{Target: "../getting-started"}:            "/source/fundamentals/crud.md",
+	}
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherMDXDocRefs(files)
+	assert.NoError(t, err)
+	assert.EqualValues(t, expected, actual, "GatherMDXDocRefs should return all doc refs in mdx files")
+}
+
+func TestGatherMDXRoutes(t *testing.T) {
+	defer afterTest(t)
+	writeMDXProject()
+
+	expected := map[string]string{
+		"/":                  "/source/index.mdx",
+		"/quick-start":       "/source/quick-start.mdx",
+		"/getting-started":   "/source/quick-start.mdx",
+		"/fundamentals/crud": "/source/fundamentals/crud.md",
+	}
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherMDXRoutes(files)
+	assert.NoError(t, err)
+	assert.EqualValues(t, expected, actual, "GatherMDXRoutes should return file and slug routes")
+}
+
+func TestResolveMDXDocRef(t *testing.T) {
+	defer afterTest(t)
+	writeMDXProject()
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	routes, err := GatherMDXRoutes(files)
+	assert.NoError(t, err)
+
+	cases := []struct {
+		ref      mdx.DocRef
+		filename string
+		ok       bool
+	}{{
+		ref:      mdx.DocRef{Target: "./quick-start.mdx"},
+		filename: "/source/index.mdx",
+		ok:       true,
+	}, {
+		ref:      mdx.DocRef{Target: "/docs/fundamentals/crud"},
+		filename: "/source/index.mdx",
+		ok:       true,
+	}, {
+		ref:      mdx.DocRef{Target: "./fundamentals/crud.md#insert"},
+		filename: "/source/quick-start.mdx",
+		ok:       true,
+	}, {
+		ref:      mdx.DocRef{Target: "./nope.mdx"},
+		filename: "/source/quick-start.mdx",
+		ok:       false,
+	}, {
+		ref:      mdx.DocRef{Target: "../getting-started"},
+		filename: "/source/fundamentals/crud.md",
+		ok:       true,
+	}, {
+		ref:      mdx.DocRef{Target: "/docs/nope"},
+		filename: "/source/index.mdx",
+		ok:       false,
+	}}
+
+	for _, c := range cases {
+		assert.Equal(t, c.ok, ResolveMDXDocRef(c.ref, c.filename, routes, "/docs"), "ResolveMDXDocRef(%v, %s) should be %v", c.ref, c.filename, c.ok)
+	}
+}
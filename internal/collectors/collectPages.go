@@ -0,0 +1,36 @@
+package collectors
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// docRoute mimics snooty and docusaurus, serving /source/foo/bar.txt at /foo/bar
+// and /source/foo/index.txt at /foo.
+func docRoute(filename string) string {
+	route := strings.TrimPrefix(filepath.ToSlash(filename), "/source")
+	route = strings.TrimSuffix(route, path.Ext(route))
+	route = strings.TrimSuffix(route, "/index")
+	if route == "" {
+		return "/"
+	}
+	return route
+}
+
+// GatherPageRoutes returns the route of every page in the project, mapped to
+// the file it's built from. Includes aren't pages.
+func GatherPageRoutes(files []string) map[string]string {
+	pages := make(map[string]string, len(files))
+	for _, file := range files {
+		fileName := filepath.ToSlash(strings.Replace(file, basepath, "", 1))
+		if !strings.HasPrefix(fileName, "/source/") || strings.HasPrefix(fileName, "/source/includes/") {
+			continue
+		}
+		switch path.Ext(fileName) {
+		case ".txt", ".rst", ".md", ".mdx":
+			pages[docRoute(fileName)] = fileName
+		}
+	}
+	return pages
+}
@@ -0,0 +1,32 @@
+package collectors
+
+import (
+	"path/filepath"
+	"testing"
+
+	iowrap "github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatherPageRoutes(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "fundamentals"), 0755))
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "includes"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), indexFile, 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "index.txt"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "gridfs.txt"), grifsFile, 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "includes", "table.rst"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "includes", "steps-install.yaml"), []byte("test"), 0644))
+
+	expected := map[string]string{
+		"/":                    "/source/index.txt",
+		"/fundamentals":        "/source/fundamentals/index.txt",
+		"/fundamentals/gridfs": "/source/fundamentals/gridfs.txt",
+	}
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	assert.EqualValues(t, expected, GatherPageRoutes(files), "GatherPageRoutes should return all pages, skipping includes")
+}
@@ -1,13 +1,19 @@
 package collectors
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
+	"github.com/terakilobyte/checker/internal/parsers/giza"
+	"github.com/terakilobyte/checker/internal/parsers/mdx"
 	"github.com/terakilobyte/checker/internal/parsers/rst"
 	"github.com/terakilobyte/checker/internal/sources"
+	"github.com/terakilobyte/checker/internal/utils"
 
 	iowrap "github.com/spf13/afero"
 
@@ -15,12 +21,26 @@ import (
 )
 
 var (
-	FS                  iowrap.Fs
-	FSUtil              *iowrap.Afero
-	basepath            string
+	FS       iowrap.Fs
+	FSUtil   *iowrap.Afero
+	basepath string
+	// Extensions are the extensions of the files GatherFiles collects.
+	Extensions = DefaultExtensions
+	// ExcludeExtensions are extensions of files, and directories, that
+	// GatherFiles skips even when they end in one of Extensions.
+	ExcludeExtensions []string
+	// Exclude and Include are globs, relative to the project, of the paths
+	// GatherFiles skips and, when there are any, the only files it collects.
+	Exclude []string
+	Include []string
+	// FollowSymlinks makes GatherFiles descend into symlinked directories.
+	FollowSymlinks      bool
 	sharedConstantRegex = regexp.MustCompile(`\{\+([[:alnum:]\p{P}\p{S}]+)\+\}`)
 )
 
+// DefaultExtensions are the source file extensions collected by default.
+var DefaultExtensions = []string{".rst", ".txt", ".yml", ".yaml", ".md", ".mdx"}
+
 func init() {
 	FS = iowrap.NewOsFs()
 	FSUtil = &iowrap.Afero{Fs: FS}
@@ -44,62 +64,409 @@ func sourceDirectoryExists(path string) bool {
 	return exists(filepath.Join(path, "source"))
 }
 
-func GatherFiles(path string) []string {
+// ErrNotProject is returned by GatherFiles for a path that isn't a docs
+// project.
+var ErrNotProject = errors.New("snooty.toml or source directory does not exist")
+
+// GatherFiles returns every file of the project at path that's checked.
+func GatherFiles(path string) ([]string, error) {
 	basepath = path
 	if !snootyTomlExists(path) || !sourceDirectoryExists(path) {
-		log.Panic("snooty.toml or source directory does not exist")
+		return nil, fmt.Errorf("%s: %w", path, ErrNotProject)
 	}
 
 	files := make([]string, 0)
 
-	// TODO: make this passable as a flag with these defaults
-	exts := []string{".rst", ".txt", ".yml", ".yaml"}
-	validExt := func(s string) bool {
-		for _, ext := range exts {
-			if strings.Contains(s, ext) {
+	validExt := func(name string) bool {
+		return hasExtension(name, Extensions) && !hasExtension(name, ExcludeExtensions)
+	}
+	// globs match paths relative to the project
+	matchesAny := func(name string, patterns []string) bool {
+		rel, err := filepath.Rel(basepath, name)
+		if err != nil {
+			return false
+		}
+		for _, pattern := range patterns {
+			if ok, _ := utils.MatchGlob(pattern, filepath.ToSlash(rel)); ok {
 				return true
 			}
 		}
 		return false
 	}
-
-	err := FSUtil.Walk(basepath, func(path string, info os.FileInfo, err error) error {
-		if info.IsDir() && info.Name() == "draft" {
-			return filepath.SkipDir
+	collect := func(name string) bool {
+		if matchesAny(name, Exclude) {
+			return false
 		}
-		if validExt(filepath.Ext(path)) {
-			files = append(files, path)
+		return len(Include) == 0 || matchesAny(name, Include)
+	}
+
+	// A file may be reachable through more than one path once symlinks are
+	// involved, so files are deduplicated by their resolved path and
+	// symlinked directories are only ever walked once.
+	root := realPath(basepath)
+	seen := make(map[string]bool)
+	walked := map[string]bool{root: true}
+	var walk func(dir, logical string) error
+	walk = func(dir, logical string) error {
+		return FSUtil.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() && (info.Name() == "draft" || hasExtension(info.Name(), ExcludeExtensions)) {
+				return filepath.SkipDir
+			}
+			name := path
+			if dir != logical {
+				name = filepath.Join(logical, strings.TrimPrefix(path, dir))
+			}
+			if path != dir && matchesAny(name, Exclude) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info.Mode()&os.ModeSymlink != 0 {
+				target := realPath(path)
+				stat, err := FS.Stat(target)
+				if err != nil {
+					log.Warnf("skipping broken symlink %s: %v", name, err)
+					return nil
+				}
+				if stat.IsDir() {
+					if !FollowSymlinks {
+						log.Debugf("skipping symlinked directory %s", name)
+						return nil
+					}
+					if walked[target] {
+						log.Debugf("skipping symlinked directory %s, %s was already walked", name, target)
+						return nil
+					}
+					walked[target] = true
+					return walk(target, canonicalPath(root, target, name))
+				}
+				name = canonicalPath(root, target, name)
+				if validExt(info.Name()) && collect(name) && !seen[target] {
+					seen[target] = true
+					files = append(files, name)
+				}
+				return nil
+			}
+			if info.IsDir() {
+				walked[realPath(path)] = true
+				return nil
+			}
+			if validExt(info.Name()) && collect(name) {
+				key := realPath(path)
+				if !seen[key] {
+					seen[key] = true
+					files = append(files, name)
+				}
+			}
+			return nil
+		})
+	}
+	if err := walk(basepath, basepath); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// hasExtension reports whether name ends in one of exts. Extensions may have
+// more than one dot, like .generated.rst.
+func hasExtension(name string, exts []string) bool {
+	for _, ext := range exts {
+		if strings.HasSuffix(name, ext) {
+			return true
 		}
-		return nil
-	})
-	if err != nil {
-		log.Panic(err)
 	}
-	return files
+	return false
+}
+
+// realPath resolves any symlinks in path, if the file system supports them.
+func realPath(path string) string {
+	if _, ok := FS.(iowrap.Symlinker); !ok {
+		return path
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	return path
 }
 
-func gather(files []string, fn func(filename string, data []byte)) {
+// canonicalPath reports a symlink target that lives inside the project by
+// its own path, and one outside of it by the path of the link.
+func canonicalPath(root, target, link string) string {
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return link
+	}
+	return filepath.Join(basepath, rel)
+}
+
+// gather calls fn with the contents of each of files, by its path in the
+// project, stopping at the first that can't be read.
+func gather(files []string, fn func(filename string, data []byte)) error {
 	for _, file := range files {
 		dat, err := FSUtil.ReadFile(file)
 		if err != nil {
-			log.Panic(err)
+			return fmt.Errorf("could not read %s: %w", file, err)
 		}
 
 		fileName := strings.Replace(file, basepath, "", 1)
+		if ext := filepath.Ext(file); ext == ".yaml" || ext == ".yml" {
+			// giza steps, extracts, and release files keep their rst in yaml strings
+			content, err := giza.Content(dat)
+			if err != nil {
+				log.Warnf("could not parse %s as yaml, scanning it as plain text: %v", fileName, err)
+			} else {
+				dat = content
+			}
+		}
 		fn(fileName, dat)
 	}
+	return nil
+}
+
+// Project is everything checkable in a set of files, by the file it's in.
+type Project struct {
+	Constants    map[rst.RstConstant]string
+	Roles        RstRoleMap
+	HTTPLinks    map[rst.RstHTTPLink]string
+	ContactLinks map[rst.RstContactLink]string
+	LocalRefs    RefTargetMap
+	Directives   RstDirectiveMap
+	// SharedIncludes are the files that include each shared include
+	SharedIncludes map[rst.SharedInclude][]string
+	Targets        *TargetRegistry
+	MDXDocRefs     MDXDocRefMap
+	MDXRoutes      map[string]string
+	// Labels, SyntaxWarnings, LinkTargets, and PageFields are by filename, and
+	// only collected when Collect asks for them
+	Labels         map[string][]rst.Entity
+	SyntaxWarnings map[string][]rst.SyntaxWarning
+	LinkTargets    map[string][]string
+	PageFields     map[string][]rst.PageField
+}
+
+// Collect is what GatherProject collects beyond what every check needs.
+type Collect struct {
+	// Callables are the kinds of directive Targets registers by name, like
+	// GatherTargets does
+	Callables      map[string]bool
+	Labels         bool
+	SyntaxWarnings bool
+	LinkTargets    bool
+	PageFields     bool
+	// EachDocument is called with the contents and parsed document of every
+	// rst file Parse is true for, like EachDocument does
+	EachDocument func(filename string, data []byte, doc *rst.Document)
+	Parse        func(filename string) bool
+}
+
+func newProject(files []string, callables map[string]bool) *Project {
+	return &Project{
+		Constants:      make(map[rst.RstConstant]string, len(files)),
+		Roles:          make(RstRoleMap, len(files)),
+		HTTPLinks:      make(map[rst.RstHTTPLink]string, len(files)),
+		ContactLinks:   make(map[rst.RstContactLink]string),
+		LocalRefs:      make(RefTargetMap, len(files)),
+		Directives:     make(RstDirectiveMap, len(files)),
+		SharedIncludes: make(map[rst.SharedInclude][]string),
+		Targets:        newTargetRegistry(callables),
+		MDXDocRefs:     make(MDXDocRefMap),
+		MDXRoutes:      make(map[string]string),
+		Labels:         make(map[string][]rst.Entity),
+		SyntaxWarnings: make(map[string][]rst.SyntaxWarning),
+		LinkTargets:    make(map[string][]string),
+		PageFields:     make(map[string][]rst.PageField),
+	}
+}
+
+// index collects what files are resolved against, wherever they're checked.
+func (p *Project) index(filename string, data []byte, e rst.Entities) {
+	for _, ref := range e.LocalRefs {
+		p.LocalRefs[ref] = filename
+	}
+	for _, share := range e.SharedIncludes {
+		p.SharedIncludes[share] = append(p.SharedIncludes[share], filename)
+	}
+	p.Targets.register(filename, e.Directives)
+	if isMDX(filename) {
+		addMDXRoutes(p.MDXRoutes, filename, data)
+	}
+}
+
+// GatherProject reads and lexes each file once, collecting everything the
+// Gather functions would each make a pass over every file for.
+func GatherProject(files []string, collect Collect) (*Project, error) {
+	p := newProject(files, collect.Callables)
+	if err := gather(files, func(filename string, data []byte) {
+		e := rst.Lex(data)
+		p.index(filename, data, e)
+		for _, con := range e.Constants {
+			p.Constants[con] = filename
+		}
+		for _, role := range e.Roles {
+			p.Roles[role] = filename
+		}
+		for _, link := range e.HTTPLinks {
+			p.HTTPLinks[link] = filename
+		}
+		for _, link := range e.ContactLinks {
+			p.ContactLinks[link] = filename
+		}
+		for _, directive := range e.Directives {
+			p.Directives[directive] = filename
+		}
+		directiveDataLinks(filename, e.Directives, func(link rst.RstHTTPLink) {
+			p.HTTPLinks[link] = filename
+		})
+		if isMDX(filename) {
+			for _, ref := range mdx.ParseForDocRefs(data) {
+				p.MDXDocRefs[ref] = filename
+			}
+			return
+		}
+		if collect.Labels {
+			if found := parseLabels(data); len(found) > 0 {
+				p.Labels[filename] = found
+			}
+		}
+		if collect.SyntaxWarnings {
+			if found := rst.ParseForSyntaxWarnings(data); len(found) > 0 {
+				p.SyntaxWarnings[filename] = found
+			}
+		}
+		if collect.LinkTargets {
+			if found := parseLinkTargets(data); len(found) > 0 {
+				p.LinkTargets[filename] = found
+			}
+		}
+		if ext := filepath.Ext(filename); collect.PageFields && ext != ".yaml" && ext != ".yml" {
+			if found := rst.ParseForPageFields(data); len(found) > 0 {
+				p.PageFields[filename] = found
+			}
+		}
+		if collect.EachDocument != nil && (collect.Parse == nil || collect.Parse(filename)) {
+			collect.EachDocument(filename, data, rst.ParseDocument(data))
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GatherIndex collects only what files are resolved against, their local
+// refs, shared includes, targets, and MDX routes, in one pass. With
+// --memory-limit the rest is gathered a batch of files at a time.
+func GatherIndex(files []string, callables map[string]bool) (*Project, error) {
+	p := newProject(files, callables)
+	if err := gather(files, func(filename string, data []byte) {
+		p.index(filename, data, rst.Lex(data))
+	}); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GatherDocuments parses every file into a document, by filename, for
+// anything that needs to know where in a file what it found is.
+func GatherDocuments(files []string) (map[string]*rst.Document, error) {
+	documents := make(map[string]*rst.Document, len(files))
+	if err := gather(files, func(filename string, data []byte) {
+		documents[filename] = rst.ParseDocument(data)
+	}); err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+// GatherSyntaxWarnings finds likely broken markup in every rst file, by
+// filename.
+func GatherSyntaxWarnings(files []string) (map[string][]rst.SyntaxWarning, error) {
+	warnings := make(map[string][]rst.SyntaxWarning)
+	if err := gather(files, func(filename string, data []byte) {
+		if isMDX(filename) {
+			return
+		}
+		if found := rst.ParseForSyntaxWarnings(data); len(found) > 0 {
+			warnings[filename] = found
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
+
+// GatherPageFields finds the page options and .. meta:: options of every rst
+// page, by filename. Giza yaml files aren't pages, so they're skipped.
+func GatherPageFields(files []string) (map[string][]rst.PageField, error) {
+	fields := make(map[string][]rst.PageField)
+	if err := gather(files, func(filename string, data []byte) {
+		if ext := filepath.Ext(filename); isMDX(filename) || ext == ".yaml" || ext == ".yml" {
+			return
+		}
+		if found := rst.ParseForPageFields(data); len(found) > 0 {
+			fields[filename] = found
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// GatherLabels finds every label, like .. _install:, defined in rst files,
+// with where it's defined, by filename.
+func GatherLabels(files []string) (map[string][]rst.Entity, error) {
+	labels := make(map[string][]rst.Entity)
+	if err := gather(files, func(filename string, data []byte) {
+		if isMDX(filename) {
+			return
+		}
+		if found := parseLabels(data); len(found) > 0 {
+			labels[filename] = found
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+func parseLabels(data []byte) []rst.Entity {
+	var found []rst.Entity
+	// reading from memory can't fail
+	_ = rst.Parse(bytes.NewReader(data), func(e rst.Entity) {
+		if e.Kind == rst.KindLocalRef {
+			found = append(found, e)
+		}
+	})
+	return found
+}
+
+// EachDocument calls fn with the contents and parsed document of every rst
+// file, one at a time, so they don't all have to be held at once. It stops at
+// the first file that can't be read.
+func EachDocument(files []string, fn func(filename string, data []byte, doc *rst.Document)) error {
+	return gather(files, func(filename string, data []byte) {
+		if isMDX(filename) {
+			return
+		}
+		fn(filename, data, rst.ParseDocument(data))
+	})
 }
 
 type RstRoleMap map[rst.RstRole]string
 
-func GatherRoles(files []string) RstRoleMap {
+func GatherRoles(files []string) (RstRoleMap, error) {
 	roles := make(map[rst.RstRole]string, len(files))
-	gather(files, func(filename string, data []byte) {
+	if err := gather(files, func(filename string, data []byte) {
 		for _, role := range rst.ParseForRoles(data) {
 			roles[role] = filename
 		}
-	})
-	return roles
+	}); err != nil {
+		return nil, err
+	}
+	return roles, nil
 }
 
 func (r *RstRoleMap) Get(key string) (*rst.RstRole, bool) {
@@ -118,36 +485,106 @@ func (r *RstRoleMap) Union(other RstRoleMap) *RstRoleMap {
 	return r
 }
 
-func GatherConstants(files []string) map[rst.RstConstant]string {
+func GatherConstants(files []string) (map[rst.RstConstant]string, error) {
 	consts := make(map[rst.RstConstant]string, len(files))
-	gather(files, func(filename string, data []byte) {
+	if err := gather(files, func(filename string, data []byte) {
 		for _, con := range rst.ParseForConstants(data) {
 			consts[con] = filename
 		}
-	})
-	return consts
+	}); err != nil {
+		return nil, err
+	}
+	return consts, nil
 }
 
-func GatherHTTPLinks(files []string) map[rst.RstHTTPLink]string {
+func GatherHTTPLinks(files []string) (map[rst.RstHTTPLink]string, error) {
 	links := make(map[rst.RstHTTPLink]string, len(files))
-	gather(files, func(filename string, data []byte) {
+	if err := gather(files, func(filename string, data []byte) {
 		for _, link := range rst.ParseForHTTPLinks(data) {
 			links[link] = filename
 		}
-	})
-	return links
+		directiveDataLinks(filename, rst.ParseForDirectives(data), func(link rst.RstHTTPLink) {
+			links[link] = filename
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// GatherContactLinks finds every mailto: and tel: link, by the file it's in.
+func GatherContactLinks(files []string) (map[rst.RstContactLink]string, error) {
+	links := make(map[rst.RstContactLink]string)
+	if err := gather(files, func(filename string, data []byte) {
+		for _, link := range rst.ParseForContactLinks(data) {
+			links[link] = filename
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// GatherLinkTargets finds the target of every hyperlink, hyperlink
+// definition, and mailto: or tel: link in rst files, whatever its scheme,
+// once per file, by filename.
+func GatherLinkTargets(files []string) (map[string][]string, error) {
+	targets := make(map[string][]string)
+	if err := gather(files, func(filename string, data []byte) {
+		if isMDX(filename) {
+			return
+		}
+		if found := parseLinkTargets(data); len(found) > 0 {
+			targets[filename] = found
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+func parseLinkTargets(data []byte) []string {
+	var targets []string
+	seen := make(map[string]bool)
+	found := rst.ParseForLinkTargets(data)
+	for _, link := range rst.ParseForContactLinks(data) {
+		found = append(found, string(link))
+	}
+	for _, target := range found {
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+type RstDirectiveMap map[rst.RstDirective]string
+
+func GatherDirectives(files []string) (RstDirectiveMap, error) {
+	directives := make(RstDirectiveMap, len(files))
+	if err := gather(files, func(filename string, data []byte) {
+		for _, directive := range rst.ParseForDirectives(data) {
+			directives[directive] = filename
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return directives, nil
 }
 
 type RefTargetMap map[rst.RefTarget]string
 
-func GatherLocalRefs(files []string) RefTargetMap {
+func GatherLocalRefs(files []string) (RefTargetMap, error) {
 	refs := make(map[rst.RefTarget]string, len(files))
-	gather(files, func(filename string, data []byte) {
+	if err := gather(files, func(filename string, data []byte) {
 		for _, ref := range rst.ParseForLocalRefs(data) {
 			refs[ref] = filename
 		}
-	})
-	return refs
+	}); err != nil {
+		return nil, err
+	}
+	return refs, nil
 }
 
 func (r *RefTargetMap) Get(ref *rst.RstRole) (*rst.RefTarget, bool) {
@@ -166,23 +603,41 @@ func (r *RefTargetMap) Union(other RefTargetMap) *RefTargetMap {
 	return r
 }
 
-// Snooty does magic and converts tsl to ssl in refs, so we have to mimic it
-func (r RefTargetMap) SSLToTLS() RefTargetMap {
+// Alias adds an alias for every target whose name starts with one of
+// aliases' keys, replacing that prefix with the value, so a target renamed
+// during a migration resolves by its new name too. Like snooty, the first ssl
+// anywhere in a name is replaced with tls as well.
+func (r RefTargetMap) Alias(aliases map[string]string) RefTargetMap {
+	aliased := make(RefTargetMap)
 	for k, v := range r {
 		if strings.Contains(k.Name, "ssl") {
-			tlsK := rst.RefTarget{Name: strings.Replace(k.Name, "ssl", "tls", 1)}
-			r[tlsK] = v
+			aliased[rst.RefTarget{Name: strings.Replace(k.Name, "ssl", "tls", 1)}] = v
+		}
+		for old, renamed := range aliases {
+			if strings.HasPrefix(k.Name, old) {
+				aliased[rst.RefTarget{Name: renamed + strings.TrimPrefix(k.Name, old)}] = v
+			}
+		}
+	}
+	for k, v := range aliased {
+		if _, ok := r[k]; !ok {
+			r[k] = v
 		}
 	}
 	return r
 }
 
-func GatherSharedIncludes(files []string) []rst.SharedInclude {
-	includes := make([]rst.SharedInclude, 0)
-	gather(files, func(filename string, data []byte) {
-		includes = append(includes, rst.ParseForSharedIncludes(data)...)
-	})
-	return includes
+// GatherSharedIncludes returns the files that include each shared include.
+func GatherSharedIncludes(files []string) (map[rst.SharedInclude][]string, error) {
+	includes := make(map[rst.SharedInclude][]string)
+	if err := gather(files, func(filename string, data []byte) {
+		for _, share := range rst.ParseForSharedIncludes(data) {
+			includes[share] = append(includes[share], filename)
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return includes, nil
 }
 
 func GatherSharedRefs(input []byte, defs sources.TomlConfig) RstRoleMap {
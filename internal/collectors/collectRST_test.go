@@ -89,10 +89,30 @@ func TestFindsSourceDirectory(t *testing.T) {
 
 }
 
-func TestGatherXPanicsIfNoSourceOrSnootyToml(t *testing.T) {
+func TestGatherFilesFailsIfNoSourceOrSnootyToml(t *testing.T) {
 	defer afterTest(t)
 	log.SetOutput(io.Discard)
-	assert.Panics(t, func() { GatherFiles(basepath) }, "gatherRole should panic if no source or Snooty.toml")
+	_, err := GatherFiles(basepath)
+	assert.ErrorIs(t, err, ErrNotProject, "GatherFiles should fail if no source or Snooty.toml")
+}
+
+func TestGatherFailsIfUnreadable(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	_, err := GatherRoles([]string{filepath.Join(basepath, "source", "gone.txt")})
+	assert.Contains(t, err.Error(), "could not read")
+}
+
+// gathered fails t if a Gather function did, returning what it gathered, so
+// it can be compared in one assertion.
+func gathered(t *testing.T) func(interface{}, error) interface{} {
+	return func(v interface{}, err error) interface{} {
+		t.Helper()
+		assert.NoError(t, err)
+		return v
+	}
 }
 
 func TestGatherFiles(t *testing.T) {
@@ -106,12 +126,93 @@ func TestGatherFiles(t *testing.T) {
 	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "baz.txt"), []byte("test"), 0644))
 	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "biz.txt"), []byte("test"), 0644))
 	expected := []string{filepath.Join(basepath, "source", "foo.txt"), filepath.Join(basepath, "source", "bar.txt"), filepath.Join(basepath, "source", "fundamentals", "baz.txt"), filepath.Join(basepath, "source", "fundamentals", "biz.txt")}
-	actual := GatherFiles(basepath)
+	actual, err := GatherFiles(basepath)
+	assert.NoError(t, err)
 
 	assert.ElementsMatch(t, expected, actual, "gatherFiles should return all files in source directory")
 
 }
 
+func TestGatherFilesExtensions(t *testing.T) {
+	defer afterTest(t)
+	t.Cleanup(func() {
+		Extensions, ExcludeExtensions = DefaultExtensions, nil
+	})
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "api.generated"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.rest"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "crud.rst"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "options.generated.rst"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "notes.md"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "api.generated", "reference.rst"), []byte("test"), 0644))
+
+	Extensions = []string{".rst", ".rest"}
+	ExcludeExtensions = []string{".generated.rst", ".generated"}
+	expected := []string{filepath.Join(basepath, "source", "index.rest"), filepath.Join(basepath, "source", "crud.rst")}
+	assert.ElementsMatch(t, expected, gathered(t)(GatherFiles(basepath)), "only files with an included extension and no excluded one are collected")
+}
+
+func TestGatherFilesGlobs(t *testing.T) {
+	defer afterTest(t)
+	t.Cleanup(func() {
+		Exclude, Include = nil, nil
+	})
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "archive", "v1"), 0755))
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "atlas"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "archive", "v1", "index.txt"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "atlas", "index.txt"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "atlas", "steps.yaml"), []byte("test"), 0644))
+
+	Exclude = []string{"source/archive/**"}
+	expected := []string{
+		filepath.Join(basepath, "source", "index.txt"),
+		filepath.Join(basepath, "source", "atlas", "index.txt"),
+		filepath.Join(basepath, "source", "atlas", "steps.yaml"),
+	}
+	assert.ElementsMatch(t, expected, gathered(t)(GatherFiles(basepath)), "excluded paths should be skipped")
+
+	Include = []string{"source/atlas/**"}
+	Exclude = []string{"**/*.yaml"}
+	expected = []string{filepath.Join(basepath, "source", "atlas", "index.txt")}
+	assert.ElementsMatch(t, expected, gathered(t)(GatherFiles(basepath)), "only included paths that aren't excluded should be collected")
+}
+
+func TestGatherFilesSymlinks(t *testing.T) {
+	fs, fsutil, base := FS, FSUtil, basepath
+	FS = iowrap.NewOsFs()
+	FSUtil = &iowrap.Afero{Fs: FS}
+	t.Cleanup(func() {
+		FS, FSUtil, basepath, FollowSymlinks = fs, fsutil, base, false
+	})
+
+	dir := t.TempDir()
+	outside := t.TempDir()
+	check(os.MkdirAll(filepath.Join(dir, "source", "fundamentals"), 0755))
+	check(os.WriteFile(filepath.Join(dir, "snooty.toml"), []byte("test"), 0644))
+	check(os.WriteFile(filepath.Join(dir, "source", "index.txt"), []byte("test"), 0644))
+	check(os.WriteFile(filepath.Join(dir, "source", "fundamentals", "crud.txt"), []byte("test"), 0644))
+	check(os.WriteFile(filepath.Join(outside, "shared.txt"), []byte("test"), 0644))
+	// the same directory twice, a cycle, an external directory and a duplicate file
+	check(os.Symlink(filepath.Join(dir, "source", "fundamentals"), filepath.Join(dir, "source", "also")))
+	check(os.Symlink(filepath.Join(dir, "source"), filepath.Join(dir, "source", "fundamentals", "loop")))
+	check(os.Symlink(outside, filepath.Join(dir, "source", "external")))
+	check(os.Symlink(filepath.Join(dir, "source", "index.txt"), filepath.Join(dir, "source", "copy.txt")))
+
+	expected := []string{
+		filepath.Join(dir, "source", "index.txt"),
+		filepath.Join(dir, "source", "fundamentals", "crud.txt"),
+	}
+	assert.ElementsMatch(t, expected, gathered(t)(GatherFiles(dir)), "symlinked directories are not followed by default")
+
+	FollowSymlinks = true
+	expected = append(expected, filepath.Join(dir, "source", "external", "shared.txt"))
+	assert.ElementsMatch(t, expected, gathered(t)(GatherFiles(dir)), "each file should be reported once by its own path")
+}
+
 func TestGatherRoles(t *testing.T) {
 	defer afterTest(t)
 
@@ -152,12 +253,123 @@ func TestGatherRoles(t *testing.T) {
 		{Target: "gridfs-upload-files", RoleType: "ref", Name: "ref"}:                                         "/source/fundamentals/gridfs.txt",
 	}
 
-	actual := GatherRoles(GatherFiles(basepath))
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherRoles(files)
+	assert.NoError(t, err)
 
 	assert.EqualValues(t, expected, actual, "gatherRoles should return all roles in source directory")
 
 }
 
+func TestGatherProject(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "fundamentals"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), []byte(indexFile), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "aggregation.txt"), []byte(aggregationsFile), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "gridfs.txt"), []byte(grifsFile), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "compatibility.txt"), []byte(compatibilityFile), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "page.mdx"), []byte("---\nslug: guide\n---\n[Install](/install)\n"), 0644))
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	callables := map[string]bool{"method": true}
+
+	var parsed []string
+	project, err := GatherProject(files, Collect{
+		Callables:      callables,
+		Labels:         true,
+		SyntaxWarnings: true,
+		LinkTargets:    true,
+		PageFields:     true,
+		EachDocument: func(filename string, data []byte, doc *rst.Document) {
+			parsed = append(parsed, filename)
+		},
+		Parse: func(filename string) bool { return filename == "/source/index.txt" },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, gathered(t)(GatherConstants(files)), project.Constants)
+	assert.Equal(t, gathered(t)(GatherRoles(files)), project.Roles)
+	assert.Equal(t, gathered(t)(GatherHTTPLinks(files)), project.HTTPLinks)
+	assert.Equal(t, gathered(t)(GatherContactLinks(files)), project.ContactLinks)
+	assert.Equal(t, gathered(t)(GatherLocalRefs(files)), project.LocalRefs)
+	assert.Equal(t, gathered(t)(GatherDirectives(files)), project.Directives)
+	assert.Equal(t, gathered(t)(GatherSharedIncludes(files)), project.SharedIncludes)
+	assert.Equal(t, gathered(t)(GatherTargets(files, callables)), project.Targets)
+	assert.Equal(t, gathered(t)(GatherMDXDocRefs(files)), project.MDXDocRefs)
+	assert.Equal(t, gathered(t)(GatherMDXRoutes(files)), project.MDXRoutes)
+	assert.Equal(t, gathered(t)(GatherLabels(files)), project.Labels)
+	assert.Equal(t, gathered(t)(GatherSyntaxWarnings(files)), project.SyntaxWarnings)
+	assert.Equal(t, gathered(t)(GatherLinkTargets(files)), project.LinkTargets)
+	assert.Equal(t, gathered(t)(GatherPageFields(files)), project.PageFields)
+	assert.Equal(t, []string{"/source/index.txt"}, parsed)
+
+	index, err := GatherIndex(files, callables)
+	assert.NoError(t, err)
+	assert.Equal(t, project.LocalRefs, index.LocalRefs)
+	assert.Equal(t, project.SharedIncludes, index.SharedIncludes)
+	assert.Equal(t, project.Targets, index.Targets)
+	assert.Equal(t, project.MDXRoutes, index.MDXRoutes)
+	assert.Empty(t, index.Roles)
+}
+
+func TestGatherDocuments(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), []byte("Title\n=====\n\nSee :ref:`install`.\n"), 0644))
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	documents, err := GatherDocuments(files)
+	assert.NoError(t, err)
+	assert.Len(t, documents, 1)
+	entities := documents["/source/index.txt"].Entities
+	assert.Len(t, entities, 1)
+	assert.Equal(t, rst.RstRole{Target: "install", RoleType: "ref", Name: "ref"}, entities[0].Value)
+	assert.Equal(t, 4, entities[0].Span.Line)
+}
+
+func TestEachDocument(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), []byte("Title\n=====\n\nSee :ref:`install`.\n"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "page.mdx"), []byte("# Page\n"), 0644))
+
+	var seen []string
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	err = EachDocument(files, func(filename string, data []byte, doc *rst.Document) {
+		seen = append(seen, filename)
+		assert.Contains(t, string(data), "See :ref:`install`.")
+		assert.Len(t, doc.Entities, 1)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/source/index.txt"}, seen)
+}
+
+func TestGatherLabels(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), []byte("Title\n=====\n\n.. _install:\n\nSee :ref:`install`.\n"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "notes.md"), []byte(".. _not-rst:\n"), 0644))
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	labels, err := GatherLabels(files)
+	assert.NoError(t, err)
+	assert.Len(t, labels, 1)
+	assert.Len(t, labels["/source/index.txt"], 1)
+	assert.Equal(t, rst.RefTarget{Name: "install"}, labels["/source/index.txt"][0].Value)
+	assert.Equal(t, 4, labels["/source/index.txt"][0].Span.Line)
+}
+
 func TestRstRoleMapGet(t *testing.T) {
 	defer afterTest(t)
 
@@ -168,7 +380,10 @@ func TestRstRoleMapGet(t *testing.T) {
 	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "aggregation.txt"), []byte(aggregationsFile), 0644))
 	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "gridfs.txt"), []byte(grifsFile), 0644))
 
-	roleMap := GatherRoles(GatherFiles(basepath))
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	roleMap, err := GatherRoles(files)
+	assert.NoError(t, err)
 
 	cases := []struct {
 		key   string
@@ -289,17 +504,27 @@ func TestRefTargetMapUnion(t *testing.T) {
 
 }
 
-func TestRefTargetMapSSLToTLS(t *testing.T) {
+func TestRefTargetMapAlias(t *testing.T) {
 	lr1 := RefTargetMap{
-		{Name: "nodejs-ssl"}: "/source/fundamentals/ssl.txt",
+		{Name: "nodejs-ssl"}:          "/source/fundamentals/ssl.txt",
+		{Name: "install-old"}:         "/source/install.txt",
+		{Name: "install-old-linux"}:   "/source/install/linux.txt",
+		{Name: "upgrade-install-old"}: "/source/upgrade.txt",
+		{Name: "nodejs-tls-setup"}:    "/source/fundamentals/tls.txt",
 	}
 
 	expected := RefTargetMap{
-		{Name: "nodejs-ssl"}: "/source/fundamentals/ssl.txt",
-		{Name: "nodejs-tls"}: "/source/fundamentals/ssl.txt",
+		{Name: "nodejs-ssl"}:          "/source/fundamentals/ssl.txt",
+		{Name: "nodejs-tls"}:          "/source/fundamentals/ssl.txt",
+		{Name: "install-old"}:         "/source/install.txt",
+		{Name: "install-new"}:         "/source/install.txt",
+		{Name: "install-old-linux"}:   "/source/install/linux.txt",
+		{Name: "install-new-linux"}:   "/source/install/linux.txt",
+		{Name: "upgrade-install-old"}: "/source/upgrade.txt",
+		{Name: "nodejs-tls-setup"}:    "/source/fundamentals/tls.txt",
 	}
 
-	assert.EqualValues(t, expected, lr1.SSLToTLS(), "union should return union of two maps")
+	assert.EqualValues(t, expected, lr1.Alias(map[string]string{"install-old": "install-new"}), "alias should add renamed targets, only renaming prefixes")
 }
 
 func TestGatherConstants(t *testing.T) {
@@ -316,7 +541,10 @@ func TestGatherConstants(t *testing.T) {
 		{Name: "api", Target: "/interfaces/AggregateOptions.html"}:  "/source/fundamentals/aggregation.txt",
 	}
 
-	actual := GatherConstants(GatherFiles(basepath))
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherConstants(files)
+	assert.NoError(t, err)
 
 	assert.EqualValues(t, expected, actual, "gatherConstants should return all constants in source directory")
 
@@ -339,12 +567,48 @@ func TestGatherHTTPLinks(t *testing.T) {
 		"https://www.mongodb.com/blog/post/quick-start-nodejs--mongodb--how-to-analyze-data-using-the-aggregation-framework": "/source/fundamentals/aggregation.txt",
 	}
 
-	actual := GatherHTTPLinks(GatherFiles(basepath))
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherHTTPLinks(files)
+	assert.NoError(t, err)
 
 	assert.EqualValues(t, expected, actual, "gatherConstants should return all constants in source directory")
 
 }
 
+func TestGatherContactLinks(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), []byte("Email `us <mailto:docs@example.com>`__ or call tel:+1-212-555-0100.\n"), 0644))
+
+	expected := map[rst.RstContactLink]string{
+		"mailto:docs@example.com": "/source/index.txt",
+		"tel:+1-212-555-0100":     "/source/index.txt",
+	}
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherContactLinks(files)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestGatherLinkTargets(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), []byte("Get `the FAQ <ftp://ftp.example.com/faq.txt>`__ or call `us <tel:+1-212-555-0100>`__.\n"), 0644))
+
+	expected := map[string][]string{"/source/index.txt": {"ftp://ftp.example.com/faq.txt", "tel:+1-212-555-0100"}}
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherLinkTargets(files)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
 func TestGatherLocalRefs(t *testing.T) {
 	defer afterTest(t)
 
@@ -365,12 +629,39 @@ func TestGatherLocalRefs(t *testing.T) {
 		{Name: "nodejs-aggregation-overview"}: "/source/fundamentals/aggregation.txt",
 	}
 
-	actual := GatherLocalRefs(GatherFiles(basepath))
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherLocalRefs(files)
+	assert.NoError(t, err)
 
 	assert.EqualValues(t, expected, actual, "GatherLocalRefs should return all local refs in source directory")
 
 }
 
+func TestGatherDirectives(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "reference"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "compatibility.txt"), compatibilityFile, 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "reference", "api.txt"), []byte(".. openapi:: /openapi/atlas.yaml\n"), 0644))
+
+	expected := RstDirectiveMap{
+		{Name: "contents", Target: "On this page"}:                                   "/source/compatibility.txt",
+		{Name: "include", Target: "/includes/mongodb-compatibility-table-node.rst"}:  "/source/compatibility.txt",
+		{Name: "include", Target: "/includes/language-compatibility-table-node.rst"}: "/source/compatibility.txt",
+		{Name: "openapi", Target: "/openapi/atlas.yaml"}:                             "/source/reference/api.txt",
+		{Name: "sharedinclude", Target: "dbx/about-compatibility.rst"}:               "/source/compatibility.txt",
+	}
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherDirectives(files)
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, expected, actual, "GatherDirectives should return all directives in source directory")
+}
+
 func TestGatherSharedIncludes(t *testing.T) {
 	defer afterTest(t)
 
@@ -381,9 +672,16 @@ func TestGatherSharedIncludes(t *testing.T) {
 	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "gridfs.txt"), grifsFile, 0644))
 	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "compatibility.txt"), compatibilityFile, 0644))
 
-	expected := []rst.SharedInclude{{Path: "dbx/about-compatibility.rst"}, {Path: "shared-content-ref-test/ref-test.rst"}}
+	expected := map[rst.SharedInclude][]string{
+		{Path: "dbx/about-compatibility.rst"}:          {"/source/compatibility.txt"},
+		{Path: "shared-content-ref-test/ref-test.rst"}: {"/source/fundamentals/gridfs.txt"},
+	}
 
-	assert.ElementsMatch(t, expected, GatherSharedIncludes(GatherFiles(basepath)), "GatherSharedIncludes should return all shared includes in source directory")
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherSharedIncludes(files)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual, "GatherSharedIncludes should return the files that include each shared include in source directory")
 
 }
 
@@ -420,3 +718,35 @@ func TestGatherSharedLocalRefs(t *testing.T) {
 	assert.EqualValues(t, expected, actual, "GatherSharedLocalRefs should return all shared refs in source directory")
 
 }
+
+func TestGatherFromGizaYaml(t *testing.T) {
+	defer afterTest(t)
+
+	steps := []byte(`title: Import the public key.
+ref: import-key
+action:
+  pre: |
+    See :ref:` + "`install-mdb-community-ubuntu`" + ` and
+    :manual:` + "`the release notes </release-notes>`" + `.
+  code: wget -qO - "https://www.mongodb.org/static/pgp/server-5.0.asc"
+...
+`)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "includes"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "includes", "steps-install.yaml"), steps, 0644))
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+
+	expectedRoles := RstRoleMap{
+		{Target: "install-mdb-community-ubuntu", RoleType: "ref", Name: "ref"}: "/source/includes/steps-install.yaml",
+		{Target: "/release-notes", RoleType: "role", Name: "manual"}:           "/source/includes/steps-install.yaml",
+	}
+	expectedLinks := map[rst.RstHTTPLink]string{
+		"https://www.mongodb.org/static/pgp/server-5.0.asc": "/source/includes/steps-install.yaml",
+	}
+
+	assert.EqualValues(t, expectedRoles, gathered(t)(GatherRoles(files)), "GatherRoles should find roles inside giza yaml strings")
+	assert.EqualValues(t, expectedLinks, gathered(t)(GatherHTTPLinks(files)), "GatherHTTPLinks should find links inside giza yaml strings")
+}
@@ -0,0 +1,99 @@
+package collectors
+
+import (
+	"net/url"
+	"sort"
+
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+)
+
+// FileStats counts what checker found in a single file.
+type FileStats struct {
+	Refs      int
+	Roles     int
+	Links     int
+	Includes  int
+	Constants int
+}
+
+// Add returns the sum of s and other.
+func (s FileStats) Add(other FileStats) FileStats {
+	return FileStats{
+		Refs:      s.Refs + other.Refs,
+		Roles:     s.Roles + other.Roles,
+		Links:     s.Links + other.Links,
+		Includes:  s.Includes + other.Includes,
+		Constants: s.Constants + other.Constants,
+	}
+}
+
+func isInclude(directive rst.RstDirective) bool {
+	switch directive.Name {
+	case "include", "sharedinclude", "literalinclude":
+		return true
+	}
+	return false
+}
+
+// GatherStats counts every ref, role, link, include, and constant use per file.
+// Unlike the other Gather functions, repeated uses are each counted.
+func GatherStats(files []string) (map[string]FileStats, error) {
+	stats := make(map[string]FileStats, len(files))
+	if err := gather(files, func(filename string, data []byte) {
+		var s FileStats
+		for _, role := range rst.ParseForRoles(data) {
+			if role.RoleType == "ref" {
+				s.Refs++
+			} else {
+				s.Roles++
+			}
+		}
+		s.Links = len(rst.ParseForHTTPLinks(data))
+		for _, directive := range rst.ParseForDirectives(data) {
+			if isInclude(directive) {
+				s.Includes++
+			}
+		}
+		s.Constants = len(rst.ParseForConstants(data))
+		stats[filename] = s
+	}); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// DomainCount is how many times links to Domain are used.
+type DomainCount struct {
+	Domain string
+	Count  int
+}
+
+// GatherTopDomains returns the n most linked external domains, most linked first.
+func GatherTopDomains(files []string, n int) ([]DomainCount, error) {
+	counts := make(map[string]int)
+	if err := gather(files, func(filename string, data []byte) {
+		for _, link := range rst.ParseForHTTPLinks(data) {
+			u, err := url.Parse(string(link))
+			if err != nil || u.Host == "" {
+				continue
+			}
+			counts[u.Hostname()]++
+		}
+	}); err != nil {
+		return nil, err
+	}
+	domains := make([]DomainCount, 0, len(counts))
+	for domain, count := range counts {
+		domains = append(domains, DomainCount{Domain: domain, Count: count})
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if domains[i].Count != domains[j].Count {
+			return domains[i].Count > domains[j].Count
+		}
+		return domains[i].Domain < domains[j].Domain
+	})
+	if n > 0 && len(domains) > n {
+		domains = domains[:n]
+	}
+	return domains, nil
+}
@@ -0,0 +1,47 @@
+package collectors
+
+import (
+	"path/filepath"
+	"testing"
+
+	iowrap "github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGatherStats(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "fundamentals"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "compatibility.txt"), compatibilityFile, 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "fundamentals", "aggregation.txt"), aggregationsFile, 0644))
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherStats(files)
+	assert.NoError(t, err)
+
+	assert.Equal(t, FileStats{Refs: 2, Includes: 3}, actual["/source/compatibility.txt"])
+	assert.Equal(t, FileStats{Refs: 0, Roles: 10, Links: 1, Constants: 2}, actual["/source/fundamentals/aggregation.txt"])
+	assert.Equal(t, FileStats{Refs: 2, Roles: 10, Links: 1, Includes: 3, Constants: 2}, actual["/source/compatibility.txt"].Add(actual["/source/fundamentals/aggregation.txt"]))
+}
+
+func TestGatherTopDomains(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), indexFile, 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "links.txt"), []byte("https://github.com/mongodb/docs https://www.mongodb.com/docs"), 0644))
+
+	expected := []DomainCount{
+		{Domain: "github.com", Count: 3},
+		{Domain: "developer.mongodb.com", Count: 2},
+	}
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherTopDomains(files, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
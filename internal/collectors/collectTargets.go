@@ -0,0 +1,117 @@
+package collectors
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+)
+
+// TargetRegistry is the objects that directives define, like
+// .. method:: db.collection.find(), by kind, with every file defining each.
+type TargetRegistry struct {
+	targets   map[string]map[string][]string
+	callables map[string]bool
+}
+
+// GatherTargets registers the objects every directive in files defines. Kinds
+// in callables, like method, are registered by name, without arguments.
+func GatherTargets(files []string, callables map[string]bool) (*TargetRegistry, error) {
+	r := newTargetRegistry(callables)
+	if err := gather(files, func(filename string, data []byte) {
+		r.register(filename, rst.ParseForDirectives(data))
+	}); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func newTargetRegistry(callables map[string]bool) *TargetRegistry {
+	return &TargetRegistry{targets: make(map[string]map[string][]string), callables: callables}
+}
+
+// register adds the objects directives, found in filename, define.
+func (r *TargetRegistry) register(filename string, directives []rst.RstDirective) {
+	for _, directive := range directives {
+		for _, target := range r.definitions(directive) {
+			if r.targets[directive.Name] == nil {
+				r.targets[directive.Name] = make(map[string][]string)
+			}
+			r.targets[directive.Name][target] = addUse(r.targets[directive.Name][target], filename)
+		}
+	}
+}
+
+// definitions are the targets a directive defines. Some define several, like
+// .. option:: -p, --port <number>, which defines -p and --port.
+func (r *TargetRegistry) definitions(directive rst.RstDirective) []string {
+	target := directive.Target
+	if r.callables[directive.Name] {
+		target = strings.SplitN(target, "(", 2)[0]
+	}
+	definitions := make([]string, 0, 1)
+	for _, definition := range strings.Split(target, ",") {
+		if fields := strings.Fields(definition); len(fields) > 0 {
+			definitions = append(definitions, fields[0])
+		}
+	}
+	return definitions
+}
+
+// Normalize converts a role's target to the form kind's objects are
+// registered in: :method:`~db.collection.find()` references
+// db.collection.find, and :option:`mongod --port` references --port.
+func (r *TargetRegistry) Normalize(kind string, target string) string {
+	target = strings.TrimPrefix(strings.TrimSpace(target), "~")
+	if r.callables[kind] {
+		target = strings.SplitN(target, "(", 2)[0]
+	}
+	fields := strings.Fields(target)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[len(fields)-1]
+}
+
+// Has reports whether any directive defines objects of kind.
+func (r *TargetRegistry) Has(kind string) bool {
+	return len(r.targets[kind]) > 0
+}
+
+// Defines reports whether a directive defines target as an object of kind.
+func (r *TargetRegistry) Defines(kind string, target string) bool {
+	_, ok := r.targets[kind][r.Normalize(kind, target)]
+	return ok
+}
+
+// Kinds returns every kind of directive that defines objects, sorted.
+func (r *TargetRegistry) Kinds() []string {
+	kinds := make([]string, 0, len(r.targets))
+	for kind := range r.targets {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Duplicates maps the objects of kind that are defined in more than one file
+// to those files.
+func (r *TargetRegistry) Duplicates(kind string) map[string][]string {
+	duplicates := make(map[string][]string)
+	for target, files := range r.targets[kind] {
+		if len(files) > 1 {
+			duplicates[target] = files
+		}
+	}
+	return duplicates
+}
+
+// Targets returns every object of kind that's defined, sorted.
+func (r *TargetRegistry) Targets(kind string) []string {
+	targets := make([]string, 0, len(r.targets[kind]))
+	for target := range r.targets[kind] {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
@@ -0,0 +1,81 @@
+package collectors
+
+import (
+	"path/filepath"
+	"testing"
+
+	iowrap "github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const mongodFile = `
+.. option:: -p, --port <number>
+
+.. option:: --bind_ip
+
+.. setting:: net.port
+
+.. method:: db.collection.find(query, projection)
+
+.. dbcommand:: find
+`
+
+func TestGatherTargets(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "reference"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "reference", "mongod.txt"), []byte(mongodFile), 0644))
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	registry, err := GatherTargets(files, map[string]bool{"method": true})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"--bind_ip", "--port", "-p"}, registry.Targets("option"))
+	assert.Equal(t, []string{"net.port"}, registry.Targets("setting"))
+	assert.Equal(t, []string{"db.collection.find"}, registry.Targets("method"))
+	assert.True(t, registry.Has("dbcommand"))
+	assert.False(t, registry.Has("serverstatus"))
+
+	cases := []struct {
+		kind    string
+		target  string
+		defines bool
+	}{
+		{kind: "option", target: "--port", defines: true},
+		{kind: "option", target: "mongod --port", defines: true},
+		{kind: "option", target: "--prot", defines: false},
+		{kind: "setting", target: "net.port", defines: true},
+		{kind: "method", target: "db.collection.find()", defines: true},
+		{kind: "method", target: "~db.collection.find()", defines: true},
+		{kind: "method", target: "db.collection.find", defines: true},
+		{kind: "method", target: "db.collection.findOne()", defines: false},
+		{kind: "dbcommand", target: "find", defines: true},
+		{kind: "serverstatus", target: "find", defines: false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.defines, registry.Defines(c.kind, c.target), "Defines(%q, %q)", c.kind, c.target)
+	}
+}
+
+func TestTargetDuplicates(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source", "reference"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "reference", "mongod.txt"), []byte(mongodFile), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "reference", "find.txt"), []byte(".. method:: db.collection.find()\n\n.. method:: db.collection.find()\n\n.. include:: /includes/x.rst\n"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), []byte(".. include:: /includes/x.rst\n"), 0644))
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	registry, err := GatherTargets(files, map[string]bool{"method": true})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"dbcommand", "include", "method", "option", "setting"}, registry.Kinds())
+	assert.Equal(t, map[string][]string{
+		"db.collection.find": {"/source/reference/find.txt", "/source/reference/mongod.txt"},
+	}, registry.Duplicates("method"))
+	assert.Empty(t, registry.Duplicates("option"))
+}
@@ -0,0 +1,50 @@
+package collectors
+
+import (
+	"sort"
+
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+)
+
+// addUse records that filename uses something, keeping uses sorted and unique.
+func addUse(uses []string, filename string) []string {
+	i := sort.SearchStrings(uses, filename)
+	if i < len(uses) && uses[i] == filename {
+		return uses
+	}
+	uses = append(uses, "")
+	copy(uses[i+1:], uses[i:])
+	uses[i] = filename
+	return uses
+}
+
+// GatherHTTPLinkUses maps each external link to every file that uses it.
+func GatherHTTPLinkUses(files []string) (map[rst.RstHTTPLink][]string, error) {
+	links := make(map[rst.RstHTTPLink][]string)
+	if err := gather(files, func(filename string, data []byte) {
+		for _, link := range rst.ParseForHTTPLinks(data) {
+			links[link] = addUse(links[link], filename)
+		}
+		directiveDataLinks(filename, rst.ParseForDirectives(data), func(link rst.RstHTTPLink) {
+			links[link] = addUse(links[link], filename)
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// GatherRefUses maps each ref target to every file that refers to it with :ref:.
+func GatherRefUses(files []string) (map[string][]string, error) {
+	refs := make(map[string][]string)
+	if err := gather(files, func(filename string, data []byte) {
+		for _, role := range rst.ParseForRoles(data) {
+			if role.RoleType == "ref" {
+				refs[role.Target] = addUse(refs[role.Target], filename)
+			}
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
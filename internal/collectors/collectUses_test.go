@@ -0,0 +1,57 @@
+package collectors
+
+import (
+	"path/filepath"
+	"testing"
+
+	iowrap "github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+)
+
+func TestAddUse(t *testing.T) {
+	uses := addUse(nil, "/source/b.txt")
+	uses = addUse(uses, "/source/a.txt")
+	uses = addUse(uses, "/source/b.txt")
+	uses = addUse(uses, "/source/c.txt")
+
+	assert.Equal(t, []string{"/source/a.txt", "/source/b.txt", "/source/c.txt"}, uses)
+}
+
+func TestGatherHTTPLinkUses(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "index.txt"), indexFile, 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "links.txt"), []byte("https://github.com/mongodb/node-mongodb-native/ https://github.com/mongodb/node-mongodb-native/"), 0644))
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherHTTPLinkUses(files)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"/source/index.txt", "/source/links.txt"}, actual[rst.RstHTTPLink("https://github.com/mongodb/node-mongodb-native/")])
+	assert.Equal(t, []string{"/source/index.txt"}, actual[rst.RstHTTPLink("https://university.mongodb.com/courses/M220JS/about")])
+	assert.Len(t, actual, 5)
+}
+
+func TestGatherRefUses(t *testing.T) {
+	defer afterTest(t)
+
+	check(FS.MkdirAll(filepath.Join(basepath, "source"), 0755))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "snooty.toml"), []byte("test"), 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "compatibility.txt"), compatibilityFile, 0644))
+	check(iowrap.WriteFile(FS, filepath.Join(basepath, "source", "other.txt"), []byte("See :ref:`mongodb-compatibility-table-about-node` and :doc:`/index`."), 0644))
+
+	files, err := GatherFiles(basepath)
+	assert.NoError(t, err)
+	actual, err := GatherRefUses(files)
+	assert.NoError(t, err)
+
+	for target, uses := range actual {
+		assert.NotEmpty(t, uses, target)
+	}
+	assert.Equal(t, []string{"/source/compatibility.txt", "/source/other.txt"}, actual["mongodb-compatibility-table-about-node"])
+	assert.NotContains(t, actual, "/index")
+}
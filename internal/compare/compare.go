@@ -0,0 +1,54 @@
+// Package compare tells what changed between the findings of two runs.
+package compare
+
+import (
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+// Result is how the findings of a run compare to an earlier one's.
+type Result struct {
+	// New are findings the earlier run didn't have
+	New []diagnostics.Diagnostic
+	// Fixed are findings of the earlier run that are gone
+	Fixed []diagnostics.Diagnostic
+	// Still are findings both runs have, as the later run reported them
+	Still []diagnostics.Diagnostic
+}
+
+// key identifies a finding across runs. Lines move as files are edited, and
+// messages can mention things like response times, so a finding is its
+// category, its file, and its target, or its message when it has none.
+func key(d diagnostics.Diagnostic) [3]string {
+	about := d.Target
+	if about == "" {
+		about = d.Message
+	}
+	return [3]string{string(d.Category), d.File, about}
+}
+
+// Compare compares the findings of a run, after, to those of an earlier one,
+// before. A finding reported several times is matched as many times.
+func Compare(before []diagnostics.Diagnostic, after []diagnostics.Diagnostic) Result {
+	earlier := make(map[[3]string][]diagnostics.Diagnostic)
+	for _, d := range before {
+		earlier[key(d)] = append(earlier[key(d)], d)
+	}
+	var res Result
+	for _, d := range after {
+		k := key(d)
+		if len(earlier[k]) == 0 {
+			res.New = append(res.New, d)
+			continue
+		}
+		earlier[k] = earlier[k][1:]
+		res.Still = append(res.Still, d)
+	}
+	for _, d := range before {
+		k := key(d)
+		if len(earlier[k]) > 0 {
+			res.Fixed = append(res.Fixed, earlier[k][0])
+			earlier[k] = earlier[k][1:]
+		}
+	}
+	return res
+}
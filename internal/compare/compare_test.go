@@ -0,0 +1,33 @@
+package compare
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+func TestCompare(t *testing.T) {
+	gone := diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "https://example.com/a is gone").WithTarget("https://example.com/a")
+	ref := diagnostics.Errorf(diagnostics.Ref, "/source/faq.txt", "nope is not a valid ref").AtLine(3)
+	fixed := diagnostics.Errorf(diagnostics.Link, "/source/faq.txt", "https://example.com/b is gone").WithTarget("https://example.com/b")
+	broken := diagnostics.Errorf(diagnostics.Link, "/source/faq.txt", "https://example.com/c is gone").WithTarget("https://example.com/c")
+
+	// the same link failing differently, and the ref moving down a line, is
+	// still the same finding
+	goneLater := diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "https://example.com/a timed out").WithTarget("https://example.com/a")
+	refLater := ref.AtLine(5)
+
+	res := Compare([]diagnostics.Diagnostic{gone, ref, fixed}, []diagnostics.Diagnostic{refLater, goneLater, broken})
+	assert.Equal(t, []diagnostics.Diagnostic{broken}, res.New)
+	assert.Equal(t, []diagnostics.Diagnostic{fixed}, res.Fixed)
+	assert.Equal(t, []diagnostics.Diagnostic{refLater, goneLater}, res.Still)
+}
+
+func TestCompareRepeated(t *testing.T) {
+	ref := diagnostics.Errorf(diagnostics.Ref, "/source/faq.txt", "nope is not a valid ref")
+	res := Compare([]diagnostics.Diagnostic{ref}, []diagnostics.Diagnostic{ref, ref.AtLine(9)})
+	assert.Equal(t, []diagnostics.Diagnostic{ref.AtLine(9)}, res.New)
+	assert.Empty(t, res.Fixed)
+	assert.Equal(t, []diagnostics.Diagnostic{ref}, res.Still)
+}
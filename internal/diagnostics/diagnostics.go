@@ -0,0 +1,206 @@
+package diagnostics
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+)
+
+// Category is the kind of check that produced a diagnostic.
+type Category string
+
+const (
+	Ref        Category = "ref"
+	Doc        Category = "doc"
+	Role       Category = "role"
+	Link       Category = "link"
+	Constant   Category = "constant"
+	OpenAPI    Category = "openapi"
+	Redirect   Category = "redirect"
+	Deprecated Category = "deprecated"
+	Duplicate  Category = "duplicate"
+	// Syntax is for markup that's likely broken, like an unclosed backtick
+	Syntax Category = "syntax"
+	// Naming is for ref targets named against the project's convention
+	Naming Category = "naming"
+	// Scheme is for links whose scheme the project doesn't allow
+	Scheme Category = "scheme"
+	// Metadata is for page options and meta fields snooty won't use
+	Metadata Category = "metadata"
+	// Slow is for links that work but took longer than --warn-slow to respond
+	Slow Category = "slow"
+	// Canonical is for links to pages that say their canonical url is another
+	Canonical Category = "canonical"
+	// Locale is for differences between the source and a translation of it
+	Locale Category = "locale"
+	// Plugin is for problems found by the project's plugins
+	Plugin Category = "plugin"
+	// Certificate is for links whose TLS certificate didn't verify, with the
+	// Reason it didn't
+	Certificate Category = "certificate"
+	// SharedInclude is for sharedincludes that don't exist at their shared
+	// source
+	SharedInclude Category = "sharedinclude"
+	// Checker is for problems checker itself ran into, like files it couldn't parse
+	Checker Category = "checker"
+)
+
+// Categories are every Category, in the order they're declared.
+var Categories = []Category{Ref, Doc, Role, Link, Constant, OpenAPI, Redirect, Deprecated, Duplicate, Syntax, Naming, Scheme, Metadata, Slow, Canonical, Locale, Plugin, Certificate, SharedInclude, Checker}
+
+// Reason is why a diagnostic was reported, finer grained than its Category,
+// for triaging a run with many findings.
+type Reason string
+
+const (
+	// DNS is for links whose host couldn't be found
+	DNS Reason = "dns"
+	// Timeout is for links that didn't respond in time
+	Timeout Reason = "timeout"
+	// TLS is for links whose TLS connection failed for another reason than
+	// the ones below, like a protocol version the site doesn't support
+	TLS Reason = "tls"
+	// CertExpired is for certificates that have expired, or aren't valid yet
+	CertExpired Reason = "expired-cert"
+	// CertExpiring is for certificates that still work, but expire soon
+	CertExpiring Reason = "expiring-cert"
+	// CertWrongHost is for certificates for another host than the link's
+	CertWrongHost Reason = "wrong-host-cert"
+	// CertUnknownAuthority is for certificates signed by an authority that
+	// isn't trusted, like self-signed ones
+	CertUnknownAuthority Reason = "unknown-ca"
+	// ClientError is for links that returned a 4xx status
+	ClientError Reason = "4xx"
+	// ServerError is for links that returned a 5xx status
+	ServerError Reason = "5xx"
+	// UnknownRef is for refs to targets that aren't defined
+	UnknownRef Reason = "unknown-ref"
+	// UnknownRole is for roles that aren't in the rstspec
+	UnknownRole Reason = "unknown-role"
+	// Flaky is for links that have alternated between working and failing
+	// in recent runs
+	Flaky Reason = "flaky"
+	// MissingShared is for sharedincludes whose path isn't at their shared
+	// source
+	MissingShared Reason = "missing-shared"
+	// Skipped is for links that weren't checked because checker.toml skips
+	// their domain for now
+	Skipped Reason = "skipped"
+)
+
+// Reasons are every Reason, in the order they're declared.
+var Reasons = []Reason{DNS, Timeout, TLS, CertExpired, CertExpiring, CertWrongHost, CertUnknownAuthority, ClientError, ServerError, UnknownRef, UnknownRole, MissingShared, Flaky, Skipped}
+
+// Diagnostic is a single finding about a file in the project.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Category Category `json:"category"`
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Message  string   `json:"message"`
+	// Target is the text in File the diagnostic is about, like a ref target or url
+	Target string `json:"target,omitempty"`
+	// Role is the name of the role Target is the target of, like ref, when
+	// it's a role's
+	Role string `json:"role,omitempty"`
+	// Suggestions are likely fixes, like the closest valid ref target
+	Suggestions []string `json:"suggestions,omitempty"`
+	// Fix is a known replacement for Target, like the new url of a moved page
+	Fix string `json:"fix,omitempty"`
+	// Reason is why the finding was reported, when it's known
+	Reason Reason `json:"reason,omitempty"`
+}
+
+func Errorf(category Category, file string, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{Severity: Error, Category: category, File: file, Message: fmt.Sprintf(format, args...)}
+}
+
+func Warnf(category Category, file string, format string, args ...interface{}) Diagnostic {
+	return Diagnostic{Severity: Warning, Category: category, File: file, Message: fmt.Sprintf(format, args...)}
+}
+
+// AtLine returns a copy of d pointing at line.
+func (d Diagnostic) AtLine(line int) Diagnostic {
+	d.Line = line
+	return d
+}
+
+// WithTarget returns a copy of d about target.
+func (d Diagnostic) WithTarget(target string) Diagnostic {
+	d.Target = target
+	return d
+}
+
+// WithRole returns a copy of d about the target of a role named role.
+func (d Diagnostic) WithRole(role string) Diagnostic {
+	d.Role = role
+	return d
+}
+
+// WithFix returns a copy of d where Target should be replaced with fix.
+func (d Diagnostic) WithFix(fix string) Diagnostic {
+	d.Fix = fix
+	return d
+}
+
+// WithReason returns a copy of d reported because of reason.
+func (d Diagnostic) WithReason(reason Reason) Diagnostic {
+	d.Reason = reason
+	return d
+}
+
+// WithSuggestions returns a copy of d suggesting fixes.
+func (d Diagnostic) WithSuggestions(suggestions []string) Diagnostic {
+	d.Suggestions = suggestions
+	return d
+}
+
+// Rescope returns a copy of d that's an error if its reason or category is in
+// errorOn, or a warning if it's in warnOn. Its reason is matched before its
+// category, so a reason overrides the category it's in.
+func (d Diagnostic) Rescope(errorOn []string, warnOn []string) Diagnostic {
+	for _, scope := range []string{string(d.Reason), string(d.Category)} {
+		if scope == "" {
+			continue
+		}
+		if containsScope(errorOn, scope) {
+			d.Severity = Error
+			return d
+		}
+		if containsScope(warnOn, scope) {
+			d.Severity = Warning
+			return d
+		}
+	}
+	return d
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (d Diagnostic) String() string {
+	msg := d.Message
+	if len(d.Suggestions) > 0 {
+		msg = fmt.Sprintf("%s, did you mean %s?", msg, strings.Join(d.Suggestions, ", "))
+	}
+	switch {
+	case d.File == "":
+		return msg
+	case d.Line > 0:
+		return fmt.Sprintf("in %s line %d: %s", d.File, d.Line, msg)
+	default:
+		return fmt.Sprintf("in %s: %s", d.File, msg)
+	}
+}
@@ -0,0 +1,75 @@
+package diagnostics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiagnosticString(t *testing.T) {
+	cases := []struct {
+		input    Diagnostic
+		expected string
+	}{{
+		input:    Errorf(Ref, "/source/index.txt", "%s is not a valid ref", "nope"),
+		expected: "in /source/index.txt: nope is not a valid ref",
+	}, {
+		input:    Warnf(Redirect, "config/redirects", "redirect from %s collides with a page", "/faq").AtLine(3),
+		expected: "in config/redirects line 3: redirect from /faq collides with a page",
+	}, {
+		input:    Errorf(Ref, "/source/index.txt", "%s is not a valid ref", "gridfs-upload-file").WithSuggestions([]string{"gridfs-upload-files", "gridfs-upload"}),
+		expected: "in /source/index.txt: gridfs-upload-file is not a valid ref, did you mean gridfs-upload-files, gridfs-upload?",
+	}, {
+		input:    Errorf(Constant, "", "api is not defined in config"),
+		expected: "api is not defined in config",
+	}}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.input.String())
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	assert.Equal(t, Error, Errorf(Link, "f", "m").Severity)
+	assert.Equal(t, Warning, Warnf(Link, "f", "m").Severity)
+}
+
+func TestRescope(t *testing.T) {
+	gone := Errorf(Link, "/source/index.txt", "gone").WithReason(ClientError)
+	slow := Errorf(Link, "/source/index.txt", "slow").WithReason(Timeout)
+	moved := Warnf(Link, "/source/index.txt", "moved")
+
+	// --warn-on link --error-on 4xx only fails on links that are gone
+	warnOn, errorOn := []string{"link"}, []string{"4xx"}
+	assert.Equal(t, Error, gone.Rescope(errorOn, warnOn).Severity)
+	assert.Equal(t, Warning, slow.Rescope(errorOn, warnOn).Severity)
+
+	// --error-on link --warn-on timeout
+	warnOn, errorOn = []string{"timeout"}, []string{"link"}
+	assert.Equal(t, Error, gone.Rescope(errorOn, warnOn).Severity)
+	assert.Equal(t, Warning, slow.Rescope(errorOn, warnOn).Severity)
+	assert.Equal(t, Error, moved.Rescope(errorOn, warnOn).Severity)
+
+	assert.Equal(t, gone, gone.Rescope(nil, nil))
+}
+
+func TestSummarize(t *testing.T) {
+	found := []Diagnostic{
+		Errorf(Link, "/source/index.txt", "gone").WithReason(ClientError),
+		Errorf(Link, "/source/faq.txt", "gone").WithReason(ClientError),
+		Errorf(Link, "/source/faq.txt", "slow").WithReason(Timeout),
+		Errorf(Ref, "/source/faq.txt", "nope").WithReason(UnknownRef),
+		Errorf(Link, "/source/faq.txt", "soft 404"),
+	}
+	groups := Summarize(found)
+	assert.Equal(t, []string{"4xx", "link", "timeout", "unknown-ref"}, func() []string {
+		var names []string
+		for _, g := range groups {
+			names = append(names, g.Name)
+		}
+		return names
+	}())
+	assert.Equal(t, 2, groups[0].Count)
+	assert.Contains(t, groups[0].Hint, "gone or moved")
+	assert.Empty(t, groups[1].Hint, "categories without a reason have no hint")
+}
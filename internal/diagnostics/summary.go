@@ -0,0 +1,57 @@
+package diagnostics
+
+import "sort"
+
+// Group is how many findings of a run share a reason, or a category when
+// they have no reason, and what usually fixes them.
+type Group struct {
+	Name  string
+	Count int
+	Hint  string
+}
+
+// hints say what usually causes each reason, so a run with many findings can
+// be triaged without reading every one.
+var hints = map[Reason]string{
+	DNS:                  "the host doesn't exist anymore, or couldn't be looked up from where checker ran; look for typos in the domain",
+	Timeout:              "the server didn't respond in time, which is often transient; rerun before editing links",
+	TLS:                  "the TLS connection failed, often because the site only supports old protocol versions; open the link in a browser to see why",
+	CertExpired:          "the site's certificate expired; it's usually renewed soon, so rerun before editing links, or ask the site's owner",
+	CertExpiring:         "the site's certificate expires soon; if the team runs the site, make sure it's renewed in time",
+	CertWrongHost:        "the certificate is for another host, often because the link's domain moved; find the page's current url",
+	CertUnknownAuthority: "the certificate is self-signed or from an untrusted authority; for internal hosts, use --insecure-hosts",
+	ClientError:          "the page is gone or moved; find where it went, or link to an archived copy",
+	ServerError:          "the server had an error, which is often transient; rerun before editing links",
+	UnknownRef:           "the ref target was renamed or removed; use the suggested target or add the label back",
+	UnknownRole:          "the role isn't in the rstspec; look for a typo or update rstspec.toml",
+	MissingShared:        "the shared include was moved or isn't published yet; check its path, or read it from a local checkout with shared_sources",
+	Flaky:                "the link has been failing on and off in recent runs; it's likely fine, so only look into it if it keeps failing",
+	Skipped:              "the link's domain is skipped in checker.toml until a date; fix or remove the skip before then",
+}
+
+// Summarize groups found by reason, or by category when they have no reason,
+// most common first.
+func Summarize(found []Diagnostic) []Group {
+	counts := map[string]int{}
+	for _, d := range found {
+		counts[groupName(d)]++
+	}
+	groups := make([]Group, 0, len(counts))
+	for name, count := range counts {
+		groups = append(groups, Group{Name: name, Count: count, Hint: hints[Reason(name)]})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Name < groups[j].Name
+	})
+	return groups
+}
+
+func groupName(d Diagnostic) string {
+	if d.Reason != "" {
+		return string(d.Reason)
+	}
+	return string(d.Category)
+}
@@ -0,0 +1,96 @@
+// Package events streams what happens during a run as JSON Lines, one event
+// per line as it happens, for wrapper tools and dashboards to follow along.
+//
+// A nil *Emitter emits nothing, so callers don't need to check whether events
+// were asked for.
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+const (
+	StageStarted  = "stage_started"
+	StageFinished = "stage_finished"
+	Finding       = "finding"
+	Finished      = "finished"
+)
+
+// Event is a line of output. Only the fields that apply to Type are set.
+type Event struct {
+	Type       string                  `json:"event"`
+	Time       time.Time               `json:"time"`
+	Stage      string                  `json:"stage,omitempty"`
+	DurationMS *int64                  `json:"durationMs,omitempty"`
+	Diagnostic *diagnostics.Diagnostic `json:"diagnostic,omitempty"`
+	Errors     *int                    `json:"errors,omitempty"`
+	Warnings   *int                    `json:"warnings,omitempty"`
+}
+
+// Emitter writes events to an io.Writer. It's safe for concurrent use.
+type Emitter struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	now     func() time.Time
+	stage   string
+	started time.Time
+}
+
+func New(out io.Writer) *Emitter {
+	return &Emitter{enc: json.NewEncoder(out), now: time.Now}
+}
+
+func (e *Emitter) emit(event Event) {
+	// a failed write can't be reported anywhere better than the log the
+	// events are replacing, so it's dropped
+	_ = e.enc.Encode(event)
+}
+
+func (e *Emitter) finishStage(now time.Time) {
+	if e.stage == "" {
+		return
+	}
+	ms := now.Sub(e.started).Milliseconds()
+	e.emit(Event{Type: StageFinished, Time: now, Stage: e.stage, DurationMS: &ms})
+	e.stage = ""
+}
+
+// Stage finishes the current stage, if any, and starts the next one.
+func (e *Emitter) Stage(name string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	e.finishStage(now)
+	e.stage, e.started = name, now
+	e.emit(Event{Type: StageStarted, Time: now, Stage: name})
+}
+
+// Finding emits a diagnostic as soon as it's found.
+func (e *Emitter) Finding(d diagnostics.Diagnostic) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.emit(Event{Type: Finding, Time: e.now(), Diagnostic: &d})
+}
+
+// Finish finishes the current stage and the run.
+func (e *Emitter) Finish(errors int, warnings int) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := e.now()
+	e.finishStage(now)
+	e.emit(Event{Type: Finished, Time: now, Errors: &errors, Warnings: &warnings})
+}
@@ -0,0 +1,42 @@
+package events
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+func TestEmitter(t *testing.T) {
+	var out bytes.Buffer
+	now := time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)
+	e := New(&out)
+	e.now = func() time.Time { return now }
+
+	e.Stage("parse")
+	now = now.Add(1500 * time.Millisecond)
+	e.Stage("validate")
+	e.Finding(diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "broken"))
+	now = now.Add(time.Second)
+	e.Finish(1, 0)
+
+	expected := []string{
+		`{"event":"stage_started","time":"2021-12-01T00:00:00Z","stage":"parse"}`,
+		`{"event":"stage_finished","time":"2021-12-01T00:00:01.5Z","stage":"parse","durationMs":1500}`,
+		`{"event":"stage_started","time":"2021-12-01T00:00:01.5Z","stage":"validate"}`,
+		`{"event":"finding","time":"2021-12-01T00:00:01.5Z","diagnostic":{"severity":"error","category":"link","file":"/source/index.txt","message":"broken"}}`,
+		`{"event":"stage_finished","time":"2021-12-01T00:00:02.5Z","stage":"validate","durationMs":1000}`,
+		`{"event":"finished","time":"2021-12-01T00:00:02.5Z","errors":1,"warnings":0}`,
+	}
+	assert.Equal(t, expected, strings.Split(strings.TrimSpace(out.String()), "\n"))
+}
+
+func TestNilEmitter(t *testing.T) {
+	var e *Emitter
+	e.Stage("parse")
+	e.Finding(diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "broken"))
+	e.Finish(1, 0)
+}
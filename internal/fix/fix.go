@@ -0,0 +1,180 @@
+package fix
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+)
+
+// Kind is the part of the source an Edit rewrites.
+type Kind int
+
+const (
+	// RoleName rewrites the name of a role, like :red: to :ref:
+	RoleName Kind = iota
+	// RoleTarget rewrites the target of a role, keeping any title
+	RoleTarget
+	// Link rewrites an http link
+	Link
+)
+
+// Edit replaces Old with New wherever it's used as Kind in File.
+type Edit struct {
+	File string
+	Kind Kind
+	Old  string
+	New  string
+	// Role, if set, limits a RoleTarget edit to the targets of roles named
+	// Role, so fixing :ref:`install` leaves :doc:`install` alone
+	Role string
+	// Line, if set, limits the edit to that line of File
+	Line int
+}
+
+func (e Edit) String() string {
+	return fmt.Sprintf("replace %s with %s", e.Old, e.New)
+}
+
+// Upgrade reports whether e only upgrades an http link to https, which the
+// site may not serve, so it's worth checking before it's applied.
+func (e Edit) Upgrade() bool {
+	return e.Kind == Link && strings.HasPrefix(e.Old, "http://") && e.New == "https://"+strings.TrimPrefix(e.Old, "http://")
+}
+
+// Candidates returns the edits that could fix d, best first.
+func Candidates(d diagnostics.Diagnostic) []Edit {
+	if d.Target == "" || d.File == "" {
+		return nil
+	}
+	var kind Kind
+	switch d.Category {
+	case diagnostics.Role:
+		kind = RoleName
+	case diagnostics.Ref:
+		kind = RoleTarget
+	case diagnostics.Link:
+		kind = Link
+	case diagnostics.Deprecated:
+		kind = RoleTarget
+		if strings.HasPrefix(d.Target, "http") {
+			kind = Link
+		}
+	default:
+		return nil
+	}
+
+	edits := make([]Edit, 0)
+	seen := make(map[string]bool)
+	add := func(replacement string) {
+		if replacement == "" || replacement == d.Target || seen[replacement] {
+			return
+		}
+		seen[replacement] = true
+		edits = append(edits, Edit{File: d.File, Kind: kind, Old: d.Target, New: replacement, Role: d.Role, Line: d.Line})
+	}
+	add(d.Fix)
+	for _, suggestion := range d.Suggestions {
+		add(suggestion)
+	}
+	if kind == Link && strings.HasPrefix(d.Target, "http://") {
+		add("https://" + strings.TrimPrefix(d.Target, "http://"))
+	}
+	return edits
+}
+
+// Apply rewrites data with e, returning the result and the number of replacements.
+func Apply(data []byte, e Edit) ([]byte, int) {
+	if e.Line > 0 {
+		start, end := lineRange(data, e.Line)
+		line, count := Apply(data[start:end], Edit{Kind: e.Kind, Old: e.Old, New: e.New, Role: e.Role})
+		if count == 0 {
+			return data, 0
+		}
+		return append(append(append([]byte{}, data[:start]...), line...), data[end:]...), count
+	}
+	count := 0
+	switch e.Kind {
+	case Link:
+		data = rst.ReplaceHTTPLinks(data, func(link rst.RstHTTPLink) rst.RstHTTPLink {
+			if string(link) != e.Old {
+				return link
+			}
+			count++
+			return rst.RstHTTPLink(e.New)
+		})
+	case RoleName:
+		re := regexp.MustCompile(`:` + regexp.QuoteMeta(e.Old) + `:\x60`)
+		data = re.ReplaceAllFunc(data, func(match []byte) []byte {
+			count++
+			return []byte(":" + e.New + ":`")
+		})
+	case RoleTarget:
+		role := `[[:alnum:]\.]+`
+		if e.Role != "" {
+			role = regexp.QuoteMeta(e.Role)
+		}
+		re := regexp.MustCompile(`(:` + role + `:\x60(?:[^\x60]*<)?)` + regexp.QuoteMeta(e.Old) + `(>?\x60)`)
+		data = re.ReplaceAllFunc(data, func(match []byte) []byte {
+			count++
+			sub := re.FindSubmatch(match)
+			return append(append(append([]byte{}, sub[1]...), e.New...), sub[2]...)
+		})
+	}
+	return data, count
+}
+
+// lineRange is where line, counting from 1, starts and ends in data, or an
+// empty range at the end of data if there aren't that many lines.
+func lineRange(data []byte, line int) (int, int) {
+	start := 0
+	for i := 1; i < line; i++ {
+		next := bytes.IndexByte(data[start:], '\n')
+		if next < 0 {
+			return len(data), len(data)
+		}
+		start += next + 1
+	}
+	end := bytes.IndexByte(data[start:], '\n')
+	if end < 0 {
+		return start, len(data)
+	}
+	return start, start + end
+}
+
+// Context returns the lines within radius of the first line containing target,
+// prefixed with their line numbers.
+func Context(data []byte, target string, radius int) []string {
+	lines := strings.Split(string(data), "\n")
+	found := -1
+	for i, line := range lines {
+		if strings.Contains(line, target) {
+			found = i
+			break
+		}
+	}
+	if found < 0 {
+		return nil
+	}
+	context := make([]string, 0, 2*radius+1)
+	for i := found - radius; i <= found+radius; i++ {
+		if i < 0 || i >= len(lines) {
+			continue
+		}
+		marker := " "
+		if i == found {
+			marker = ">"
+		}
+		context = append(context, fmt.Sprintf("%s %4d | %s", marker, i+1, strings.TrimRight(lines[i], "\r")))
+	}
+	return context
+}
+
+// Changed reports whether applying e to data would change anything.
+func Changed(data []byte, e Edit) bool {
+	updated, _ := Apply(data, e)
+	return !bytes.Equal(data, updated)
+}
@@ -0,0 +1,124 @@
+package fix
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+func TestCandidates(t *testing.T) {
+	cases := []struct {
+		input    diagnostics.Diagnostic
+		expected []Edit
+	}{{
+		input:    diagnostics.Errorf(diagnostics.Ref, "/source/index.txt", "not valid"),
+		expected: nil,
+	}, {
+		input: diagnostics.Errorf(diagnostics.Ref, "/source/index.txt", "not valid").WithTarget("gridfs-upload-file").WithSuggestions([]string{"gridfs-upload-files", "gridfs-upload"}),
+		expected: []Edit{
+			{File: "/source/index.txt", Kind: RoleTarget, Old: "gridfs-upload-file", New: "gridfs-upload-files"},
+			{File: "/source/index.txt", Kind: RoleTarget, Old: "gridfs-upload-file", New: "gridfs-upload"},
+		},
+	}, {
+		input: diagnostics.Errorf(diagnostics.Role, "/source/index.txt", "not valid").WithTarget("red").WithSuggestions([]string{"ref"}),
+		expected: []Edit{
+			{File: "/source/index.txt", Kind: RoleName, Old: "red", New: "ref"},
+		},
+	}, {
+		input: diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "not valid").WithTarget("http://www.mongodb.com"),
+		expected: []Edit{
+			{File: "/source/index.txt", Kind: Link, Old: "http://www.mongodb.com", New: "https://www.mongodb.com"},
+		},
+	}, {
+		input: diagnostics.Warnf(diagnostics.Deprecated, "/source/index.txt", "deprecated").WithTarget("http://docs.mongodb.com/manual").WithFix("https://www.mongodb.com/docs/manual/"),
+		expected: []Edit{
+			{File: "/source/index.txt", Kind: Link, Old: "http://docs.mongodb.com/manual", New: "https://www.mongodb.com/docs/manual/"},
+			{File: "/source/index.txt", Kind: Link, Old: "http://docs.mongodb.com/manual", New: "https://docs.mongodb.com/manual"},
+		},
+	}, {
+		input: diagnostics.Warnf(diagnostics.Deprecated, "/source/index.txt", "deprecated").WithTarget("nodejs-ssl").WithFix("nodejs-tls"),
+		expected: []Edit{
+			{File: "/source/index.txt", Kind: RoleTarget, Old: "nodejs-ssl", New: "nodejs-tls"},
+		},
+	}}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, Candidates(c.input), "Candidates(%v)", c.input)
+	}
+}
+
+func TestApply(t *testing.T) {
+	cases := []struct {
+		input    string
+		edit     Edit
+		expected string
+		count    int
+	}{{
+		input:    "see :red:`gridfs` and :red:`the title <gridfs>`, but not :redact:`x`",
+		edit:     Edit{Kind: RoleName, Old: "red", New: "ref"},
+		expected: "see :ref:`gridfs` and :ref:`the title <gridfs>`, but not :redact:`x`",
+		count:    2,
+	}, {
+		input:    "see :ref:`gridfs-upload-file` and :ref:`uploads <gridfs-upload-file>`, not :ref:`gridfs-upload-files`",
+		edit:     Edit{Kind: RoleTarget, Old: "gridfs-upload-file", New: "gridfs-upload-files"},
+		expected: "see :ref:`gridfs-upload-files` and :ref:`uploads <gridfs-upload-files>`, not :ref:`gridfs-upload-files`",
+		count:    2,
+	}, {
+		input:    "gridfs-upload-file is mentioned, but isn't a role",
+		edit:     Edit{Kind: RoleTarget, Old: "gridfs-upload-file", New: "gridfs-upload-files"},
+		expected: "gridfs-upload-file is mentioned, but isn't a role",
+		count:    0,
+	}, {
+		input:    "`the manual <http://docs.mongodb.com/manual>`__ and http://docs.mongodb.com/manual/core",
+		edit:     Edit{Kind: Link, Old: "http://docs.mongodb.com/manual", New: "https://www.mongodb.com/docs/manual"},
+		expected: "`the manual <https://www.mongodb.com/docs/manual>`__ and http://docs.mongodb.com/manual/core",
+		count:    1,
+	}, {
+		input:    "see :ref:`install` and :doc:`install`",
+		edit:     Edit{Kind: RoleTarget, Old: "install", New: "installation", Role: "ref"},
+		expected: "see :ref:`installation` and :doc:`install`",
+		count:    1,
+	}, {
+		input:    "see :red:`gridfs`\nand :red:`gridfs`\n",
+		edit:     Edit{Kind: RoleName, Old: "red", New: "ref", Line: 2},
+		expected: "see :red:`gridfs`\nand :ref:`gridfs`\n",
+		count:    1,
+	}, {
+		input:    "see :red:`gridfs`",
+		edit:     Edit{Kind: RoleName, Old: "red", New: "ref", Line: 3},
+		expected: "see :red:`gridfs`",
+		count:    0,
+	}}
+
+	for _, c := range cases {
+		actual, count := Apply([]byte(c.input), c.edit)
+		assert.Equal(t, c.expected, string(actual))
+		assert.Equal(t, c.count, count)
+		assert.Equal(t, c.count > 0, Changed([]byte(c.input), c.edit))
+	}
+}
+
+func TestCandidatesKeepRoleAndLine(t *testing.T) {
+	d := diagnostics.Errorf(diagnostics.Ref, "/source/index.txt", "not valid").WithTarget("install").WithRole("ref").WithSuggestions([]string{"installation"}).AtLine(3)
+
+	assert.Equal(t, []Edit{{File: "/source/index.txt", Kind: RoleTarget, Old: "install", New: "installation", Role: "ref", Line: 3}}, Candidates(d))
+}
+
+func TestUpgrade(t *testing.T) {
+	assert.True(t, Edit{Kind: Link, Old: "http://www.mongodb.com", New: "https://www.mongodb.com"}.Upgrade())
+	assert.False(t, Edit{Kind: Link, Old: "http://docs.mongodb.com/manual", New: "https://www.mongodb.com/docs/manual/"}.Upgrade())
+	assert.False(t, Edit{Kind: RoleTarget, Old: "http://a", New: "https://a"}.Upgrade())
+}
+
+func TestContext(t *testing.T) {
+	data := []byte("one\ntwo\nthree :ref:`nope`\nfour\nfive\nsix")
+
+	expected := []string{
+		"     2 | two",
+		">    3 | three :ref:`nope`",
+		"     4 | four",
+	}
+	assert.Equal(t, expected, Context(data, "nope", 1))
+	assert.Nil(t, Context(data, "missing", 1))
+}
@@ -0,0 +1,236 @@
+// Package formats writes what a run found as reports for other tools: plain
+// text, SARIF for code scanning, JUnit XML for CI test reports, and anything
+// else through a user's Go template.
+package formats
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+func count(found []diagnostics.Diagnostic) (errors int, warnings int) {
+	for _, d := range found {
+		if d.Severity == diagnostics.Warning {
+			warnings++
+		} else {
+			errors++
+		}
+	}
+	return errors, warnings
+}
+
+// Text writes the findings and a summary as plain text, a line each.
+func Text(w io.Writer, found []diagnostics.Diagnostic) error {
+	for _, d := range found {
+		if _, err := fmt.Fprintf(w, "%s: %s\n", d.Severity, d); err != nil {
+			return err
+		}
+	}
+	errors, warnings := count(found)
+	_, err := fmt.Fprintf(w, "%d errors and %d warnings found.\n", errors, warnings)
+	return err
+}
+
+// Report is what a template renders.
+type Report struct {
+	// Version is checker's version
+	Version  string
+	Findings []diagnostics.Diagnostic
+	Errors   int
+	Warnings int
+}
+
+// templateFuncs are available to templates, on top of text/template's own.
+var templateFuncs = template.FuncMap{
+	"join":       strings.Join,
+	"replace":    strings.ReplaceAll,
+	"trimPrefix": strings.TrimPrefix,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+}
+
+// ParseTemplate parses the Go template in filename, for Template.
+func ParseTemplate(filename string) (*template.Template, error) {
+	return template.New(filepath.Base(filename)).Funcs(templateFuncs).ParseFiles(filename)
+}
+
+// Template renders the findings through tmpl, with a Report as its data.
+func Template(w io.Writer, tmpl *template.Template, version string, found []diagnostics.Diagnostic) error {
+	errors, warnings := count(found)
+	return tmpl.Execute(w, Report{Version: version, Findings: found, Errors: errors, Warnings: warnings})
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+	// Properties keep what a finding is about, so a SARIF log can be a
+	// baseline for --compare like a jsonl report
+	Properties *sarifProperties `json:"properties,omitempty"`
+}
+
+type sarifProperties struct {
+	Target string `json:"target,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// SARIF writes the findings as a SARIF 2.1.0 log, with a rule for each
+// category, for GitHub code scanning and other static analysis viewers.
+// Files are relative to the project.
+func SARIF(w io.Writer, version string, found []diagnostics.Diagnostic) error {
+	categories := make(map[string]bool)
+	results := make([]sarifResult, 0, len(found))
+	for _, d := range found {
+		categories[string(d.Category)] = true
+		message := d.Message
+		if len(d.Suggestions) > 0 {
+			message = fmt.Sprintf("%s, did you mean %s?", message, strings.Join(d.Suggestions, ", "))
+		}
+		result := sarifResult{RuleID: string(d.Category), Level: string(d.Severity), Message: sarifMessage{Text: message}}
+		if d.File != "" {
+			location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: strings.TrimPrefix(d.File, "/")}}}
+			if d.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: d.Line}
+			}
+			result.Locations = []sarifLocation{location}
+		}
+		if d.Target != "" || d.Reason != "" {
+			result.Properties = &sarifProperties{Target: d.Target, Reason: string(d.Reason)}
+		}
+		results = append(results, result)
+	}
+	rules := make([]sarifRule, 0, len(categories))
+	for category := range categories {
+		rules = append(rules, sarifRule{ID: category})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "checker",
+				Version:        version,
+				InformationURI: "https://github.com/terakilobyte/checker",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	})
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit writes the findings as JUnit XML, a failed test case for each error
+// and a passed one, with the warning as output, for each warning. A run
+// without findings is a single passed test case, so CI shows the run.
+func JUnit(w io.Writer, found []diagnostics.Diagnostic) error {
+	suite := junitSuite{Name: "checker"}
+	for _, d := range found {
+		c := junitCase{Name: d.Message, ClassName: strings.TrimPrefix(d.File, "/")}
+		if d.Severity == diagnostics.Warning {
+			c.SystemOut = d.String()
+		} else {
+			c.Failure = &junitFailure{Message: d.Message, Type: string(d.Category), Text: d.String()}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	if len(suite.Cases) == 0 {
+		suite.Cases = []junitCase{{Name: "no errors found", ClassName: "checker"}}
+	}
+	suite.Tests = len(suite.Cases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,150 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/terakilobyte/checker/internal/compare"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+var found = []diagnostics.Diagnostic{
+	diagnostics.Errorf(diagnostics.Ref, "/source/index.txt", "nope is not a valid ref").AtLine(3).WithSuggestions([]string{"faq-top"}),
+	diagnostics.Warnf(diagnostics.Deprecated, "/source/faq.txt", "nodejs-ssl is deprecated"),
+}
+
+func TestText(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, Text(&b, found))
+	assert.Equal(t, `error: in /source/index.txt line 3: nope is not a valid ref, did you mean faq-top?
+warning: in /source/faq.txt: nodejs-ssl is deprecated
+1 errors and 1 warnings found.
+`, b.String())
+}
+
+func TestSARIF(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, SARIF(&b, "0.1.5", found))
+
+	var log sarifLog
+	assert.NoError(t, json.Unmarshal(b.Bytes(), &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	assert.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "0.1.5", run.Tool.Driver.Version)
+	assert.Equal(t, []sarifRule{{ID: "deprecated"}, {ID: "ref"}}, run.Tool.Driver.Rules)
+	assert.Len(t, run.Results, 2)
+	assert.Equal(t, "ref", run.Results[0].RuleID)
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Equal(t, "nope is not a valid ref, did you mean faq-top?", run.Results[0].Message.Text)
+	assert.Equal(t, "source/index.txt", run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, 3, run.Results[0].Locations[0].PhysicalLocation.Region.StartLine)
+	assert.Equal(t, "warning", run.Results[1].Level)
+	assert.Nil(t, run.Results[1].Locations[0].PhysicalLocation.Region)
+}
+
+func TestJUnit(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, JUnit(&b, found))
+
+	var suites junitTestSuites
+	assert.NoError(t, xml.Unmarshal(b.Bytes(), &suites))
+	suite := suites.Suites[0]
+	assert.Equal(t, 2, suite.Tests)
+	assert.Equal(t, 1, suite.Failures)
+	assert.Equal(t, "source/index.txt", suite.Cases[0].ClassName)
+	assert.Equal(t, "ref", suite.Cases[0].Failure.Type)
+	assert.Nil(t, suite.Cases[1].Failure)
+	assert.Contains(t, suite.Cases[1].SystemOut, "deprecated")
+}
+
+func TestJUnitClean(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, JUnit(&b, nil))
+
+	var suites junitTestSuites
+	assert.NoError(t, xml.Unmarshal(b.Bytes(), &suites))
+	assert.Equal(t, 1, suites.Suites[0].Tests)
+	assert.Equal(t, 0, suites.Suites[0].Failures)
+}
+
+func TestTemplate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "wiki.tmpl")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte(`checker {{.Version}}: {{.Errors}} errors, {{.Warnings}} warnings
+{{range .Findings}}| {{upper (print .Severity)}} | {{trimPrefix .File "/"}} | {{.Message}} | {{join .Suggestions ", "}} |
+{{end}}`), 0644))
+
+	tmpl, err := ParseTemplate(filename)
+	assert.NoError(t, err)
+	var b bytes.Buffer
+	assert.NoError(t, Template(&b, tmpl, "0.1.5", found))
+	assert.Equal(t, `checker 0.1.5: 1 errors, 1 warnings
+| ERROR | source/index.txt | nope is not a valid ref | faq-top |
+| WARNING | source/faq.txt | nodejs-ssl is deprecated |  |
+`, b.String())
+}
+
+func TestParseTemplateInvalid(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "broken.tmpl")
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("{{range .Findings}}"), 0644))
+	_, err := ParseTemplate(filename)
+	assert.Error(t, err)
+
+	_, err = ParseTemplate(filepath.Join(t.TempDir(), "missing.tmpl"))
+	assert.Error(t, err)
+}
+
+func TestReadSARIF(t *testing.T) {
+	var b bytes.Buffer
+	assert.NoError(t, SARIF(&b, "v1.0.0", found))
+	read, err := Read(&b)
+	assert.NoError(t, err)
+	assert.Len(t, read, 2)
+	assert.Equal(t, diagnostics.Ref, read[0].Category)
+	assert.Equal(t, diagnostics.Error, read[0].Severity)
+	assert.Equal(t, "/source/index.txt", read[0].File)
+	assert.Equal(t, 3, read[0].Line)
+	assert.Equal(t, "nope is not a valid ref, did you mean faq-top?", read[0].Message)
+}
+
+func TestReadSARIFCompare(t *testing.T) {
+	// a slow link's message has its response time, so it's matched across
+	// runs by its target
+	before := []diagnostics.Diagnostic{
+		diagnostics.Warnf(diagnostics.Slow, "/source/index.txt", "https://example.com took 3s").WithTarget("https://example.com"),
+		diagnostics.Errorf(diagnostics.Link, "/source/faq.txt", "https://example.com/gone returned 404").WithTarget("https://example.com/gone").WithReason(diagnostics.ClientError),
+	}
+	var b bytes.Buffer
+	assert.NoError(t, SARIF(&b, "v1.0.0", before))
+	read, err := Read(&b)
+	assert.NoError(t, err)
+	assert.Equal(t, before, read)
+
+	after := []diagnostics.Diagnostic{
+		diagnostics.Warnf(diagnostics.Slow, "/source/index.txt", "https://example.com took 5s").WithTarget("https://example.com"),
+	}
+	res := compare.Compare(read, after)
+	assert.Empty(t, res.New)
+	assert.Equal(t, after, res.Still)
+	assert.Equal(t, before[1:], res.Fixed)
+}
+
+func TestReadJSONL(t *testing.T) {
+	report := `{"event":"stageStarted","time":"2021-01-01T00:00:00Z","stage":"validate"}
+{"event":"finding","time":"2021-01-01T00:00:01Z","diagnostic":{"severity":"error","category":"link","file":"/source/index.txt","message":"gone","target":"https://example.com","reason":"4xx"}}
+{"event":"finished","time":"2021-01-01T00:00:02Z","errors":1,"warnings":0}
+`
+	read, err := Read(bytes.NewBufferString(report))
+	assert.NoError(t, err)
+	assert.Equal(t, []diagnostics.Diagnostic{
+		diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "gone").WithTarget("https://example.com").WithReason(diagnostics.ClientError),
+	}, read)
+
+	_, err = Read(bytes.NewBufferString("error: in /source/index.txt: gone\n"))
+	assert.Error(t, err)
+}
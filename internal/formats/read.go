@@ -0,0 +1,67 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+// Read reads the findings of a machine readable report: a jsonl event stream
+// or a SARIF log.
+func Read(r io.Reader) ([]diagnostics.Diagnostic, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err == nil && log.Version != "" {
+		return fromSARIF(log), nil
+	}
+	found := make([]diagnostics.Diagnostic, 0)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for line := 1; ; line++ {
+		var event struct {
+			Type       string                  `json:"event"`
+			Diagnostic *diagnostics.Diagnostic `json:"diagnostic"`
+		}
+		if err := dec.Decode(&event); err == io.EOF {
+			return found, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("not a jsonl or SARIF report, event %d: %v", line, err)
+		}
+		if event.Type == "finding" && event.Diagnostic != nil {
+			found = append(found, *event.Diagnostic)
+		}
+	}
+}
+
+func fromSARIF(log sarifLog) []diagnostics.Diagnostic {
+	found := make([]diagnostics.Diagnostic, 0)
+	for _, run := range log.Runs {
+		for _, result := range run.Results {
+			d := diagnostics.Diagnostic{
+				Severity: diagnostics.Severity(result.Level),
+				Category: diagnostics.Category(result.RuleID),
+				Message:  result.Message.Text,
+			}
+			if len(result.Locations) > 0 {
+				location := result.Locations[0].PhysicalLocation
+				d.File = "/" + strings.TrimPrefix(location.ArtifactLocation.URI, "/")
+				if location.Region != nil {
+					d.Line = location.Region.StartLine
+				}
+			}
+			if result.Properties != nil {
+				d.Target = result.Properties.Target
+				d.Reason = diagnostics.Reason(result.Properties.Reason)
+			}
+			found = append(found, d)
+		}
+	}
+	return found
+}
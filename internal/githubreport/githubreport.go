@@ -0,0 +1,219 @@
+// Package githubreport publishes diagnostics to a pull request on GitHub, as a
+// check run with inline annotations or as a sticky comment.
+package githubreport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v41/github"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+const (
+	checkName = "checker"
+	// marker identifies checker's comment, so later runs update it instead of adding another
+	marker = "<!-- checker -->"
+	// GitHub accepts at most 50 annotations per request
+	maxAnnotations = 50
+	// maxSummary leaves room under GitHub's 65536 character limit on comment
+	// bodies and check run summaries for the marker and the last line
+	maxSummary = 65000
+)
+
+// Report is what to publish, and where.
+type Report struct {
+	Owner string
+	Repo  string
+	// Dir is the project's directory relative to the repository root, so
+	// annotations point at the right files
+	Dir   string
+	Found []diagnostics.Diagnostic
+	// New is whether Found are only the findings an earlier run didn't have
+	New bool
+	// Failed is whether the diagnostics fail the run
+	Failed bool
+}
+
+// NewClient returns a GitHub client authenticated with token.
+func NewClient(token string) *github.Client {
+	return github.NewClient(&http.Client{Transport: utils.TokenTransport(token, http.DefaultTransport)})
+}
+
+func (r Report) path(d diagnostics.Diagnostic) string {
+	return path.Join(r.Dir, strings.TrimPrefix(d.File, "/"))
+}
+
+// Annotations converts the diagnostics to check run annotations. Diagnostics
+// without a line annotate the first line of their file, and those without a
+// file, like a plugin that failed, are only in the summary, since GitHub
+// rejects annotations that aren't on a file.
+func (r Report) Annotations() []*github.CheckRunAnnotation {
+	annotations := make([]*github.CheckRunAnnotation, 0, len(r.Found))
+	for _, d := range r.Found {
+		if d.File == "" {
+			continue
+		}
+		line := d.Line
+		if line == 0 {
+			line = 1
+		}
+		level := "failure"
+		if d.Severity == diagnostics.Warning {
+			level = "warning"
+		}
+		annotations = append(annotations, &github.CheckRunAnnotation{
+			Path:            github.String(r.path(d)),
+			StartLine:       github.Int(line),
+			EndLine:         github.Int(line),
+			AnnotationLevel: github.String(level),
+			Title:           github.String(string(d.Category)),
+			Message:         github.String(d.Message),
+		})
+	}
+	return annotations
+}
+
+// Title is a one line summary, like "2 errors and 1 warning".
+func (r Report) Title() string {
+	errors, warnings := 0, 0
+	for _, d := range r.Found {
+		if d.Severity == diagnostics.Warning {
+			warnings++
+		} else {
+			errors++
+		}
+	}
+	if errors == 0 && warnings == 0 {
+		if r.New {
+			return "No new problems found"
+		}
+		return "No problems found"
+	}
+	if r.New {
+		return fmt.Sprintf("%s and %s", plural(errors, "new error"), plural(warnings, "new warning"))
+	}
+	return fmt.Sprintf("%s and %s", plural(errors, "error"), plural(warnings, "warning"))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// Summary is a markdown table of the diagnostics, cut short with how many
+// more there are if it would be too long for GitHub.
+func (r Report) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**checker: %s**\n", r.Title())
+	if len(r.Found) == 0 {
+		return b.String()
+	}
+	b.WriteString("\n| | File | Problem |\n|---|---|---|\n")
+	for i, d := range r.Found {
+		icon := ":x:"
+		if d.Severity == diagnostics.Warning {
+			icon = ":warning:"
+		}
+		file := "-"
+		if d.File != "" {
+			file = "`" + r.path(d) + "`"
+			if d.Line > 0 {
+				file = fmt.Sprintf("`%s:%d`", r.path(d), d.Line)
+			}
+		}
+		message := strings.ReplaceAll(d.Message, "|", "\\|")
+		if len(d.Suggestions) > 0 {
+			message += ", did you mean " + strings.Join(d.Suggestions, ", ") + "?"
+		}
+		row := fmt.Sprintf("| %s | %s | %s |\n", icon, file, message)
+		if b.Len()+len(row) > maxSummary {
+			fmt.Fprintf(&b, "\n…and %d more\n", len(r.Found)-i)
+			break
+		}
+		b.WriteString(row)
+	}
+	return b.String()
+}
+
+// PublishCheckRun creates a completed check run on sha, annotating the
+// diagnostics inline.
+func PublishCheckRun(ctx context.Context, client *github.Client, sha string, r Report) error {
+	conclusion := "success"
+	if r.Failed {
+		conclusion = "failure"
+	}
+	annotations := r.Annotations()
+	output := func(batch []*github.CheckRunAnnotation) *github.CheckRunOutput {
+		return &github.CheckRunOutput{
+			Title:       github.String(r.Title()),
+			Summary:     github.String(r.Summary()),
+			Annotations: batch,
+		}
+	}
+
+	first := annotations
+	if len(first) > maxAnnotations {
+		first = first[:maxAnnotations]
+	}
+	run, _, err := client.Checks.CreateCheckRun(ctx, r.Owner, r.Repo, github.CreateCheckRunOptions{
+		Name:        checkName,
+		HeadSHA:     sha,
+		Status:      github.String("completed"),
+		Conclusion:  github.String(conclusion),
+		CompletedAt: &github.Timestamp{Time: time.Now()},
+		Output:      output(first),
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := maxAnnotations; i < len(annotations); i += maxAnnotations {
+		end := i + maxAnnotations
+		if end > len(annotations) {
+			end = len(annotations)
+		}
+		_, _, err := client.Checks.UpdateCheckRun(ctx, r.Owner, r.Repo, run.GetID(), github.UpdateCheckRunOptions{
+			Name:   checkName,
+			Output: output(annotations[i:end]),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpsertComment posts the summary as a comment on pull request number,
+// updating checker's earlier comment if there is one.
+func UpsertComment(ctx context.Context, client *github.Client, number int, r Report) error {
+	body := github.String(marker + "\n" + r.Summary())
+
+	opts := &github.IssueListCommentsOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		comments, resp, err := client.Issues.ListComments(ctx, r.Owner, r.Repo, number, opts)
+		if err != nil {
+			return err
+		}
+		for _, comment := range comments {
+			if strings.HasPrefix(comment.GetBody(), marker) {
+				_, _, err := client.Issues.EditComment(ctx, r.Owner, r.Repo, comment.GetID(), &github.IssueComment{Body: body})
+				return err
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	_, _, err := client.Issues.CreateComment(ctx, r.Owner, r.Repo, number, &github.IssueComment{Body: body})
+	return err
+}
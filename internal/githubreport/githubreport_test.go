@@ -0,0 +1,157 @@
+package githubreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v41/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+var found = []diagnostics.Diagnostic{
+	diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "https://example.com is not a valid http link"),
+	diagnostics.Warnf(diagnostics.Redirect, "config/redirects", "a | b").AtLine(4),
+}
+
+func testClient(t *testing.T, handler http.Handler) *github.Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client
+}
+
+func TestAnnotations(t *testing.T) {
+	r := Report{Dir: "docs", Found: found}
+
+	expected := []*github.CheckRunAnnotation{{
+		Path:            github.String("docs/source/index.txt"),
+		StartLine:       github.Int(1),
+		EndLine:         github.Int(1),
+		AnnotationLevel: github.String("failure"),
+		Title:           github.String("link"),
+		Message:         github.String("https://example.com is not a valid http link"),
+	}, {
+		Path:            github.String("docs/config/redirects"),
+		StartLine:       github.Int(4),
+		EndLine:         github.Int(4),
+		AnnotationLevel: github.String("warning"),
+		Title:           github.String("redirect"),
+		Message:         github.String("a | b"),
+	}}
+
+	assert.Equal(t, expected, r.Annotations())
+}
+
+func TestAnnotationsSkipDiagnosticsWithoutFile(t *testing.T) {
+	r := Report{Found: []diagnostics.Diagnostic{diagnostics.Errorf(diagnostics.Plugin, "", "plugin failed")}}
+
+	assert.Empty(t, r.Annotations())
+	assert.Contains(t, r.Summary(), "| :x: | - | plugin failed |\n")
+}
+
+func TestSummary(t *testing.T) {
+	assert.Equal(t, "**checker: No problems found**\n", Report{}.Summary())
+
+	expected := "**checker: 1 error and 1 warning**\n\n" +
+		"| | File | Problem |\n|---|---|---|\n" +
+		"| :x: | `source/index.txt` | https://example.com is not a valid http link |\n" +
+		"| :warning: | `config/redirects:4` | a \\| b |\n"
+	assert.Equal(t, expected, Report{Found: found}.Summary())
+}
+
+func TestSummaryTruncates(t *testing.T) {
+	many := make([]diagnostics.Diagnostic, 0, 2000)
+	for i := 0; i < 2000; i++ {
+		many = append(many, diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "https://example.com/%d is not a valid http link", i))
+	}
+	summary := Report{Found: many}.Summary()
+
+	assert.Less(t, len(summary), 65536)
+	rows := strings.Count(summary, "| :x: |")
+	assert.Less(t, rows, 2000)
+	assert.True(t, strings.HasSuffix(summary, fmt.Sprintf("\n…and %d more\n", 2000-rows)))
+}
+
+func TestTitleOfNewFindings(t *testing.T) {
+	assert.Equal(t, "No new problems found", Report{New: true}.Title())
+	assert.Equal(t, "1 new error and 1 new warning", Report{Found: found, New: true}.Title())
+}
+
+func TestPublishCheckRunBatchesAnnotations(t *testing.T) {
+	many := make([]diagnostics.Diagnostic, 0, 120)
+	for i := 0; i < 120; i++ {
+		many = append(many, diagnostics.Errorf(diagnostics.Ref, "/source/index.txt", "bad ref %d", i))
+	}
+	batches := make([]int, 0)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/mongodb/docs/check-runs", func(w http.ResponseWriter, r *http.Request) {
+		var opts github.CreateCheckRunOptions
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&opts))
+		assert.Equal(t, "abc123", opts.HeadSHA)
+		assert.Equal(t, "failure", opts.GetConclusion())
+		batches = append(batches, len(opts.Output.Annotations))
+		fmt.Fprint(w, `{"id": 7}`)
+	})
+	mux.HandleFunc("/repos/mongodb/docs/check-runs/7", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPatch, r.Method)
+		var opts github.UpdateCheckRunOptions
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&opts))
+		batches = append(batches, len(opts.Output.Annotations))
+		fmt.Fprint(w, `{"id": 7}`)
+	})
+
+	err := PublishCheckRun(context.Background(), testClient(t, mux), "abc123", Report{Owner: "mongodb", Repo: "docs", Found: many, Failed: true})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{50, 50, 20}, batches)
+}
+
+func TestUpsertComment(t *testing.T) {
+	cases := []struct {
+		name     string
+		existing string
+		method   string
+		path     string
+	}{{
+		name:     "creates a comment",
+		existing: `[{"id": 1, "body": "LGTM"}]`,
+		method:   http.MethodPost,
+		path:     "/repos/mongodb/docs/issues/12/comments",
+	}, {
+		name:     "updates its earlier comment",
+		existing: `[{"id": 1, "body": "LGTM"}, {"id": 2, "body": "<!-- checker -->\nold"}]`,
+		method:   http.MethodPatch,
+		path:     "/repos/mongodb/docs/issues/comments/2",
+	}}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			var written string
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					fmt.Fprint(w, test.existing)
+					return
+				}
+				assert.Equal(t, test.method, r.Method)
+				assert.Equal(t, test.path, r.URL.Path)
+				var comment github.IssueComment
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&comment))
+				written = comment.GetBody()
+				fmt.Fprint(w, `{"id": 3}`)
+			})
+
+			err := UpsertComment(context.Background(), testClient(t, handler), 12, Report{Owner: "mongodb", Repo: "docs", Found: found})
+
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(written, marker+"\n**checker: 1 error and 1 warning**"), written)
+		})
+	}
+}
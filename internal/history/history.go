@@ -0,0 +1,264 @@
+// Package history keeps the findings of every run in a file, so it's possible
+// to tell when something started failing, like whether a site broke recently.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+// Run is what a run checked and found.
+type Run struct {
+	Time time.Time `json:"time"`
+	// Scoped runs only checked some files, so what they didn't find may just
+	// not have been checked
+	Scoped bool `json:"scoped,omitempty"`
+	// Checked are the urls the run checked
+	Checked  []string                 `json:"checked,omitempty"`
+	Findings []diagnostics.Diagnostic `json:"findings"`
+}
+
+// Store is a file of runs, one JSON object a line, oldest first, so the file
+// can be kept as a CI artifact between runs.
+type Store struct {
+	path string
+	// Keep is how many runs Runs returns, the latest. Record only drops the
+	// older ones from the file once it has twice as many, so most runs just
+	// append a line. 0 keeps every run.
+	Keep int
+}
+
+func Open(path string) *Store {
+	return &Store{path: path}
+}
+
+// Record appends run to the store, creating it if needed. Only the findings
+// that are an item failing are kept, since nothing else is looked up later.
+func (s *Store) Record(run Run) error {
+	findings := make([]diagnostics.Diagnostic, 0)
+	for _, d := range run.Findings {
+		if d.Target != "" && failure(d) {
+			findings = append(findings, d)
+		}
+	}
+	run.Findings = findings
+	line, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return s.compact()
+}
+
+// compact drops the oldest runs beyond Keep once the file has twice as
+// many, replacing the file so it's never left half written.
+func (s *Store) compact() error {
+	if s.Keep <= 0 {
+		return nil
+	}
+	if n, err := s.lines(); err != nil || n <= 2*s.Keep {
+		return err
+	}
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	lines := strings.SplitAfter(strings.TrimRight(string(data), "\n"), "\n")
+	kept := strings.Join(lines[len(lines)-s.Keep:], "") + "\n"
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.WriteString(kept); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// lines counts the lines of the file without parsing them.
+func (s *Store) lines() (int, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	n := 0
+	buf := make([]byte, 64*1024)
+	for {
+		read, err := f.Read(buf)
+		n += bytes.Count(buf[:read], []byte{'\n'})
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// Runs returns the latest Keep recorded runs, oldest first. A store that doesn't exist
+// yet has none.
+func (s *Store) Runs() ([]Run, error) {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	runs := make([]Run, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", s.path, line, err)
+		}
+		runs = append(runs, run)
+	}
+	if s.Keep > 0 && len(runs) > s.Keep {
+		runs = runs[len(runs)-s.Keep:]
+	}
+	return runs, scanner.Err()
+}
+
+// Status is how an item fared in a run.
+type Status struct {
+	Time    time.Time
+	Failing bool
+	// Finding is what the run reported about the item, if it was failing
+	Finding *diagnostics.Diagnostic
+}
+
+// failure reports whether d is a url or ref failing, whatever severity
+// --warn-on or flakiness gave it, rather than a warning about one that works,
+// like a deprecated url or a certificate that's only expiring.
+func failure(d diagnostics.Diagnostic) bool {
+	switch d.Category {
+	case diagnostics.Link, diagnostics.Certificate:
+		return d.Reason != diagnostics.Skipped && d.Reason != diagnostics.CertExpiring
+	case diagnostics.Ref, diagnostics.Role:
+		return d.Reason == diagnostics.UnknownRef || d.Reason == diagnostics.UnknownRole
+	case diagnostics.Doc:
+		return true
+	}
+	return false
+}
+
+// Timeline is how item, a url or ref target, fared in each run that checked
+// it, oldest first. A url is passing in a run that checked it without
+// reporting it, and a ref in any run that checked every file without
+// reporting it.
+func Timeline(runs []Run, item string) []Status {
+	timeline := make([]Status, 0, len(runs))
+	for _, run := range runs {
+		status := Status{Time: run.Time}
+		for i, d := range run.Findings {
+			if d.Target == item && failure(d) {
+				status.Failing, status.Finding = true, &run.Findings[i]
+				break
+			}
+		}
+		checked := contains(run.Checked, item) || (!run.Scoped && !strings.Contains(item, "://"))
+		if !status.Failing && !checked {
+			continue
+		}
+		timeline = append(timeline, status)
+	}
+	return timeline
+}
+
+// Since returns when the latest streak of timeline started: the first of the
+// run of statuses at its end that are all failing, or all passing.
+func Since(timeline []Status) (Status, int) {
+	if len(timeline) == 0 {
+		return Status{}, 0
+	}
+	last := len(timeline) - 1
+	start := last
+	for start > 0 && timeline[start-1].Failing == timeline[last].Failing {
+		start--
+	}
+	return timeline[start], len(timeline) - start
+}
+
+const (
+	// flakyWindow is how many of a url's latest runs are looked at to tell
+	// whether it's flaky
+	flakyWindow = 10
+	// flakyFlips is how many times a url has to go from passing to failing
+	// or back in those runs to be flaky
+	flakyFlips = 2
+)
+
+// Flaky returns the urls that alternated between passing and failing in the
+// runs that checked them recently.
+func Flaky(runs []Run) map[string]bool {
+	urls := make(map[string]bool)
+	for _, run := range runs {
+		for _, u := range run.Checked {
+			urls[u] = true
+		}
+	}
+	flaky := make(map[string]bool)
+	for u := range urls {
+		timeline := Timeline(runs, u)
+		if len(timeline) > flakyWindow {
+			timeline = timeline[len(timeline)-flakyWindow:]
+		}
+		flips := 0
+		for i := 1; i < len(timeline); i++ {
+			if timeline[i].Failing != timeline[i-1].Failing {
+				flips++
+			}
+		}
+		if flips >= flakyFlips {
+			flaky[u] = true
+		}
+	}
+	return flaky
+}
+
+func contains(s []string, e string) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
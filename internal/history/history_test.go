@@ -0,0 +1,138 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+func TestStore(t *testing.T) {
+	store := Open(filepath.Join(t.TempDir(), "history", "runs.jsonl"))
+	runs, err := store.Runs()
+	assert.NoError(t, err)
+	assert.Empty(t, runs)
+
+	first := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	gone := diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "gone").WithTarget("https://example.com")
+	assert.NoError(t, store.Record(Run{Time: first, Checked: []string{"https://example.com"}}))
+	assert.NoError(t, store.Record(Run{Time: first.Add(time.Hour), Checked: []string{"https://example.com"}, Findings: []diagnostics.Diagnostic{gone}}))
+
+	runs, err = store.Runs()
+	assert.NoError(t, err)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, first, runs[0].Time)
+	assert.Equal(t, []diagnostics.Diagnostic{gone}, runs[1].Findings)
+}
+
+func TestTimeline(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, 1, d, 0, 0, 0, 0, time.UTC) }
+	gone := diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "gone").WithTarget("https://example.com")
+	interpreted := diagnostics.Warnf(diagnostics.Link, "/source/index.txt", "interpeted url https://example.com from :issue: was not valid").WithTarget("https://example.com")
+	// a page under the url, or a warning about a url that works, isn't it failing
+	page := diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "gone").WithTarget("https://example.com/page")
+	deprecated := diagnostics.Warnf(diagnostics.Deprecated, "/source/index.txt", "deprecated").WithTarget("https://example.com")
+	checked := []string{"https://example.com"}
+	runs := []Run{
+		{Time: day(1), Checked: checked},
+		{Time: day(2), Checked: checked, Findings: []diagnostics.Diagnostic{gone}},
+		{Time: day(3), Checked: checked, Findings: []diagnostics.Diagnostic{page, deprecated}},
+		// a run that didn't check it says nothing about it
+		{Time: day(4)},
+		{Time: day(5), Checked: checked, Findings: []diagnostics.Diagnostic{gone}},
+		{Time: day(6), Checked: checked, Findings: []diagnostics.Diagnostic{interpreted}},
+	}
+	timeline := Timeline(runs, "https://example.com")
+	var failing []bool
+	for _, status := range timeline {
+		failing = append(failing, status.Failing)
+	}
+	assert.Equal(t, []bool{false, true, false, true, true}, failing)
+
+	since, streak := Since(timeline)
+	assert.Equal(t, day(5), since.Time)
+	assert.Equal(t, 2, streak)
+	assert.Equal(t, gone, *since.Finding)
+
+	_, streak = Since(nil)
+	assert.Equal(t, 0, streak)
+}
+
+func TestTimelineRef(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, 1, d, 0, 0, 0, 0, time.UTC) }
+	nope := diagnostics.Errorf(diagnostics.Ref, "/source/index.txt", "nope is not a valid ref").WithTarget("nope").WithReason(diagnostics.UnknownRef)
+	runs := []Run{
+		{Time: day(1)},
+		// a scoped run that didn't report it may not have checked it
+		{Time: day(2), Scoped: true},
+		{Time: day(3), Scoped: true, Findings: []diagnostics.Diagnostic{nope}},
+	}
+	timeline := Timeline(runs, "nope")
+	assert.Len(t, timeline, 2)
+	assert.False(t, timeline[0].Failing)
+	assert.True(t, timeline[1].Failing)
+}
+
+func TestFlaky(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, 1, d, 0, 0, 0, 0, time.UTC) }
+	checked := []string{"https://flaky.example.com", "https://gone.example.com", "https://fine.example.com"}
+	failing := func(u string) diagnostics.Diagnostic {
+		return diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "failed").WithTarget(u)
+	}
+	runs := []Run{
+		{Time: day(1), Checked: checked},
+		{Time: day(2), Checked: checked, Findings: []diagnostics.Diagnostic{failing("https://flaky.example.com"), failing("https://gone.example.com")}},
+		{Time: day(3), Checked: checked, Findings: []diagnostics.Diagnostic{failing("https://gone.example.com")}},
+		{Time: day(4), Checked: checked, Findings: []diagnostics.Diagnostic{failing("https://gone.example.com")}},
+	}
+	assert.Equal(t, map[string]bool{"https://flaky.example.com": true}, Flaky(runs))
+
+	// a flaky url's failures are reported as warnings, but still failures
+	runs = append(runs, Run{Time: day(5), Checked: checked, Findings: []diagnostics.Diagnostic{failing("https://flaky.example.com").WithReason(diagnostics.Flaky)}})
+	runs[len(runs)-1].Findings[0].Severity = diagnostics.Warning
+	assert.True(t, Timeline(runs, "https://flaky.example.com")[4].Failing)
+}
+
+func TestStoreKeepsLatestRuns(t *testing.T) {
+	store := Open(filepath.Join(t.TempDir(), "runs.jsonl"))
+	store.Keep = 2
+	first := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, store.Record(Run{Time: first.Add(time.Duration(i) * time.Hour)}))
+	}
+
+	runs, err := store.Runs()
+	assert.NoError(t, err)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, first.Add(2*time.Hour), runs[0].Time)
+	assert.Equal(t, first.Add(3*time.Hour), runs[1].Time)
+
+	// the file is only rewritten once it has twice as many runs as it keeps
+	lines, err := store.lines()
+	assert.NoError(t, err)
+	assert.Equal(t, 4, lines)
+	assert.NoError(t, store.Record(Run{Time: first.Add(4 * time.Hour)}))
+	lines, err = store.lines()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, lines)
+	runs, err = store.Runs()
+	assert.NoError(t, err)
+	assert.Equal(t, first.Add(4*time.Hour), runs[1].Time)
+}
+
+func TestStoreKeepsOnlyFailures(t *testing.T) {
+	store := Open(filepath.Join(t.TempDir(), "runs.jsonl"))
+	gone := diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "gone").WithTarget("https://example.com")
+	found := []diagnostics.Diagnostic{
+		gone,
+		diagnostics.Warnf(diagnostics.Syntax, "/source/index.txt", "unclosed backtick"),
+		diagnostics.Warnf(diagnostics.Deprecated, "/source/index.txt", "deprecated").WithTarget("https://example.org"),
+	}
+	assert.NoError(t, store.Record(Run{Findings: found}))
+
+	runs, err := store.Runs()
+	assert.NoError(t, err)
+	assert.Equal(t, []diagnostics.Diagnostic{gone}, runs[0].Findings)
+}
@@ -0,0 +1,184 @@
+// Package httpdebug captures the metadata of checker's network requests, to
+// log them and to export them as a HAR file, so it's possible to show a site's
+// owner exactly what checker asked for and what it got back.
+package httpdebug
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+// Recorder records every request that goes through its Transport. It's safe
+// for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Entry is a request and its response, in the HAR 1.2 format. Bodies aren't
+// recorded.
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	// Time is how long the request took, in milliseconds
+	Time     float64  `json:"time"`
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+	Cache    struct{} `json:"cache"`
+	Timings  Timings  `json:"timings"`
+	// Error is why there's no response, if the request failed
+	Error string `json:"_error,omitempty"`
+}
+
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type Request struct {
+	Method      string   `json:"method"`
+	URL         string   `json:"url"`
+	HTTPVersion string   `json:"httpVersion"`
+	Headers     []Header `json:"headers"`
+	QueryString []Header `json:"queryString"`
+	Cookies     []Header `json:"cookies"`
+	HeadersSize int      `json:"headersSize"`
+	BodySize    int      `json:"bodySize"`
+}
+
+type Content struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type Response struct {
+	Status      int      `json:"status"`
+	StatusText  string   `json:"statusText"`
+	HTTPVersion string   `json:"httpVersion"`
+	Headers     []Header `json:"headers"`
+	Cookies     []Header `json:"cookies"`
+	Content     Content  `json:"content"`
+	RedirectURL string   `json:"redirectURL"`
+	HeadersSize int      `json:"headersSize"`
+	BodySize    int      `json:"bodySize"`
+}
+
+type Timings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// redacted are headers whose values are secret, so they're left out of HARs,
+// which get attached to bug reports.
+var redacted = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+func headers(h http.Header) []Header {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	list := make([]Header, 0, len(h))
+	for _, name := range names {
+		for _, value := range h[name] {
+			if redacted[name] {
+				value = "REDACTED"
+			}
+			list = append(list, Header{Name: name, Value: value})
+		}
+	}
+	return list
+}
+
+// Transport wraps next, recording and logging every request.
+func (r *Recorder) Transport(next http.RoundTripper) http.RoundTripper {
+	return utils.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		elapsed := time.Since(start)
+		ms := float64(elapsed) / float64(time.Millisecond)
+
+		entry := Entry{
+			StartedDateTime: start,
+			Time:            ms,
+			Request: Request{
+				Method:      req.Method,
+				URL:         req.URL.String(),
+				HTTPVersion: req.Proto,
+				Headers:     headers(req.Header),
+				QueryString: headers(http.Header(req.URL.Query())),
+				Cookies:     []Header{},
+				HeadersSize: -1,
+				BodySize:    -1,
+			},
+			Response: Response{Headers: []Header{}, Cookies: []Header{}, HeadersSize: -1, BodySize: -1},
+			Timings:  Timings{Send: 0, Wait: ms, Receive: 0},
+		}
+		if err != nil {
+			entry.Error = err.Error()
+			log.Infof("http: %s %s failed after %s: %v", req.Method, req.URL, elapsed.Round(time.Millisecond), err)
+		} else {
+			entry.Response.Status = resp.StatusCode
+			entry.Response.StatusText = http.StatusText(resp.StatusCode)
+			entry.Response.HTTPVersion = resp.Proto
+			entry.Response.Headers = headers(resp.Header)
+			entry.Response.Content = Content{Size: resp.ContentLength, MimeType: resp.Header.Get("Content-Type")}
+			entry.Response.RedirectURL = resp.Header.Get("Location")
+			log.Infof("http: %s %s -> %d in %s", req.Method, req.URL, resp.StatusCode, elapsed.Round(time.Millisecond))
+		}
+
+		r.mu.Lock()
+		r.entries = append(r.entries, entry)
+		r.mu.Unlock()
+		return resp, err
+	})
+}
+
+// Entries returns what was recorded, in the order requests started.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]Entry, len(r.entries))
+	copy(entries, r.entries)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedDateTime.Before(entries[j].StartedDateTime)
+	})
+	return entries
+}
+
+// HAR encodes what was recorded as a HAR 1.2 archive.
+func (r *Recorder) HAR(version string) ([]byte, error) {
+	type creator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	type harLog struct {
+		Version string  `json:"version"`
+		Creator creator `json:"creator"`
+		Entries []Entry `json:"entries"`
+	}
+	return json.MarshalIndent(struct {
+		Log harLog `json:"log"`
+	}{harLog{Version: "1.2", Creator: creator{Name: "checker", Version: version}, Entries: r.Entries()}}, "", "  ")
+}
+
+// WriteHAR writes what was recorded to filename as a HAR 1.2 archive.
+func (r *Recorder) WriteHAR(filename string, version string) error {
+	data, err := r.HAR(version)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
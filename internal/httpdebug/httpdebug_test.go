@@ -0,0 +1,66 @@
+package httpdebug
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Location", "/new")
+		w.WriteHeader(http.StatusMovedPermanently)
+	}))
+	defer server.Close()
+
+	r := &Recorder{}
+	client := &http.Client{
+		Transport: r.Transport(http.DefaultTransport),
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/old?a=1", nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	req.Header.Set("Authorization", "token secret")
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	_, err = client.Get("http://127.0.0.1:0/")
+	assert.Error(t, err)
+
+	entries := r.Entries()
+	assert.Len(t, entries, 2)
+	assert.Equal(t, server.URL+"/old?a=1", entries[0].Request.URL)
+	assert.Contains(t, entries[0].Request.Headers, Header{Name: "User-Agent", Value: "Mozilla/5.0"})
+	assert.Contains(t, entries[0].Request.Headers, Header{Name: "Authorization", Value: "REDACTED"})
+	assert.Equal(t, []Header{{Name: "a", Value: "1"}}, entries[0].Request.QueryString)
+	assert.Equal(t, 301, entries[0].Response.Status)
+	assert.Equal(t, "Moved Permanently", entries[0].Response.StatusText)
+	assert.Equal(t, "/new", entries[0].Response.RedirectURL)
+	assert.Equal(t, "text/html", entries[0].Response.Content.MimeType)
+	assert.Empty(t, entries[0].Error)
+	assert.NotEmpty(t, entries[1].Error)
+	assert.Equal(t, 0, entries[1].Response.Status)
+}
+
+func TestWriteHAR(t *testing.T) {
+	r := &Recorder{}
+	filename := filepath.Join(t.TempDir(), "checker.har")
+
+	assert.NoError(t, r.WriteHAR(filename, "1.0.0"))
+
+	data, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	var har map[string]map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &har))
+	assert.Equal(t, "1.2", har["log"]["version"])
+	assert.Equal(t, map[string]interface{}{"name": "checker", "version": "1.0.0"}, har["log"]["creator"])
+	assert.Equal(t, []interface{}{}, har["log"]["entries"])
+}
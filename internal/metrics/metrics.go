@@ -0,0 +1,187 @@
+// Package metrics records how a run went in the Prometheus text format, for a
+// node_exporter textfile collector or a Pushgateway.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+// Buckets are the upper bounds, in seconds, of the request latency histogram.
+var Buckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// pushClient gives up on a Pushgateway that doesn't answer, so a finished run
+// doesn't hang pushing its metrics.
+var pushClient = &http.Client{Timeout: 30 * time.Second}
+
+type histogram struct {
+	counts []int
+	sum    float64
+	count  int
+}
+
+// Recorder collects metrics for a run. It's safe for concurrent use.
+type Recorder struct {
+	mu       sync.Mutex
+	started  time.Time
+	duration time.Duration
+	requests map[string]*histogram
+	found    map[[2]string]int
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		started:  time.Now(),
+		requests: make(map[string]*histogram),
+		found:    make(map[[2]string]int),
+	}
+}
+
+// Observe records a request to host that took elapsed.
+func (r *Recorder) Observe(host string, elapsed time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h, ok := r.requests[host]
+	if !ok {
+		h = &histogram{counts: make([]int, len(Buckets))}
+		r.requests[host] = h
+	}
+	seconds := elapsed.Seconds()
+	for i, le := range Buckets {
+		if seconds <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// Transport wraps next, observing the latency of every request.
+func (r *Recorder) Transport(next http.RoundTripper) http.RoundTripper {
+	return utils.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next.RoundTrip(req)
+		r.Observe(req.URL.Hostname(), time.Since(start))
+		return resp, err
+	})
+}
+
+// Finish records the diagnostics the run found and how long it took.
+func (r *Recorder) Finish(found []diagnostics.Diagnostic) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.duration = time.Since(r.started)
+	for _, d := range found {
+		r.found[[2]string{string(d.Category), string(d.Severity)}]++
+	}
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var b bytes.Buffer
+
+	hosts := make([]string, 0, len(r.requests))
+	for host := range r.requests {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	b.WriteString("# HELP checker_http_requests_total Network requests made while checking, by host.\n")
+	b.WriteString("# TYPE checker_http_requests_total counter\n")
+	for _, host := range hosts {
+		fmt.Fprintf(&b, "checker_http_requests_total{host=%q} %d\n", host, r.requests[host].count)
+	}
+
+	b.WriteString("# HELP checker_http_request_duration_seconds Latency of network requests, by host.\n")
+	b.WriteString("# TYPE checker_http_request_duration_seconds histogram\n")
+	for _, host := range hosts {
+		h := r.requests[host]
+		for i, le := range Buckets {
+			fmt.Fprintf(&b, "checker_http_request_duration_seconds_bucket{host=%q,le=\"%g\"} %d\n", host, le, h.counts[i])
+		}
+		fmt.Fprintf(&b, "checker_http_request_duration_seconds_bucket{host=%q,le=\"+Inf\"} %d\n", host, h.count)
+		fmt.Fprintf(&b, "checker_http_request_duration_seconds_sum{host=%q} %g\n", host, h.sum)
+		fmt.Fprintf(&b, "checker_http_request_duration_seconds_count{host=%q} %d\n", host, h.count)
+	}
+
+	keys := make([][2]string, 0, len(r.found))
+	for key := range r.found {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0]+keys[i][1] < keys[j][0]+keys[j][1]
+	})
+	b.WriteString("# HELP checker_diagnostics Problems found by the last run, by category and severity.\n")
+	b.WriteString("# TYPE checker_diagnostics gauge\n")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "checker_diagnostics{category=%q,severity=%q} %d\n", key[0], key[1], r.found[key])
+	}
+
+	b.WriteString("# HELP checker_run_duration_seconds How long the last run took.\n")
+	b.WriteString("# TYPE checker_run_duration_seconds gauge\n")
+	fmt.Fprintf(&b, "checker_run_duration_seconds %g\n", r.duration.Seconds())
+	b.WriteString("# HELP checker_last_run_timestamp_seconds When the last run started.\n")
+	b.WriteString("# TYPE checker_last_run_timestamp_seconds gauge\n")
+	fmt.Fprintf(&b, "checker_last_run_timestamp_seconds %d\n", r.started.Unix())
+
+	return b.WriteTo(w)
+}
+
+// WriteFile writes the metrics to filename for a textfile collector. The file
+// is replaced atomically, so the collector never reads a partial file.
+func (r *Recorder) WriteFile(filename string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := r.WriteTo(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
+
+// Push replaces the metrics grouped under job on the Pushgateway at gateway.
+func (r *Recorder) Push(gateway string, job string) error {
+	var b bytes.Buffer
+	if _, err := r.WriteTo(&b); err != nil {
+		return err
+	}
+	url := strings.TrimSuffix(gateway, "/") + "/metrics/job/" + neturl.PathEscape(job)
+	req, err := http.NewRequest(http.MethodPut, url, &b)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+	resp, err := pushClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returned a status of %d", url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+func testRecorder() *Recorder {
+	r := NewRecorder()
+	r.started = time.Unix(1600000000, 0)
+	r.Observe("example.com", 200*time.Millisecond)
+	r.Observe("example.com", 3*time.Second)
+	r.Finish([]diagnostics.Diagnostic{
+		diagnostics.Errorf(diagnostics.Link, "/source/index.txt", "broken"),
+		diagnostics.Errorf(diagnostics.Link, "/source/faq.txt", "broken"),
+		diagnostics.Warnf(diagnostics.Deprecated, "/source/faq.txt", "deprecated"),
+	})
+	r.duration = 90 * time.Second
+	return r
+}
+
+func TestWriteTo(t *testing.T) {
+	var b bytes.Buffer
+	_, err := testRecorder().WriteTo(&b)
+	assert.NoError(t, err)
+
+	for _, line := range []string{
+		`checker_http_requests_total{host="example.com"} 2`,
+		`checker_http_request_duration_seconds_bucket{host="example.com",le="0.1"} 0`,
+		`checker_http_request_duration_seconds_bucket{host="example.com",le="0.25"} 1`,
+		`checker_http_request_duration_seconds_bucket{host="example.com",le="5"} 2`,
+		`checker_http_request_duration_seconds_bucket{host="example.com",le="+Inf"} 2`,
+		`checker_http_request_duration_seconds_sum{host="example.com"} 3.2`,
+		`checker_http_request_duration_seconds_count{host="example.com"} 2`,
+		`checker_diagnostics{category="deprecated",severity="warning"} 1`,
+		`checker_diagnostics{category="link",severity="error"} 2`,
+		`checker_run_duration_seconds 90`,
+		`checker_last_run_timestamp_seconds 1600000000`,
+	} {
+		assert.Contains(t, strings.Split(b.String(), "\n"), line)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	r := NewRecorder()
+	client := &http.Client{Transport: r.Transport(http.DefaultTransport)}
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, 1, r.requests["127.0.0.1"].count)
+}
+
+func TestWriteFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "checker.prom")
+
+	assert.NoError(t, testRecorder().WriteFile(filename))
+
+	data, err := ioutil.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "checker_run_duration_seconds 90\n")
+}
+
+func TestPush(t *testing.T) {
+	var method, path, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, testRecorder().Push(server.URL+"/", "docs-node"))
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/docs-node", path)
+	assert.Contains(t, body, "checker_run_duration_seconds 90\n")
+}
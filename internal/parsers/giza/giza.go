@@ -0,0 +1,66 @@
+package giza
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Content returns every string value found in the giza file, a yaml stream of
+// one document per step or extract, each starting on the line it starts on in
+// input, so the rst parsers can scan them without tripping over yaml quoting
+// and folding, and still report the right line.
+func Content(input []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	line := 1
+	dec := yaml.NewDecoder(bytes.NewReader(input))
+	for {
+		var node yaml.Node
+		err := dec.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		collectStrings(&node, &buf, &line)
+	}
+	return buf.Bytes(), nil
+}
+
+// collectStrings writes the string values in node to buf, padding it with
+// empty lines to the line each starts on. line is the line buf is on. A value
+// that's folded onto fewer lines than it takes in the yaml only moves what's
+// after it in the same value.
+func collectStrings(node *yaml.Node, buf *bytes.Buffer, line *int) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.ShortTag() != "!!str" {
+			return
+		}
+		start := node.Line
+		// a block scalar starts on the line after its | or >
+		if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			start++
+		}
+		for ; *line < start; *line++ {
+			buf.WriteByte('\n')
+		}
+		value := strings.TrimSuffix(node.Value, "\n")
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		*line += strings.Count(value, "\n") + 1
+	case yaml.MappingNode:
+		// keys are yaml, only values are rst
+		for i := 1; i < len(node.Content); i += 2 {
+			collectStrings(node.Content[i], buf, line)
+		}
+	default:
+		for _, child := range node.Content {
+			collectStrings(child, buf, line)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package giza
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const steps = `title: Import the public key used by the package management system.
+stepnum: 1
+ref: import-key
+action:
+  pre: |
+    From a terminal, issue the following command. See
+    :ref:` + "`install-mdb-community-ubuntu`" + ` for details.
+  language: sh
+  code: |
+    wget -qO - https://www.mongodb.org/static/pgp/server-5.0.asc | sudo apt-key add -
+---
+title: Create a list file for MongoDB.
+stepnum: 2
+ref: create-list
+content: "Refer to the :manual:` + "`release notes </release-notes>`" + `."
+...
+`
+
+func TestContentInvalid(t *testing.T) {
+	_, err := Content([]byte("title: [unclosed"))
+	assert.Error(t, err)
+}
+
+func TestContent(t *testing.T) {
+	content, err := Content([]byte(steps))
+	assert.NoError(t, err)
+
+	for _, expected := range []string{
+		"Import the public key used by the package management system.\n",
+		":ref:`install-mdb-community-ubuntu` for details.\n",
+		"https://www.mongodb.org/static/pgp/server-5.0.asc",
+		"Refer to the :manual:`release notes </release-notes>`.\n",
+	} {
+		assert.Contains(t, string(content), expected)
+	}
+	assert.NotContains(t, string(content), "stepnum")
+}
+
+func TestContentKeepsLines(t *testing.T) {
+	content, err := Content([]byte(steps))
+	assert.NoError(t, err)
+
+	lines := strings.Split(string(content), "\n")
+	assert.Equal(t, "Import the public key used by the package management system.", lines[0])
+	assert.Equal(t, ":ref:`install-mdb-community-ubuntu` for details.", lines[6])
+	assert.Contains(t, lines[9], "https://www.mongodb.org/static/pgp/server-5.0.asc")
+	assert.Equal(t, "Refer to the :manual:`release notes </release-notes>`.", lines[14])
+}
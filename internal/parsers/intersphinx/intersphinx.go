@@ -4,15 +4,116 @@ import (
 	"bytes"
 	"compress/zlib"
 	"io/ioutil"
+	"regexp"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/unicode/norm"
 )
 
-type SphinxMap map[string]bool
+const versionPrefix = "# Sphinx inventory version "
 
+// entryRegex matches an inventory entry: its name, which can contain spaces,
+// object type like std:label, priority, uri, and display name.
+var entryRegex = regexp.MustCompile(`^(.+?)\s+(\S+:\S+)\s+(-?\d+)\s+(\S*)\s+(.*)$`)
+
+// SphinxMap maps the names in intersphinx inventories to their object types,
+// like std:label or std:doc. A name can have several types.
+type SphinxMap map[string]map[string]bool
+
+// Resolves reports whether name is an inventory entry a role can reference:
+// a std:label for :ref:, a std:doc for :doc:, anything in a domain for that
+// domain's roles, like py:meth, and otherwise a type named like the role in
+// any domain, like std:term for :term: or mongodb:setting for :setting:.
+func (s SphinxMap) Resolves(role string, name string) bool {
+	types := s[name]
+	switch {
+	case role == "ref":
+		return types["std:label"]
+	case strings.Contains(role, ":"):
+		domain := strings.SplitN(role, ":", 2)[0] + ":"
+		for objectType := range types {
+			if strings.HasPrefix(objectType, domain) {
+				return true
+			}
+		}
+		return false
+	default:
+		for objectType := range types {
+			if strings.HasSuffix(objectType, ":"+role) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HasKind reports whether any entry is an object of kind, like method, in any
+// domain.
+func (s SphinxMap) HasKind(kind string) bool {
+	for _, types := range s {
+		for objectType := range types {
+			if strings.HasSuffix(objectType, ":"+kind) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s SphinxMap) add(name string, objectType string) {
+	// in normal form C, like the ref targets they're compared to
+	name = norm.NFC.String(name)
+	if s[name] == nil {
+		s[name] = make(map[string]bool)
+	}
+	s[name][objectType] = true
+}
+
+// Intersphinx parses a Sphinx objects.inv fetched from domain. Version 2
+// inventories are zlib compressed; older version 1 ones are plain text.
 func Intersphinx(buff []byte, domain string) SphinxMap {
+	firstLine := string(buff)
+	if i := strings.IndexByte(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+	version := strings.TrimPrefix(strings.TrimSpace(firstLine), versionPrefix)
+	switch {
+	case !strings.HasPrefix(firstLine, versionPrefix):
+		log.Warnf("%sobjects.inv isn't a Sphinx inventory, it doesn't start with %q", domain, versionPrefix)
+		return nil
+	case version == "1":
+		return parseV1(buff)
+	case version == "2":
+		return parseV2(buff)
+	default:
+		log.Errorf("%sobjects.inv is Sphinx inventory version %s, but only versions 1 and 2 are supported", domain, version)
+		return nil
+	}
+}
+
+// parseV1 parses a version 1 inventory, whose entries are all Python objects:
+// "name type location" lines after a two line project and version header.
+func parseV1(buff []byte) SphinxMap {
+	res := make(SphinxMap)
+	for _, line := range strings.Split(string(buff), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		objectType := "py:" + fields[1]
+		if fields[1] == "mod" {
+			objectType = "py:module"
+		}
+		res.add(fields[0], objectType)
+	}
+	return res
+}
 
+func parseV2(buff []byte) SphinxMap {
 	markerLine := "# The remainder of this file is compressed using zlib.\n"
 	cut := bytes.Index(buff, []byte(markerLine)) + len(markerLine)
 	if cut < len(markerLine) {
@@ -39,14 +140,14 @@ func Intersphinx(buff []byte, domain string) SphinxMap {
 		return nil
 	}
 
-	res := make(map[string]bool)
+	res := make(SphinxMap)
 
 	for _, line := range strings.Split(string(parsed), "\n") {
-		if len(line) == 0 {
+		entry := entryRegex.FindStringSubmatch(line)
+		if entry == nil {
 			continue
 		}
-		lineSplit := strings.Split(line, " ")
-		res[lineSplit[0]] = true
+		res.add(entry[1], entry[2])
 	}
 	return res
 }
@@ -54,8 +155,10 @@ func Intersphinx(buff []byte, domain string) SphinxMap {
 func JoinSphinxes(input []SphinxMap) SphinxMap {
 	refMap := make(SphinxMap)
 	for _, m := range input {
-		for k, v := range m {
-			refMap[k] = v
+		for name, types := range m {
+			for objectType := range types {
+				refMap.add(name, objectType)
+			}
 		}
 	}
 	return refMap
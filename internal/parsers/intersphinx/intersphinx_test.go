@@ -84,10 +84,10 @@ usage-examples std:doc -1 usage-examples/ Usage Examples`)
 	resp := Intersphinx(append(header, b.Bytes()...), "https://test.com/")
 
 	expected := SphinxMap{
-		"whats-new":      true,
-		"compatibility":  true,
-		"fundamentals":   true,
-		"usage-examples": true,
+		"whats-new":      {"std:doc": true},
+		"compatibility":  {"std:doc": true},
+		"fundamentals":   {"std:doc": true},
+		"usage-examples": {"std:doc": true},
 	}
 
 	assert.EqualValues(t, expected, resp, "Expected %v, got %v", expected, resp)
@@ -96,29 +96,102 @@ usage-examples std:doc -1 usage-examples/ Usage Examples`)
 func TestJoinSphinxes(t *testing.T) {
 	input := []SphinxMap{
 		{
-			"whats-new":      true,
-			"compatibility":  true,
-			"fundamentals":   true,
-			"usage-examples": true,
+			"whats-new":     {"std:doc": true},
+			"compatibility": {"std:doc": true},
 		}, {
-			"foowhats-new":      true,
-			"foocompatibility":  true,
-			"foofundamentals":   true,
-			"foousage-examples": true,
+			"foowhats-new":  {"std:label": true},
+			"compatibility": {"std:label": true},
 		}}
+	expected := SphinxMap{
+		"whats-new":     {"std:doc": true},
+		"compatibility": {"std:doc": true, "std:label": true},
+		"foowhats-new":  {"std:label": true},
+	}
+	actual := JoinSphinxes(input)
+	assert.EqualValues(t, expected, actual, "expected %v, got %v", expected, actual)
+}
+
+func TestEntryTypes(t *testing.T) {
+	logrus.SetOutput(ioutil.Discard)
+	header := []byte(`# Sphinx inventory version 2
+# Project: pymongo
+# Version:
+# The remainder of this file is compressed using zlib.
+`)
+	zText := []byte(`install std:doc -1 install.html Installing
+install std:label -1 install.html#install Installing
+faq std:doc -1 faq.html FAQ
+read preference std:term -1 glossary.html#term-read-preference -
+pymongo.collection.Collection.find py:method 1 api/collection.html#$ -
+pymongo.collection.Collection py:class 1 api/collection.html#$ -
+net.port mongodb:setting -1 reference/configuration-options.html#$ -`)
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	if _, err := w.Write(zText); err != nil {
+		log.Fatal(err)
+	}
+	w.Close()
+	resp := Intersphinx(append(header, b.Bytes()...), "https://test.com/")
+
+	cases := []struct {
+		role     string
+		name     string
+		resolves bool
+	}{
+		{role: "ref", name: "install", resolves: true},
+		{role: "doc", name: "install", resolves: true},
+		{role: "ref", name: "faq", resolves: false},
+		{role: "doc", name: "faq", resolves: true},
+		{role: "term", name: "read preference", resolves: true},
+		{role: "ref", name: "read preference", resolves: false},
+		{role: "py:meth", name: "pymongo.collection.Collection.find", resolves: true},
+		{role: "py:class", name: "pymongo.collection.Collection", resolves: true},
+		{role: "java:method", name: "pymongo.collection.Collection.find", resolves: false},
+		{role: "ref", name: "pymongo.collection.Collection", resolves: false},
+		{role: "ref", name: "missing", resolves: false},
+		{role: "setting", name: "net.port", resolves: true},
+		{role: "option", name: "net.port", resolves: false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.resolves, resp.Resolves(c.role, c.name), "Resolves(%q, %q)", c.role, c.name)
+	}
+	assert.True(t, resp.HasKind("setting"))
+	assert.True(t, resp.HasKind("method"))
+	assert.False(t, resp.HasKind("dbcommand"))
+}
 
+func TestVersion1(t *testing.T) {
+	inventory := []byte(`# Sphinx inventory version 1
+# Project: pymongo
+# Version: 2.8
+pymongo mod api/pymongo/index.html
+pymongo.collection.Collection class api/pymongo/collection.html
+pymongo.collection.Collection.find method api/pymongo/collection.html
+`)
+	resp := Intersphinx(inventory, "https://test.com/")
 	expected := SphinxMap{
-		"whats-new":         true,
-		"compatibility":     true,
-		"fundamentals":      true,
-		"usage-examples":    true,
-		"foowhats-new":      true,
-		"foocompatibility":  true,
-		"foofundamentals":   true,
-		"foousage-examples": true,
+		"pymongo":                            {"py:module": true},
+		"pymongo.collection.Collection":      {"py:class": true},
+		"pymongo.collection.Collection.find": {"py:method": true},
 	}
+	assert.EqualValues(t, expected, resp)
+	assert.True(t, resp.Resolves("py:meth", "pymongo.collection.Collection.find"))
+	assert.False(t, resp.Resolves("ref", "pymongo"))
+}
 
-	actual := JoinSphinxes(input)
+func TestNormalizesNames(t *testing.T) {
+	// café with a combining accent, as some generators write it
+	inventory := []byte("# Sphinx inventory version 1\n# Project: cafe\n# Version: 1\ncafe\u0301 mod index.html\n")
+	resp := Intersphinx(inventory, "https://test.com/")
+	assert.True(t, resp.Resolves("py:mod", "caf\u00e9"))
+}
 
-	assert.EqualValues(t, expected, actual, "expected %v, got %v", expected, actual)
+func TestUnsupportedVersion(t *testing.T) {
+	var logged bytes.Buffer
+	logrus.SetOutput(&logged)
+	defer logrus.SetOutput(ioutil.Discard)
+
+	resp := Intersphinx([]byte("# Sphinx inventory version 3\n"), "https://test.com/")
+	assert.Nil(t, resp)
+	assert.Contains(t, logged.String(), "https://test.com/objects.inv is Sphinx inventory version 3")
 }
@@ -0,0 +1,81 @@
+package mdx
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	// the first group is the ! of an image, whose target isn't a doc. A
+	// link's text may be an image, like a badge.
+	markdownLinkRegex = regexp.MustCompile(`(!?)\[(?:[^\[\]]|!\[[^\]]*\]\([^)]*\))*\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	jsxLinkRegex      = regexp.MustCompile(`<(?:Link|a)\s[^>]*?\b(?:to|href)=["']([^"']+)["']`)
+	frontMatterRegex  = regexp.MustCompile(`(?s)\A---\r?\n(.*?)\r?\n---`)
+	slugRegex         = regexp.MustCompile(`(?m)^slug:\s*(.+?)\s*$`)
+)
+
+// DocRef is a reference from an MDX file to another doc, either by a relative
+// file path (./foo.mdx) or by an absolute site route (/docs/foo).
+type DocRef struct {
+	Target string
+}
+
+type FrontMatter struct {
+	Slug string
+}
+
+// IsRelative returns true if the ref points at a file relative to the referencing file.
+func (d DocRef) IsRelative() bool {
+	return !strings.HasPrefix(d.Target, "/")
+}
+
+// Path returns the target without any #anchor or ?query.
+func (d DocRef) Path() string {
+	if i := strings.IndexAny(d.Target, "#?"); i >= 0 {
+		return d.Target[:i]
+	}
+	return d.Target
+}
+
+func isDocTarget(target string) bool {
+	if strings.HasPrefix(target, "#") || strings.HasPrefix(target, "{") {
+		return false
+	}
+	if strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") || strings.HasPrefix(target, "tel:") {
+		return false
+	}
+	// anything with another extension, like /img/logo.png, is a static file
+	switch path.Ext(DocRef{Target: target}.Path()) {
+	case "", ".md", ".mdx":
+		return true
+	}
+	return false
+}
+
+func ParseForDocRefs(input []byte) []DocRef {
+	refs := make([]DocRef, 0)
+	for _, match := range markdownLinkRegex.FindAllSubmatch(input, -1) {
+		if target := string(match[2]); len(match[1]) == 0 && isDocTarget(target) {
+			refs = append(refs, DocRef{Target: target})
+		}
+	}
+	for _, match := range jsxLinkRegex.FindAllSubmatch(input, -1) {
+		if target := string(match[1]); isDocTarget(target) {
+			refs = append(refs, DocRef{Target: target})
+		}
+	}
+	return refs
+}
+
+func ParseForFrontMatter(input []byte) FrontMatter {
+	var fm FrontMatter
+	block := frontMatterRegex.FindSubmatch(input)
+	if block == nil {
+		return fm
+	}
+	if slug := slugRegex.FindSubmatch(block[1]); slug != nil {
+		fm.Slug = strings.Trim(string(slug[1]), `"'`)
+	}
+	return fm
+}
@@ -0,0 +1,95 @@
+package mdx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocRefParser(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []DocRef
+	}{{
+		input:    "",
+		expected: []DocRef{},
+	}, {
+		input:    "[external](https://www.mongodb.com) and [mail](mailto:docs@mongodb.com)",
+		expected: []DocRef{},
+	}, {
+		input:    "[anchor](#on-this-page)",
+		expected: []DocRef{},
+	}, {
+		input:    "See [the intro](./intro.mdx) and [setup](../setup/install.md#linux \"Install\")",
+		expected: []DocRef{{Target: "./intro.mdx"}, {Target: "../setup/install.md#linux"}},
+	}, {
+		input:    "<Link to=\"/docs/fundamentals/crud\">CRUD</Link>",
+		expected: []DocRef{{Target: "/docs/fundamentals/crud"}},
+	}, {
+		input:    "<a className=\"button\" href='/docs/quick-start'>Go</a> <a href=\"https://github.com\">gh</a>",
+		expected: []DocRef{{Target: "/docs/quick-start"}},
+	}, {
+		input:    "<Link to={useBaseUrl('/docs')}>docs</Link>",
+		expected: []DocRef{},
+	}, {
+		input:    "![architecture](./img/arch.png) and [the logo](/img/logo.png)",
+		expected: []DocRef{},
+	}, {
+		input:    "<a href=\"/files/report.pdf\">report</a> [![badge](./img/badge.svg)](./intro)",
+		expected: []DocRef{{Target: "./intro"}},
+	}}
+
+	for _, test := range cases {
+		got := ParseForDocRefs([]byte(test.input))
+		assert.ElementsMatch(t, test.expected, got, "ParseForDocRefs(%q) should return %v, got %v", test.input, test.expected, got)
+	}
+}
+
+func TestDocRefPath(t *testing.T) {
+	cases := []struct {
+		input    DocRef
+		path     string
+		relative bool
+	}{{
+		input:    DocRef{Target: "./intro.mdx"},
+		path:     "./intro.mdx",
+		relative: true,
+	}, {
+		input:    DocRef{Target: "../setup/install.md#linux"},
+		path:     "../setup/install.md",
+		relative: true,
+	}, {
+		input:    DocRef{Target: "/docs/fundamentals/crud?tab=node"},
+		path:     "/docs/fundamentals/crud",
+		relative: false,
+	}}
+
+	for _, c := range cases {
+		assert.Equal(t, c.path, c.input.Path(), "%v.Path() should return %s", c.input, c.path)
+		assert.Equal(t, c.relative, c.input.IsRelative(), "%v.IsRelative() should return %v", c.input, c.relative)
+	}
+}
+
+func TestFrontMatterParser(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected FrontMatter
+	}{{
+		input:    "",
+		expected: FrontMatter{},
+	}, {
+		input:    "# No front matter\n\nslug: not-front-matter",
+		expected: FrontMatter{},
+	}, {
+		input:    "---\ntitle: Intro\nslug: /intro\n---\n# Intro",
+		expected: FrontMatter{Slug: "/intro"},
+	}, {
+		input:    "---\nslug: \"getting-started\"\nsidebar_position: 2\n---\n",
+		expected: FrontMatter{Slug: "getting-started"},
+	}}
+
+	for _, test := range cases {
+		got := ParseForFrontMatter([]byte(test.input))
+		assert.Equal(t, test.expected, got, "ParseForFrontMatter(%q) should return %v, got %v", test.input, test.expected, got)
+	}
+}
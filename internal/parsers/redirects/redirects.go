@@ -0,0 +1,136 @@
+package redirects
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	ruleRegex     = regexp.MustCompile(`^(raw|\[([^\]]*)\]):\s*(\S+)\s*->\s*(\S+)\s*$`)
+	variableRegex = regexp.MustCompile(`\$\{(\w+)\}`)
+)
+
+// Redirect is a single expanded redirect rule from a giza-style redirects file.
+type Redirect struct {
+	From    string
+	To      string
+	Version string
+	Line    int
+}
+
+// Redirects is the parsed redirects file, along with the variables it defined.
+type Redirects struct {
+	Defines   map[string]string
+	Versions  []string
+	Redirects []Redirect
+}
+
+// Parse expands a redirects file, like config/redirects, into individual
+// redirects. Supported lines are:
+//
+//	define: name value
+//	define: versions v1.0 v2.0 master
+//	raw: ${prefix}/old -> ${base}/new
+//	[v1.0-*]: ${prefix}/${version}/old -> ${base}/${version}/new
+//
+// Version ranges are [*], [vX], [vX-*], [*-vX], and [vX-vY], resolved against
+// the order of the versions define.
+func Parse(input []byte) (*Redirects, error) {
+	r := &Redirects{Defines: make(map[string]string), Redirects: make([]Redirect, 0)}
+	scanner := bufio.NewScanner(bytes.NewReader(input))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "symlink:") {
+			continue
+		}
+		if strings.HasPrefix(line, "define:") {
+			fields := strings.Fields(strings.TrimPrefix(line, "define:"))
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: define needs a name and a value", lineNo)
+			}
+			if fields[0] == "versions" {
+				r.Versions = fields[1:]
+			} else {
+				r.Defines[fields[0]] = r.expand(strings.Join(fields[1:], " "), "")
+			}
+			continue
+		}
+		matches := ruleRegex.FindStringSubmatch(line)
+		if matches == nil {
+			return nil, fmt.Errorf("line %d: could not parse %q", lineNo, line)
+		}
+		if matches[1] == "raw" {
+			r.Redirects = append(r.Redirects, Redirect{From: r.expand(matches[3], ""), To: r.expand(matches[4], ""), Line: lineNo})
+			continue
+		}
+		versions, err := r.versionRange(matches[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNo, err)
+		}
+		for _, version := range versions {
+			r.Redirects = append(r.Redirects, Redirect{
+				From:    r.expand(matches[3], version),
+				To:      r.expand(matches[4], version),
+				Version: version,
+				Line:    lineNo,
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Redirects) expand(input string, version string) string {
+	return variableRegex.ReplaceAllStringFunc(input, func(match string) string {
+		name := variableRegex.FindStringSubmatch(match)[1]
+		if name == "version" && version != "" {
+			return version
+		}
+		if val, ok := r.Defines[name]; ok {
+			return val
+		}
+		return match
+	})
+}
+
+func (r *Redirects) indexOf(version string) (int, error) {
+	for i, v := range r.Versions {
+		if v == version {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%s is not in the defined versions", version)
+}
+
+func (r *Redirects) versionRange(spec string) ([]string, error) {
+	if len(r.Versions) == 0 {
+		return nil, fmt.Errorf("version range [%s] used before versions were defined", spec)
+	}
+	start, end := 0, len(r.Versions)-1
+	bounds := strings.SplitN(spec, "-", 2)
+	if len(bounds) == 1 {
+		bounds = append(bounds, bounds[0])
+	}
+	var err error
+	if bounds[0] != "*" {
+		if start, err = r.indexOf(bounds[0]); err != nil {
+			return nil, err
+		}
+	}
+	if bounds[1] != "*" {
+		if end, err = r.indexOf(bounds[1]); err != nil {
+			return nil, err
+		}
+	}
+	if start > end {
+		return nil, fmt.Errorf("version range [%s] is empty", spec)
+	}
+	return r.Versions[start : end+1], nil
+}
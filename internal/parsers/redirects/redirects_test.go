@@ -0,0 +1,51 @@
+package redirects
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const redirectsFile = `define: prefix docs/drivers/go
+define: base https://www.mongodb.com/${prefix}
+define: versions v1.7 v1.8 master
+
+symlink: current -> master
+
+raw: ${prefix}/stable -> ${base}/current/
+[v1.8]: ${prefix}/${version}/faq -> ${base}/${version}/help/
+[v1.8-*]: ${prefix}/${version}/usage -> ${prefix}/${version}/usage-examples/
+[*-v1.8]: ${prefix}/${version}/old -> ${base}/${version}/new/
+`
+
+func TestParse(t *testing.T) {
+	r, err := Parse([]byte(redirectsFile))
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"prefix": "docs/drivers/go", "base": "https://www.mongodb.com/docs/drivers/go"}, r.Defines)
+	assert.Equal(t, []string{"v1.7", "v1.8", "master"}, r.Versions)
+
+	expected := []Redirect{
+		{From: "docs/drivers/go/stable", To: "https://www.mongodb.com/docs/drivers/go/current/", Line: 7},
+		{From: "docs/drivers/go/v1.8/faq", To: "https://www.mongodb.com/docs/drivers/go/v1.8/help/", Version: "v1.8", Line: 8},
+		{From: "docs/drivers/go/v1.8/usage", To: "docs/drivers/go/v1.8/usage-examples/", Version: "v1.8", Line: 9},
+		{From: "docs/drivers/go/master/usage", To: "docs/drivers/go/master/usage-examples/", Version: "master", Line: 9},
+		{From: "docs/drivers/go/v1.7/old", To: "https://www.mongodb.com/docs/drivers/go/v1.7/new/", Version: "v1.7", Line: 10},
+		{From: "docs/drivers/go/v1.8/old", To: "https://www.mongodb.com/docs/drivers/go/v1.8/new/", Version: "v1.8", Line: 10},
+	}
+	assert.Equal(t, expected, r.Redirects)
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []string{
+		"define: prefix",
+		"[v1.0]: a -> b",
+		"define: versions v1 v2\n[v3]: a -> b",
+		"define: versions v1 v2\n[v2-v1]: a -> b",
+		"raw: a => b",
+	}
+	for _, c := range cases {
+		_, err := Parse([]byte(c))
+		assert.Error(t, err, "Parse(%q) should fail", c)
+	}
+}
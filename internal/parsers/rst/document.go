@@ -0,0 +1,75 @@
+package rst
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+)
+
+// blockRegex matches a line opening a directive block, like ".. note::", which
+// may be a list item.
+var blockRegex = regexp.MustCompile(`^[ \t]*(?:[-*+][ \t]+)?\.\.[ \t]+([\w:.\-]+)::`)
+
+// Span is where something is in a document, from byte offset Start up to End.
+// Line and Column, both from 1, are where it starts. For yaml files they're
+// positions in the rst extracted from the yaml, not in the file.
+type Span struct {
+	Start  int
+	End    int
+	Line   int
+	Column int
+}
+
+// Block is a directive and its indented content.
+type Block struct {
+	Name string
+	Span Span
+	// Parent is the block this one is nested in, or nil at the top level
+	Parent *Block
+}
+
+// Entity is a role, link, constant, label, include, or directive found in a
+// document.
+type Entity struct {
+	Kind Kind
+	Span Span
+	// Block is the innermost block the entity is in, or nil at the top level
+	Block *Block
+	// Value is the RstRole, RstHTTPLink, RstConstant, RefTarget,
+	// SharedInclude, or RstDirective, by Kind
+	Value interface{}
+}
+
+// Document is everything found in a file, in the order it's found, with
+// where it was found.
+type Document struct {
+	Entities []Entity
+	Blocks   []*Block
+	// lines are the offsets each line starts at
+	lines []int
+}
+
+// ParseDocument lexes input like Lex does, keeping the position and
+// enclosing block of every entity.
+func ParseDocument(input []byte) *Document {
+	d := &Document{lines: []int{0}}
+	for i, b := range input {
+		if b == '\n' {
+			d.lines = append(d.lines, i+1)
+		}
+	}
+	p := &parser{
+		entity: func(e Entity) { d.Entities = append(d.Entities, e) },
+		block:  func(b *Block) { d.Blocks = append(d.Blocks, b) },
+		line:   1,
+	}
+	// reading from memory can't fail
+	_ = p.parse(bytes.NewReader(input))
+	return d
+}
+
+// Position is the line and column, from 1, of offset.
+func (d *Document) Position(offset int) (line int, column int) {
+	line = sort.Search(len(d.lines), func(i int) bool { return d.lines[i] > offset })
+	return line, offset - d.lines[line-1] + 1
+}
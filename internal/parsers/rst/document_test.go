@@ -0,0 +1,82 @@
+package rst
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const document = `.. _install:
+
+Install from https://www.mongodb.com/try today.
+
+.. note::
+
+   See :ref:` + "`install`" + `.
+
+   .. admonition:: Install
+
+      curl https://example.com/install.sh
+
+After :doc:` + "`/index`" + `.
+`
+
+func TestParseDocumentPositions(t *testing.T) {
+	d := ParseDocument([]byte(document))
+
+	kinds := make([]Kind, 0)
+	for _, e := range d.Entities {
+		kinds = append(kinds, e.Kind)
+	}
+	assert.Equal(t, []Kind{KindLocalRef, KindHTTPLink, KindRole, KindDirective, KindHTTPLink, KindRole}, kinds)
+
+	link := d.Entities[1]
+	assert.Equal(t, RstHTTPLink("https://www.mongodb.com/try"), link.Value)
+	assert.Equal(t, 3, link.Span.Line)
+	assert.Equal(t, 14, link.Span.Column)
+	assert.Equal(t, "https://www.mongodb.com/try", document[link.Span.Start:link.Span.End])
+
+	role := d.Entities[2]
+	assert.Equal(t, RstRole{Target: "install", RoleType: "ref", Name: "ref"}, role.Value)
+	assert.Equal(t, 7, role.Span.Line)
+	assert.Equal(t, 8, role.Span.Column)
+}
+
+func TestParseDocumentBlocks(t *testing.T) {
+	d := ParseDocument([]byte(document))
+
+	assert.Len(t, d.Blocks, 2)
+	note, admonition := d.Blocks[0], d.Blocks[1]
+	assert.Equal(t, "note", note.Name)
+	assert.Nil(t, note.Parent)
+	assert.Equal(t, "admonition", admonition.Name)
+	assert.Equal(t, note, admonition.Parent)
+
+	assert.Nil(t, d.Entities[0].Block, "the label isn't in a block")
+	assert.Nil(t, d.Entities[1].Block)
+	assert.Equal(t, note, d.Entities[2].Block)
+	assert.Equal(t, note, d.Entities[3].Block, "a directive is in its parent's block, not its own")
+	assert.Equal(t, admonition, d.Entities[4].Block)
+	assert.Nil(t, d.Entities[5].Block, "the note ends before the unindented line")
+}
+
+func TestParseDocumentMatchesLex(t *testing.T) {
+	e := Lex(edge)
+	roles := make([]RstRole, 0)
+	for _, entity := range ParseDocument(edge).Entities {
+		if entity.Kind == KindRole {
+			roles = append(roles, entity.Value.(RstRole))
+		}
+	}
+	assert.ElementsMatch(t, e.Roles, roles)
+}
+
+func TestPosition(t *testing.T) {
+	d := ParseDocument([]byte("ab\ncd\n\nef"))
+	line, column := d.Position(0)
+	assert.Equal(t, []int{1, 1}, []int{line, column})
+	line, column = d.Position(4)
+	assert.Equal(t, []int{2, 2}, []int{line, column})
+	line, column = d.Position(8)
+	assert.Equal(t, []int{4, 2}, []int{line, column})
+}
@@ -0,0 +1,109 @@
+package rst
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// Kind is a kind of entity found in rst.
+type Kind string
+
+const (
+	KindRole          Kind = "role"
+	KindHTTPLink      Kind = "link"
+	KindContactLink   Kind = "contact"
+	KindConstant      Kind = "constant"
+	KindLocalRef      Kind = "label"
+	KindSharedInclude Kind = "sharedinclude"
+	KindDirective     Kind = "directive"
+)
+
+// Entities is everything checker looks for in a file.
+type Entities struct {
+	Roles          []RstRole
+	HTTPLinks      []RstHTTPLink
+	ContactLinks   []RstContactLink
+	Constants      []RstConstant
+	LocalRefs      []RefTarget
+	SharedIncludes []SharedInclude
+	Directives     []RstDirective
+}
+
+// Lex finds every kind of entity in input in one pass over it, rather than
+// the one per kind the ParseFor functions take. Kinds whose markup can't be
+// in input, like roles in a file without a backtick, aren't searched for.
+func Lex(input []byte) Entities {
+	var e Entities
+	scan(input, func(kind Kind, value interface{}, _ []int) {
+		switch v := value.(type) {
+		case RstRole:
+			e.Roles = append(e.Roles, v)
+		case RstConstant:
+			e.Constants = append(e.Constants, v)
+		case RstHTTPLink:
+			e.HTTPLinks = append(e.HTTPLinks, v)
+		case RstContactLink:
+			e.ContactLinks = append(e.ContactLinks, v)
+		case RefTarget:
+			e.LocalRefs = append(e.LocalRefs, v)
+		case RstDirective:
+			e.Directives = append(e.Directives, v)
+		case SharedInclude:
+			e.SharedIncludes = append(e.SharedIncludes, v)
+		}
+	})
+	return e
+}
+
+// scan calls fn with every entity in input, and the offsets of its match, a
+// kind at a time.
+func scan(input []byte, fn func(kind Kind, value interface{}, match []int)) {
+	text := string(input)
+	if bytes.IndexByte(input, '`') >= 0 {
+		lex(text, roleRegex, func(m []string, at []int) {
+			fn(KindRole, newRole(m), at)
+		})
+		if bytes.Contains(input, []byte("{+")) {
+			lex(text, constantRegex, func(m []string, at []int) {
+				fn(KindConstant, RstConstant{Target: m[2], Name: m[1]}, at)
+			})
+		}
+	}
+	if bytes.Contains(input, []byte("http")) {
+		lex(text, httpLinkRegex, func(m []string, at []int) {
+			fn(KindHTTPLink, RstHTTPLink(m[0]), at)
+		})
+	}
+	if bytes.Contains(input, []byte("mailto:")) || bytes.Contains(input, []byte("tel:")) {
+		lex(text, contactLinkRegex, func(m []string, at []int) {
+			fn(KindContactLink, RstContactLink(m[0]), at)
+		})
+	}
+	if bytes.Contains(input, []byte("..")) {
+		lex(text, localRefRegex, func(m []string, at []int) {
+			fn(KindLocalRef, RefTarget{Name: normalize(m[1])}, at)
+		})
+		lex(text, directiveRegex, func(m []string, at []int) {
+			fn(KindDirective, RstDirective{Name: m[1], Target: m[2]}, at)
+		})
+		if bytes.Contains(input, []byte("sharedinclude::")) {
+			lex(text, sharedIncludeRegex, func(m []string, at []int) {
+				fn(KindSharedInclude, SharedInclude{Path: m[1]}, at)
+			})
+		}
+	}
+}
+
+// lex is parse without matching each match a second time for its submatches.
+// fn gets the submatches, and their offsets in text.
+func lex(text string, re *regexp.Regexp, fn func(matches []string, at []int)) {
+	for _, at := range re.FindAllStringSubmatchIndex(text, -1) {
+		matches := make([]string, len(at)/2)
+		for i := range matches {
+			if at[2*i] >= 0 {
+				matches[i] = text[at[2*i]:at[2*i+1]]
+			}
+		}
+		fn(matches, at)
+	}
+}
@@ -0,0 +1,45 @@
+package rst
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLexMatchesParsers(t *testing.T) {
+	inputs := []string{
+		"",
+		string(edge),
+		`.. _foo:
+
+See :ref:` + "`the bar <bar>`" + ` and :py:meth:` + "`~a.b.c`" + `, or https://www.mongodb.com/docs/.
+
+.. sharedinclude:: dbx/compatibility.rst
+
+.. code-block:: sh
+
+   curl https://example.com/{+version+}/install.sh
+
+Write to ` + "`the team <mailto:docs@example.com>`__" + ` or call tel:+1-212-555-0100.
+
+:ref:` + "`<{+driver+}/install>`" + `
+`,
+	}
+	for _, input := range inputs {
+		e := Lex([]byte(input))
+		assert.ElementsMatch(t, ParseForRoles([]byte(input)), e.Roles)
+		assert.ElementsMatch(t, ParseForHTTPLinks([]byte(input)), e.HTTPLinks)
+		assert.ElementsMatch(t, ParseForContactLinks([]byte(input)), e.ContactLinks)
+		assert.ElementsMatch(t, ParseForConstants([]byte(input)), e.Constants)
+		assert.ElementsMatch(t, ParseForLocalRefs([]byte(input)), e.LocalRefs)
+		assert.ElementsMatch(t, ParseForSharedIncludes([]byte(input)), e.SharedIncludes)
+		assert.ElementsMatch(t, ParseForDirectives([]byte(input)), e.Directives)
+	}
+}
+
+func TestLexSkipsAbsentMarkup(t *testing.T) {
+	e := Lex([]byte("plain prose, with no markup at all"))
+	assert.Nil(t, e.Roles)
+	assert.Nil(t, e.HTTPLinks)
+	assert.Nil(t, e.Directives)
+}
@@ -0,0 +1,146 @@
+package rst
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fieldRegex matches a field list item, like ":orphan:" or
+// ":keywords: driver, node.js", at any indent.
+var fieldRegex = regexp.MustCompile(`^([ \t]*):([\w\-.]+):(?:[ \t]+(.*?))?[ \t\r]*$`)
+
+// PageField is a field that sets something about a whole page: an option in
+// the field list a page starts with, like :orphan:, or an option of one of
+// its .. meta:: directives, like :keywords:.
+type PageField struct {
+	// Directive is "meta" for meta options, and empty for page options
+	Directive string
+	Name      string
+	Value     string
+	Span      Span
+}
+
+// PageOptions are the page options snooty reads, and whether each takes a
+// value.
+var PageOptions = map[string]bool{
+	"orphan":     false,
+	"nosearch":   false,
+	"noprevnext": false,
+	"nocomments": false,
+	"template":   true,
+	"tocdepth":   true,
+}
+
+// MetaOptions are the .. meta:: options snooty renders into the page's head,
+// unless rstspec.toml lists them.
+var MetaOptions = map[string]bool{
+	"keywords":    true,
+	"description": true,
+	"robots":      true,
+	"canonical":   true,
+}
+
+// robotsValues are the :robots: directives search engines recognize.
+var robotsValues = map[string]bool{
+	"all":          true,
+	"index":        true,
+	"noindex":      true,
+	"follow":       true,
+	"nofollow":     true,
+	"none":         true,
+	"noarchive":    true,
+	"nosnippet":    true,
+	"noimageindex": true,
+	"notranslate":  true,
+}
+
+// ParseForPageFields finds the page options in the field list input starts
+// with, and the options of its .. meta:: directives. Code is skipped.
+func ParseForPageFields(input []byte) []PageField {
+	fields := make([]PageField, 0)
+	first := true
+	p := &parser{entity: func(Entity) {}, block: func(*Block) {}, line: 1}
+	p.paragraph = func(text string, block *Block, locate func(at int) Span) {
+		atStart := first
+		first = false
+		for b := block; b != nil; b = b.Parent {
+			if literalBlocks[b.Name] {
+				return
+			}
+		}
+		lines := strings.SplitAfter(text, "\n")
+		directive := ""
+		if m := blockRegex.FindStringSubmatch(lines[0]); m != nil {
+			if m[1] != "meta" {
+				return
+			}
+			directive = "meta"
+		} else if !atStart || block != nil {
+			return
+		}
+		at := 0
+		for i, line := range lines {
+			start := at
+			at += len(line)
+			if line == "" || (directive != "" && i == 0) {
+				// options are the indented lines after the directive
+				continue
+			}
+			m := fieldRegex.FindStringSubmatchIndex(strings.TrimRight(line, "\n"))
+			if m == nil || (directive == "" && m[3] > m[2]) || (directive != "" && m[3] == m[2]) {
+				// a page's field list ends at the first line that isn't a field
+				if directive == "" {
+					return
+				}
+				continue
+			}
+			f := PageField{Directive: directive, Name: line[m[4]:m[5]], Span: locate(start + m[4] - 1)}
+			if m[6] >= 0 {
+				f.Value = line[m[6]:m[7]]
+			}
+			fields = append(fields, f)
+		}
+	}
+	// reading from memory can't fail
+	_ = p.parse(strings.NewReader(string(input)))
+	return fields
+}
+
+// Problem says what's wrong with f, or returns "" if nothing is. meta lists
+// the meta options there are, MetaOptions if it's empty.
+func (f PageField) Problem(meta map[string]bool) string {
+	if f.Directive == "" {
+		takesValue, ok := PageOptions[f.Name]
+		switch {
+		case !ok:
+			return fmt.Sprintf("page option :%s: isn't one snooty knows", f.Name)
+		case !takesValue && f.Value != "":
+			return fmt.Sprintf("page option :%s: doesn't take a value", f.Name)
+		case takesValue && f.Value == "":
+			return fmt.Sprintf("page option :%s: needs a value", f.Name)
+		case f.Name == "tocdepth":
+			if n, err := strconv.Atoi(f.Value); err != nil || n < 0 {
+				return fmt.Sprintf("page option :tocdepth: should be a number, not %s", f.Value)
+			}
+		}
+		return ""
+	}
+	if len(meta) == 0 {
+		meta = MetaOptions
+	}
+	switch {
+	case !meta[f.Name]:
+		return fmt.Sprintf("meta option :%s: isn't one snooty knows, so it won't be in the page", f.Name)
+	case strings.TrimSpace(f.Value) == "":
+		return fmt.Sprintf("meta option :%s: is empty", f.Name)
+	case f.Name == "robots":
+		for _, value := range strings.Split(f.Value, ",") {
+			if value = strings.ToLower(strings.TrimSpace(value)); !robotsValues[value] {
+				return fmt.Sprintf("meta option :robots: has %s, which search engines don't recognize", value)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package rst
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseForPageFields(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []PageField
+	}{{
+		input: ":orphan:\n:template: product-landing\n\n=====\nTitle\n=====\n",
+		expected: []PageField{
+			{Name: "orphan", Span: Span{Start: 0, End: 0, Line: 1, Column: 1}},
+			{Name: "template", Value: "product-landing", Span: Span{Start: 9, End: 9, Line: 2, Column: 1}},
+		},
+	}, {
+		input:    "Title\n=====\n\n:orphan:\n",
+		expected: []PageField{},
+	}, {
+		input: ".. meta::\n   :keywords: node.js, driver\n   :robots: noindex\n\nText.\n",
+		expected: []PageField{
+			{Directive: "meta", Name: "keywords", Value: "node.js, driver", Span: Span{Start: 13, End: 13, Line: 2, Column: 4}},
+			{Directive: "meta", Name: "robots", Value: "noindex", Span: Span{Start: 43, End: 43, Line: 3, Column: 4}},
+		},
+	}, {
+		input:    ".. note::\n   :keywords: not meta\n\n.. code-block:: rst\n\n   .. meta::\n      :keywords: example\n",
+		expected: []PageField{},
+	}}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, ParseForPageFields([]byte(c.input)), c.input)
+	}
+}
+
+func TestPageFieldProblem(t *testing.T) {
+	cases := []struct {
+		field    PageField
+		meta     map[string]bool
+		expected string
+	}{
+		{PageField{Name: "orphan"}, nil, ""},
+		{PageField{Name: "orhpan"}, nil, "page option :orhpan: isn't one snooty knows"},
+		{PageField{Name: "nosearch", Value: "true"}, nil, "page option :nosearch: doesn't take a value"},
+		{PageField{Name: "template"}, nil, "page option :template: needs a value"},
+		{PageField{Name: "tocdepth", Value: "two"}, nil, "page option :tocdepth: should be a number, not two"},
+		{PageField{Directive: "meta", Name: "keywords", Value: "node.js"}, nil, ""},
+		{PageField{Directive: "meta", Name: "keyword", Value: "node.js"}, nil, "meta option :keyword: isn't one snooty knows, so it won't be in the page"},
+		{PageField{Directive: "meta", Name: "description", Value: " "}, nil, "meta option :description: is empty"},
+		{PageField{Directive: "meta", Name: "robots", Value: "noindex, nofolow"}, nil, "meta option :robots: has nofolow, which search engines don't recognize"},
+		{PageField{Directive: "meta", Name: "og:image", Value: "/image.png"}, map[string]bool{"og:image": true}, ""},
+		{PageField{Directive: "meta", Name: "keywords", Value: "node.js"}, map[string]bool{"og:image": true}, "meta option :keywords: isn't one snooty knows, so it won't be in the page"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, c.field.Problem(c.meta), c.field.Name)
+	}
+}
@@ -0,0 +1,160 @@
+package rst
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Parse streams every entity in r to fn, in the order they're found. It reads
+// a paragraph at a time, so no more than a paragraph of r, and its entities,
+// is held at once however big r is. Entities don't span paragraphs.
+//
+// An entity's Block is still open when fn gets it, so the block's Span.End
+// isn't set until Parse has read past the end of the block.
+func Parse(r io.Reader, fn func(Entity)) error {
+	p := &parser{entity: fn, block: func(*Block) {}, line: 1}
+	return p.parse(r)
+}
+
+// parser splits rst into paragraphs as it reads it, tracking the directive
+// blocks they're in, and lexes each paragraph as it ends.
+type parser struct {
+	entity func(Entity)
+	block  func(*Block)
+	// paragraph, if set, gets the text of each paragraph, the block it
+	// starts in, and a func locating offsets in it
+	paragraph func(text string, block *Block, locate func(at int) Span)
+	// stack is the blocks open at the current line, innermost last
+	stack []openBlock
+	// contentEnd is where the last line with anything on it ends, so blocks
+	// don't claim the blank lines after them
+	contentEnd int
+	// offset and line are where the current paragraph starts
+	offset int
+	line   int
+	chunk  []byte
+	lines  []chunkLine
+}
+
+type openBlock struct {
+	block  *Block
+	indent int
+}
+
+// chunkLine is a line of the current paragraph.
+type chunkLine struct {
+	// start is the line's offset in the paragraph
+	start int
+	// block is the innermost block the line is in
+	block *Block
+	// opened is the block the line's directive opens, if it has one
+	opened *Block
+}
+
+func (p *parser) parse(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			p.addLine(line)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	p.flush()
+	p.closeTo(0)
+	return nil
+}
+
+func (p *parser) addLine(line string) {
+	if strings.TrimSpace(line) == "" {
+		p.flush()
+		p.offset += len(line)
+		p.line++
+		return
+	}
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	p.closeTo(indent)
+	l := chunkLine{start: len(p.chunk)}
+	if m := blockRegex.FindStringSubmatchIndex(line); m != nil {
+		dots := strings.Index(line, "..")
+		l.opened = p.open(line[m[2]:m[3]], dots)
+	} else if strings.HasSuffix(strings.TrimRight(line, " \t\r\n"), "::") {
+		// a paragraph ending in :: introduces a literal block
+		l.opened = p.open(literalBlock, indent)
+	}
+	if len(p.stack) > 0 {
+		l.block = p.stack[len(p.stack)-1].block
+	}
+	p.lines = append(p.lines, l)
+	p.chunk = append(p.chunk, line...)
+	p.contentEnd = p.offset + len(p.chunk) - len(line) + len(strings.TrimRight(line, "\r\n"))
+}
+
+// open opens a block at column in the line being added.
+func (p *parser) open(name string, column int) *Block {
+	at := p.offset + len(p.chunk) + column
+	b := &Block{Name: name, Span: Span{Start: at, End: at, Line: p.line + len(p.lines), Column: column + 1}}
+	if len(p.stack) > 0 {
+		b.Parent = p.stack[len(p.stack)-1].block
+	}
+	p.stack = append(p.stack, openBlock{block: b, indent: column})
+	p.block(b)
+	return b
+}
+
+// closeTo ends every open block a line indented by indent is outside of.
+func (p *parser) closeTo(indent int) {
+	for len(p.stack) > 0 && p.stack[len(p.stack)-1].indent >= indent {
+		p.stack[len(p.stack)-1].block.Span.End = p.contentEnd
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+}
+
+// flush lexes the current paragraph and hands its entities to fn.
+func (p *parser) flush() {
+	if len(p.chunk) == 0 {
+		return
+	}
+	locate := func(start, end int) (Span, chunkLine) {
+		i := sort.Search(len(p.lines), func(i int) bool { return p.lines[i].start > start }) - 1
+		l := p.lines[i]
+		return Span{Start: p.offset + start, End: p.offset + end, Line: p.line + i, Column: start - l.start + 1}, l
+	}
+	if p.paragraph != nil {
+		block := p.lines[0].block
+		if p.lines[0].opened != nil {
+			block = p.lines[0].opened.Parent
+		}
+		p.paragraph(string(p.chunk), block, func(at int) Span {
+			span, _ := locate(at, at)
+			return span
+		})
+	}
+	entities := make([]Entity, 0)
+	scan(p.chunk, func(kind Kind, value interface{}, at []int) {
+		span, l := locate(at[0], at[1])
+		e := Entity{Kind: kind, Span: span, Block: l.block, Value: value}
+		// a directive isn't in its own block
+		if l.opened != nil && e.Span.Start <= l.opened.Span.Start {
+			e.Block = l.opened.Parent
+		}
+		entities = append(entities, e)
+	})
+	sort.SliceStable(entities, func(i, j int) bool {
+		return entities[i].Span.Start < entities[j].Span.Start
+	})
+	for _, e := range entities {
+		p.entity(e)
+	}
+	p.offset += len(p.chunk)
+	p.line += len(p.lines)
+	p.chunk = p.chunk[:0]
+	p.lines = p.lines[:0]
+}
@@ -0,0 +1,53 @@
+package rst
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStreamsInOrder(t *testing.T) {
+	found := make([]Entity, 0)
+	err := Parse(strings.NewReader(document), func(e Entity) {
+		found = append(found, e)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, ParseDocument([]byte(document)).Entities, found)
+}
+
+func TestParseMatchesLex(t *testing.T) {
+	e := Lex(edge)
+	links := make([]RstHTTPLink, 0)
+	roles := make([]RstRole, 0)
+	assert.Nil(t, Parse(bytes.NewReader(edge), func(entity Entity) {
+		switch v := entity.Value.(type) {
+		case RstHTTPLink:
+			links = append(links, v)
+		case RstRole:
+			roles = append(roles, v)
+		}
+	}))
+	assert.ElementsMatch(t, e.HTTPLinks, links)
+	assert.ElementsMatch(t, e.Roles, roles)
+}
+
+type failingReader struct{ r io.Reader }
+
+func (f failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestParseReadError(t *testing.T) {
+	found := 0
+	err := Parse(failingReader{strings.NewReader(".. _a:\n\n.. _b:\n")}, func(Entity) { found++ })
+	assert.EqualError(t, err, "connection reset")
+	assert.Equal(t, 1, found, "the paragraphs read before the error are still parsed")
+}
@@ -3,19 +3,29 @@ package rst
 import (
 	"regexp"
 	"strings"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 var (
 	constantRegex      = regexp.MustCompile(`<\{\+([\w\s\-_\.\d\\\/=+!@#$%^&*(\)]*)\+\}(\/[\w\s\-_\.\d\\\/=+!@#$%^&*(\)]*)>\x60`)
-	httpLinkRegex      = regexp.MustCompile(`(https?:\/\/[-a-zA-Z0-9@:%._\+~#=]{1,256}\.[a-zA-Z0-9]{1,6}\b[-a-zA-Z0-9@:%_\+.~#?&//=]*)`)
-	roleRegex          = regexp.MustCompile(`:([[:alnum:]\.]+):\x60([^\x60]+)`)
-	localRefRegex      = regexp.MustCompile(`\.\. +_([\-_=+!@#$%^&\(\)\w\d\p{P}\p{S} ]+):`)
+	httpLinkRegex      = regexp.MustCompile(`(https?:\/\/[-a-zA-Z0-9\p{L}\p{M}@:%._\+~#=]{1,256}\.[a-zA-Z0-9]{1,6}\b[-a-zA-Z0-9\p{L}\p{M}\p{N}@:%_\+.~#?&//=]*)`)
+	roleRegex          = regexp.MustCompile(`:((?:[[:alnum:]]+:)?[[:alnum:]\.]+):\x60([^\x60]+)`)
+	localRefRegex      = regexp.MustCompile(`\.\. +_([\-_=+!@#$%^&\(\)\w\d\p{L}\p{M}\p{N}\p{P}\p{S} ]+):`)
 	sharedIncludeRegex = regexp.MustCompile(`\.\. sharedinclude::\s([\w\-_\.\d\\\/=+!@#$%^&*(\)\[\]\\\<\>'\?]+)`)
 	directiveRegex     = regexp.MustCompile(`\.\.\s([[:alnum:]]+)::\s([[:graph:] ]+)`)
+	// linkTargetRegex matches the target of a hyperlink, like `text <target>`__,
+	// or of a hyperlink definition, like .. _name: target
+	linkTargetRegex = regexp.MustCompile(`<([^<>\s\x60]+)>\x60__?|(?m:^[ \t]*\.\. _[^:\n]+:[ \t]+(\S+))`)
+	// contactLinkRegex leaves off punctuation ending the sentence a link is in
+	contactLinkRegex = regexp.MustCompile(`\b(?:mailto|tel):[^\s<>\x60"']*[^\s<>\x60"'.,;:!?)]`)
 )
 
 type RstHTTPLink string
 
+// RstContactLink is a mailto: or tel: link.
+type RstContactLink string
+
 type RstRole struct {
 	Target   string
 	RoleType string
@@ -56,36 +66,74 @@ func ParseForHTTPLinks(input []byte) []RstHTTPLink {
 	return links
 }
 
+// ParseForContactLinks finds every mailto: and tel: link in input.
+func ParseForContactLinks(input []byte) []RstContactLink {
+	links := make([]RstContactLink, 0)
+	parse(input, *contactLinkRegex, func(matches []string) {
+		links = append(links, RstContactLink(matches[0]))
+	})
+	return links
+}
+
+// ParseForLinkTargets finds the target of every hyperlink and hyperlink
+// definition in input, whatever its scheme.
+func ParseForLinkTargets(input []byte) []string {
+	targets := make([]string, 0)
+	parse(input, *linkTargetRegex, func(matches []string) {
+		targets = append(targets, matches[1]+matches[2])
+	})
+	return targets
+}
+
+// ReplaceHTTPLinks returns input with every http link replaced by fn's result.
+func ReplaceHTTPLinks(input []byte, fn func(link RstHTTPLink) RstHTTPLink) []byte {
+	return httpLinkRegex.ReplaceAllFunc(input, func(match []byte) []byte {
+		return []byte(fn(RstHTTPLink(match)))
+	})
+}
+
 func ParseForRoles(input []byte) []RstRole {
 	roles := make([]RstRole, 0)
 	allFound := roleRegex.FindAllString(string(input), -1)
 	for _, match := range allFound {
 		for _, m := range roleRegex.FindAllStringSubmatch(match, -1) {
-			matches := make([]string, 2)
-			if strings.TrimSpace(m[1]) != "" {
-				matches[0] = m[1]
-			}
-			if strings.HasSuffix(m[2], ">") {
-				lastClosingBracket := strings.LastIndex(m[2], ">")
-				lastOpeningBracket := strings.LastIndex(m[2], "<")
-				matches[1] = m[2][lastOpeningBracket+1 : lastClosingBracket]
-			} else {
-				matches[1] = m[2]
-			}
-			roleType, name := "", ""
-			if matches[0] == "ref" {
-				roleType = "ref"
-				name = "ref"
-			} else {
-				roleType = "role"
-				name = matches[0]
-			}
-			roles = append(roles, RstRole{Target: matches[1], RoleType: roleType, Name: name})
+			roles = append(roles, newRole(m))
 		}
 	}
 	return roles
 }
 
+// newRole makes a role from roleRegex's submatches, taking the target from
+// between the angle brackets of an explicit title, like :ref:`title <target>`.
+func newRole(m []string) RstRole {
+	matches := make([]string, 2)
+	if strings.TrimSpace(m[1]) != "" {
+		matches[0] = m[1]
+	}
+	if strings.HasSuffix(m[2], ">") {
+		lastClosingBracket := strings.LastIndex(m[2], ">")
+		lastOpeningBracket := strings.LastIndex(m[2], "<")
+		matches[1] = m[2][lastOpeningBracket+1 : lastClosingBracket]
+	} else {
+		matches[1] = m[2]
+	}
+	roleType, name := "", ""
+	if matches[0] == "ref" {
+		roleType = "ref"
+		name = "ref"
+	} else {
+		roleType = "role"
+		name = matches[0]
+	}
+	return RstRole{Target: normalize(matches[1]), RoleType: roleType, Name: name}
+}
+
+// normalize puts ref targets in Unicode normal form C, so a target typed
+// with combining accents matches one typed with precomposed characters.
+func normalize(target string) string {
+	return norm.NFC.String(target)
+}
+
 func ParseForConstants(input []byte) []RstConstant {
 	constants := make([]RstConstant, 0)
 	parse(input, *constantRegex, func(matches []string) {
@@ -101,7 +149,7 @@ func (r *RstConstant) IsHTTPLink() bool {
 func ParseForLocalRefs(input []byte) []RefTarget {
 	localrefs := make([]RefTarget, 0)
 	parse(input, *localRefRegex, func(matches []string) {
-		localrefs = append(localrefs, RefTarget{Name: matches[1]})
+		localrefs = append(localrefs, RefTarget{Name: normalize(matches[1])})
 	})
 
 	return localrefs
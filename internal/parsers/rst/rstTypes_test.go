@@ -48,6 +48,14 @@ func TestFindLocalRefs(t *testing.T) {
 
 }
 
+func TestTargetsAreNormalized(t *testing.T) {
+	// the label's é is a combining accent, the role's is precomposed
+	input := []byte(".. _cafe\u0301-menu:\n\nSee :ref:`caf\u00e9-menu`.\n")
+	assert.Equal(t, []RefTarget{{Name: "caf\u00e9-menu"}}, ParseForLocalRefs(input))
+	assert.Equal(t, "caf\u00e9-menu", ParseForRoles(input)[0].Target)
+	assert.Equal(t, ParseForLocalRefs(input), Lex(input).LocalRefs)
+}
+
 func TestConstantParser(t *testing.T) {
 
 	cases := []struct {
@@ -133,6 +141,52 @@ func TestLinkParser(t *testing.T) {
 	}
 }
 
+func TestNonASCIILinks(t *testing.T) {
+	input := []byte("See https://de.wikipedia.org/wiki/Stra\u00dfe and https://b\u00fccher.de/katalog today.")
+	assert.Equal(t, []RstHTTPLink{"https://de.wikipedia.org/wiki/Stra\u00dfe", "https://b\u00fccher.de/katalog"}, ParseForHTTPLinks(input))
+}
+
+func TestContactLinkParser(t *testing.T) {
+	input := []byte("Email `docs <mailto:docs@example.com?subject=Hi>`__, mailto:help@example.com, or call tel:+1-212-555-0100. The hotel: lobby.")
+	assert.Equal(t, []RstContactLink{"mailto:docs@example.com?subject=Hi", "mailto:help@example.com", "tel:+1-212-555-0100"}, ParseForContactLinks(input))
+}
+
+func TestLinkTargetParser(t *testing.T) {
+	input := []byte("See `the FAQ <ftp://ftp.example.com/faq.txt>`__, `home <//example.com>`_, and :ref:`install <install>`.\n\n.. _local-copy: file:///tmp/copy.html\n.. _install:\n")
+	assert.Equal(t, []string{"ftp://ftp.example.com/faq.txt", "//example.com", "file:///tmp/copy.html"}, ParseForLinkTargets(input))
+}
+
+func TestReplaceHTTPLinks(t *testing.T) {
+	moved := map[RstHTTPLink]RstHTTPLink{
+		"https://docs.mongodb.com/manual": "https://www.mongodb.com/docs/manual/",
+	}
+	replace := func(link RstHTTPLink) RstHTTPLink {
+		if to, ok := moved[link]; ok {
+			return to
+		}
+		return link
+	}
+
+	cases := []struct {
+		input    string
+		expected string
+	}{{
+		input:    "",
+		expected: "",
+	}, {
+		input:    "see `the manual <https://docs.mongodb.com/manual>`__",
+		expected: "see `the manual <https://www.mongodb.com/docs/manual/>`__",
+	}, {
+		input:    "https://docs.mongodb.com/manual/core and https://docs.mongodb.com/manual",
+		expected: "https://docs.mongodb.com/manual/core and https://www.mongodb.com/docs/manual/",
+	}}
+
+	for _, test := range cases {
+		got := string(ReplaceHTTPLinks([]byte(test.input), replace))
+		assert.Equal(t, test.expected, got, "ReplaceHTTPLinks(%q) should return %q, got %q", test.input, test.expected, got)
+	}
+}
+
 //go:embed testdata/makesGoUnhappy.txt
 var edge []byte
 
@@ -182,6 +236,9 @@ func TestRoleParser(t *testing.T) {
 	}, {
 		input:    []byte(":authaction:`find`/:authaction:`update`"),
 		expected: []RstRole{{Target: "find", RoleType: "role", Name: "authaction"}, {Target: "update", RoleType: "role", Name: "authaction"}},
+	}, {
+		input:    []byte("call :py:meth:`~pymongo.collection.Collection.find` or :mongodb:method:`db.collection.find()`"),
+		expected: []RstRole{{Target: "~pymongo.collection.Collection.find", RoleType: "role", Name: "py:meth"}, {Target: "db.collection.find()", RoleType: "role", Name: "mongodb:method"}},
 	}}
 
 	for _, test := range cases {
@@ -0,0 +1,90 @@
+package rst
+
+import (
+	"regexp"
+	"strings"
+)
+
+// literalBlock is the name of the block a paragraph ending in :: introduces.
+const literalBlock = "literal"
+
+// literalBlocks hold code rather than rst, so backticks in them mean nothing.
+var literalBlocks = map[string]bool{
+	literalBlock:    true,
+	"code":          true,
+	"code-block":    true,
+	"sourcecode":    true,
+	"io-code-block": true,
+	"input":         true,
+	"output":        true,
+	"raw":           true,
+	"math":          true,
+}
+
+var (
+	inlineLiteralRegex = regexp.MustCompile("(?s)``.+?``")
+	emptyRoleRegex     = regexp.MustCompile(":((?:[[:alnum:]]+:)?[[:alnum:]\\.\\-]+):``")
+	spacedRoleRegex    = regexp.MustCompile("\\S[ \\t]+:((?:[[:alnum:]]+:)?[[:alnum:]\\.\\-]+):[ \\t]+`")
+	strayLinkEndRegex  = regexp.MustCompile("(?:^|\\s)(`__?)(?:[^\\w`]|$)")
+	unclosedLinkRegex  = regexp.MustCompile("<(https?://[^>`\\s]*)`__?")
+)
+
+// SyntaxWarning is markup that's likely broken, so it won't render the way
+// its writer meant.
+type SyntaxWarning struct {
+	Message string
+	Span    Span
+}
+
+// ParseForSyntaxWarnings finds likely broken role and link markup, like
+// unclosed backticks or a :ref: with no target, that would otherwise just
+// render wrong rather than fail to resolve. Code is skipped.
+func ParseForSyntaxWarnings(input []byte) []SyntaxWarning {
+	warnings := make([]SyntaxWarning, 0)
+	p := &parser{entity: func(Entity) {}, block: func(*Block) {}, line: 1}
+	p.paragraph = func(text string, block *Block, locate func(at int) Span) {
+		for b := block; b != nil; b = b.Parent {
+			if literalBlocks[b.Name] {
+				return
+			}
+		}
+		for _, w := range lintParagraph(text) {
+			warnings = append(warnings, SyntaxWarning{Message: w.message, Span: locate(w.at)})
+		}
+	}
+	// reading from memory can't fail
+	_ = p.parse(strings.NewReader(string(input)))
+	return warnings
+}
+
+type lint struct {
+	at      int
+	message string
+}
+
+func lintParagraph(text string) []lint {
+	found := make([]lint, 0)
+	for _, m := range emptyRoleRegex.FindAllStringSubmatchIndex(text, -1) {
+		found = append(found, lint{m[0], ":" + text[m[2]:m[3]] + ": has no target"})
+	}
+	for _, m := range spacedRoleRegex.FindAllStringSubmatchIndex(text, -1) {
+		found = append(found, lint{m[2] - 1, ":" + text[m[2]:m[3]] + ": is followed by a space, so it won't be read as a role"})
+	}
+	// blank out inline literals, keeping offsets, so their backticks aren't counted
+	text = inlineLiteralRegex.ReplaceAllStringFunc(text, func(literal string) string {
+		return strings.Repeat(" ", len(literal))
+	})
+	for _, m := range unclosedLinkRegex.FindAllStringSubmatchIndex(text, -1) {
+		found = append(found, lint{m[0], "link to " + text[m[2]:m[3]] + " is missing its closing >"})
+	}
+	for _, m := range strayLinkEndRegex.FindAllStringSubmatchIndex(text, -1) {
+		found = append(found, lint{m[2], text[m[2]:m[3]] + " doesn't close a link, is its opening backtick missing?"})
+	}
+	if len(found) > 0 {
+		return found
+	}
+	if strings.Count(text, "`")%2 == 1 {
+		found = append(found, lint{strings.LastIndex(text, "`"), "unclosed backtick"})
+	}
+	return found
+}
@@ -0,0 +1,44 @@
+package rst
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseForSyntaxWarnings(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []SyntaxWarning
+	}{{
+		input:    "See :ref:`install` and `MongoDB <https://www.mongodb.com>`__.",
+		expected: []SyntaxWarning{},
+	}, {
+		input:    "Run ``echo `date` `` first.",
+		expected: []SyntaxWarning{},
+	}, {
+		input:    "See :ref:`install.\n\nNext paragraph.",
+		expected: []SyntaxWarning{{Message: "unclosed backtick", Span: Span{Start: 9, End: 9, Line: 1, Column: 10}}},
+	}, {
+		input:    "First.\n\nSee :ref:``.",
+		expected: []SyntaxWarning{{Message: ":ref: has no target", Span: Span{Start: 12, End: 12, Line: 3, Column: 5}}},
+	}, {
+		input:    "See :ref: `install`.",
+		expected: []SyntaxWarning{{Message: ":ref: is followed by a space, so it won't be read as a role", Span: Span{Start: 4, End: 4, Line: 1, Column: 5}}},
+	}, {
+		input:    "See the docs `__ for more.",
+		expected: []SyntaxWarning{{Message: "`__ doesn't close a link, is its opening backtick missing?", Span: Span{Start: 13, End: 13, Line: 1, Column: 14}}},
+	}, {
+		input:    "See `MongoDB <https://www.mongodb.com`__.",
+		expected: []SyntaxWarning{{Message: "link to https://www.mongodb.com is missing its closing >", Span: Span{Start: 13, End: 13, Line: 1, Column: 14}}},
+	}, {
+		input:    ".. code-block:: sh\n\n   echo `date\n\nRun this::\n\n   echo `date\n",
+		expected: []SyntaxWarning{},
+	}, {
+		input:    ":caption: `foo`\n",
+		expected: []SyntaxWarning{},
+	}}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, ParseForSyntaxWarnings([]byte(c.input)), c.input)
+	}
+}
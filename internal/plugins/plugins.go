@@ -0,0 +1,159 @@
+// Package plugins runs external executables as custom checks. A plugin is
+// given each file, with the entities checker parsed from it, as a line of
+// JSON on stdin, and answers with a line of JSON on stdout for each problem it
+// finds, so organizations can add house-style checks without forking checker.
+package plugins
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+)
+
+// File is what a plugin is given about a file.
+type File struct {
+	Name     string   `json:"file"`
+	Content  string   `json:"content"`
+	Entities []Entity `json:"entities"`
+}
+
+// Entity is a role, link, constant, label, include, or directive in a file.
+// Only the fields that apply to its kind are set.
+type Entity struct {
+	Kind   rst.Kind `json:"kind"`
+	Line   int      `json:"line"`
+	Column int      `json:"column"`
+	// Block is the name of the innermost directive the entity is in
+	Block string `json:"block,omitempty"`
+	// Name is a role's, constant's, label's, or directive's name
+	Name string `json:"name,omitempty"`
+	// Target is a role's target, a constant's value, or a directive's argument
+	Target string `json:"target,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+// NewFile describes the file filename, with content data parsed into doc.
+func NewFile(filename string, data []byte, doc *rst.Document) File {
+	f := File{Name: filename, Content: string(data), Entities: make([]Entity, 0, len(doc.Entities))}
+	for _, e := range doc.Entities {
+		entity := Entity{Kind: e.Kind, Line: e.Span.Line, Column: e.Span.Column}
+		if e.Block != nil {
+			entity.Block = e.Block.Name
+		}
+		switch v := e.Value.(type) {
+		case rst.RstRole:
+			entity.Name, entity.Target = v.Name, v.Target
+		case rst.RstHTTPLink:
+			entity.URL = string(v)
+		case rst.RstContactLink:
+			entity.URL = string(v)
+		case rst.RstConstant:
+			entity.Name, entity.Target = v.Name, v.Target
+		case rst.RefTarget:
+			entity.Name = v.Name
+		case rst.SharedInclude:
+			entity.Path = v.Path
+		case rst.RstDirective:
+			entity.Name, entity.Target = v.Name, v.Target
+		}
+		f.Entities = append(f.Entities, entity)
+	}
+	return f
+}
+
+// finding is a problem a plugin found. Severity is error unless it's warning.
+type finding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Target   string `json:"target"`
+	Fix      string `json:"fix"`
+}
+
+// Run runs the plugin name, command and its arguments, in dir, giving it
+// files from next until next returns false, and returns what it found.
+func Run(name string, command []string, dir string, next func() (File, bool)) ([]diagnostics.Diagnostic, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("plugin %s has no command", name)
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start plugin %s: %v", name, err)
+	}
+
+	// files are written while findings are read, so neither side blocks on a
+	// full pipe
+	written := make(chan error, 1)
+	go func() {
+		enc := json.NewEncoder(stdin)
+		var err error
+		for f, ok := next(); ok && err == nil; f, ok = next() {
+			err = enc.Encode(f)
+		}
+		if closeErr := stdin.Close(); err == nil {
+			err = closeErr
+		}
+		written <- err
+	}()
+
+	found, readErr := read(name, stdout)
+	// drain what's left so the plugin can exit
+	_, _ = io.Copy(io.Discard, stdout)
+	writeErr := <-written
+	if err := cmd.Wait(); err != nil {
+		return found, fmt.Errorf("plugin %s failed: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	if readErr != nil {
+		return found, readErr
+	}
+	// a plugin that exits without reading every file didn't need them
+	if writeErr != nil && !errors.Is(writeErr, syscall.EPIPE) {
+		return found, fmt.Errorf("could not give plugin %s the files: %v", name, writeErr)
+	}
+	return found, nil
+}
+
+func read(name string, out io.Reader) ([]diagnostics.Diagnostic, error) {
+	found := make([]diagnostics.Diagnostic, 0)
+	scanner := bufio.NewScanner(out)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var f finding
+		if err := json.Unmarshal(scanner.Bytes(), &f); err != nil {
+			return found, fmt.Errorf("plugin %s wrote something other than a finding on line %d: %v", name, line, err)
+		}
+		if f.File == "" || f.Message == "" {
+			return found, fmt.Errorf("plugin %s wrote a finding without a file or message on line %d", name, line)
+		}
+		d := diagnostics.Errorf(diagnostics.Plugin, f.File, "%s: %s", name, f.Message)
+		if f.Severity == string(diagnostics.Warning) {
+			d.Severity = diagnostics.Warning
+		}
+		found = append(found, d.AtLine(f.Line).WithTarget(f.Target).WithFix(f.Fix))
+	}
+	return found, scanner.Err()
+}
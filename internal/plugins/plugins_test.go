@@ -0,0 +1,93 @@
+package plugins
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+	"github.com/terakilobyte/checker/internal/parsers/rst"
+)
+
+func TestNewFile(t *testing.T) {
+	data := []byte("Title\n=====\n\n.. note::\n\n   See :ref:`install` and https://www.mongodb.com for more.\n")
+	f := NewFile("/source/index.txt", data, rst.ParseDocument(data))
+	assert.Equal(t, "/source/index.txt", f.Name)
+	assert.Equal(t, string(data), f.Content)
+	assert.Contains(t, f.Entities, Entity{Kind: rst.KindRole, Line: 6, Column: 8, Block: "note", Name: "ref", Target: "install"})
+	for _, e := range f.Entities {
+		if e.Kind == rst.KindHTTPLink {
+			assert.Equal(t, "https://www.mongodb.com", e.URL)
+		}
+	}
+}
+
+// plugin writes a stand-in plugin, a shell script, and returns its path.
+func plugin(t *testing.T, script string) string {
+	path := filepath.Join(t.TempDir(), "plugin")
+	assert.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755))
+	return path
+}
+
+func files(fs ...File) func() (File, bool) {
+	return func() (File, bool) {
+		if len(fs) == 0 {
+			return File{}, false
+		}
+		f := fs[0]
+		fs = fs[1:]
+		return f, true
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	given := filepath.Join(dir, "given.jsonl")
+	trademarks := plugin(t, `cat > `+given+`
+echo '{"file": "/source/index.txt", "line": 6, "message": "write MongoDB, not Mongo", "target": "Mongo", "fix": "MongoDB"}'
+echo ''
+echo '{"file": "/source/faq.txt", "severity": "warning", "message": "example.com is a banned domain"}'
+`)
+	found, err := Run("trademarks", []string{trademarks}, dir, files(File{Name: "/source/index.txt", Content: "Mongo"}, File{Name: "/source/faq.txt"}))
+	assert.NoError(t, err)
+	assert.Equal(t, []diagnostics.Diagnostic{
+		diagnostics.Errorf(diagnostics.Plugin, "/source/index.txt", "trademarks: write MongoDB, not Mongo").AtLine(6).WithTarget("Mongo").WithFix("MongoDB"),
+		diagnostics.Warnf(diagnostics.Plugin, "/source/faq.txt", "trademarks: example.com is a banned domain"),
+	}, found)
+
+	data, err := os.ReadFile(given)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	var first File
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "Mongo", first.Content)
+}
+
+func TestRunStopsReading(t *testing.T) {
+	// a plugin can stop reading once it's seen what it needs
+	lazy := plugin(t, "echo '{\"file\": \"/source/index.txt\", \"message\": \"first file only\"}'\n")
+	many := make([]File, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		many = append(many, File{Name: "/source/index.txt", Content: strings.Repeat("text ", 100)})
+	}
+	found, err := Run("lazy", []string{lazy}, t.TempDir(), files(many...))
+	assert.NoError(t, err)
+	assert.Len(t, found, 1)
+}
+
+func TestRunFails(t *testing.T) {
+	broken := plugin(t, "cat > /dev/null\necho 'missing config' >&2\nexit 1\n")
+	_, err := Run("broken", []string{broken}, t.TempDir(), files(File{Name: "/source/index.txt"}))
+	assert.EqualError(t, err, "plugin broken failed: exit status 1: missing config")
+
+	chatty := plugin(t, "cat > /dev/null\necho 'checking files'\n")
+	_, err = Run("chatty", []string{chatty}, t.TempDir(), files(File{Name: "/source/index.txt"}))
+	assert.Contains(t, err.Error(), "plugin chatty wrote something other than a finding on line 1")
+
+	_, err = Run("missing", []string{filepath.Join(t.TempDir(), "nope")}, t.TempDir(), files())
+	assert.Contains(t, err.Error(), "could not start plugin missing")
+}
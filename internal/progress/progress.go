@@ -0,0 +1,197 @@
+// Package progress reports how far along each stage of a run is, with its rate
+// and estimated time remaining. On a terminal each stage gets a progress bar,
+// elsewhere, like in CI logs, it degrades to a status line every so often.
+// Progress can also be reported as JSON lines, for wrappers and dashboards.
+//
+// A nil *Reporter and the nil *Stage it starts report nothing, so callers don't
+// need to check whether progress was asked for.
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cheggaaa/pb/v3"
+)
+
+const barTemplate = `{{string . "stage"}} {{counters . }} {{bar . }} {{percent . }} {{speed . "%s/s" "?/s"}} {{rtime . "ETA %s" "%s"}}`
+
+// Reporter starts stages and reports their progress to out, and as events to
+// events.
+type Reporter struct {
+	out io.Writer
+	tty bool
+	// interval is how often status lines are written when out isn't a terminal
+	interval time.Duration
+	now      func() time.Time
+
+	events *json.Encoder
+	// eventInterval is how often events are written while a stage is underway
+	eventInterval time.Duration
+	eventsMu      sync.Mutex
+}
+
+// New reports progress to out, with progress bars if out is a terminal.
+func New(out io.Writer, tty bool) *Reporter {
+	return &Reporter{out: out, tty: tty, interval: 10 * time.Second, now: time.Now}
+}
+
+// NewEvents only reports progress as events to events.
+func NewEvents(events io.Writer) *Reporter {
+	return New(nil, false).WithEvents(events)
+}
+
+// WithEvents also reports progress as events to events: a line of JSON when a
+// stage starts, every second while it's underway, and when it's done.
+func (r *Reporter) WithEvents(events io.Writer) *Reporter {
+	r.events = json.NewEncoder(events)
+	r.eventInterval = time.Second
+	return r
+}
+
+// Event is a line of progress on the events side channel.
+type Event struct {
+	Type      string    `json:"event"`
+	Time      time.Time `json:"time"`
+	Stage     string    `json:"stage"`
+	Completed int64     `json:"completed"`
+	Total     int64     `json:"total"`
+	// Rate is how many steps were completed a second so far
+	Rate float64 `json:"rate"`
+	Done bool    `json:"done,omitempty"`
+}
+
+// Stage is the progress of one stage of a run. It's safe for concurrent use.
+type Stage struct {
+	reporter *Reporter
+	name     string
+	total    int64
+	done     int64
+	start    time.Time
+	bar      *pb.ProgressBar
+	stop     chan struct{}
+	stopped  chan struct{}
+	finished sync.Once
+}
+
+// Start starts reporting the progress of a stage with total steps.
+func (r *Reporter) Start(name string, total int) *Stage {
+	if r == nil {
+		return nil
+	}
+	s := &Stage{reporter: r, name: name, total: int64(total), start: r.now(), stop: make(chan struct{}), stopped: make(chan struct{})}
+	s.emit(false)
+	if r.out != nil && r.tty {
+		s.bar = pb.New(total).SetTemplateString(barTemplate).Set("stage", name).SetMaxWidth(120).SetWriter(r.out).Start()
+	}
+	go func() {
+		defer close(s.stopped)
+		// a nil channel never delivers, so whatever isn't reported never ticks
+		var lines, events <-chan time.Time
+		if r.out != nil && !r.tty {
+			ticker := time.NewTicker(r.interval)
+			defer ticker.Stop()
+			lines = ticker.C
+		}
+		if r.events != nil {
+			ticker := time.NewTicker(r.eventInterval)
+			defer ticker.Stop()
+			events = ticker.C
+		}
+		for {
+			select {
+			case <-lines:
+				fmt.Fprintln(r.out, s.Status())
+			case <-events:
+				s.emit(false)
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// emit writes an event with the stage's progress, if events are reported.
+func (s *Stage) emit(done bool) {
+	r := s.reporter
+	if r.events == nil {
+		return
+	}
+	now := r.now()
+	event := Event{Type: "progress", Time: now, Stage: s.name, Completed: atomic.LoadInt64(&s.done), Total: atomic.LoadInt64(&s.total), Done: done}
+	if elapsed := now.Sub(s.start); elapsed > 0 {
+		event.Rate = float64(event.Completed) / elapsed.Seconds()
+	}
+	r.eventsMu.Lock()
+	defer r.eventsMu.Unlock()
+	// a failed write can't be reported anywhere better, so it's dropped
+	_ = r.events.Encode(event)
+}
+
+// Increment records that a step of the stage is done.
+func (s *Stage) Increment() {
+	if s == nil {
+		return
+	}
+	atomic.AddInt64(&s.done, 1)
+	if s.bar != nil {
+		s.bar.Increment()
+	}
+}
+
+// AddTotal adds n steps to the stage, for stages whose work is found while
+// it's already underway.
+func (s *Stage) AddTotal(n int) {
+	if s == nil {
+		return
+	}
+	atomic.AddInt64(&s.total, int64(n))
+	if s.bar != nil {
+		s.bar.AddTotal(int64(n))
+	}
+}
+
+// Status describes how far along the stage is, like
+// "link validation: 120/500 (24%), 12.0/s, ETA 32s".
+func (s *Stage) Status() string {
+	done, total := atomic.LoadInt64(&s.done), atomic.LoadInt64(&s.total)
+	elapsed := s.reporter.now().Sub(s.start)
+	status := fmt.Sprintf("%s: %d/%d", s.name, done, total)
+	if total > 0 {
+		status += fmt.Sprintf(" (%d%%)", done*100/total)
+	}
+	if done == 0 || elapsed <= 0 {
+		return status
+	}
+	rate := float64(done) / elapsed.Seconds()
+	status += fmt.Sprintf(", %.1f/s", rate)
+	if remaining := total - done; remaining > 0 {
+		eta := time.Duration(float64(remaining) / rate * float64(time.Second))
+		status += fmt.Sprintf(", ETA %s", eta.Round(time.Second))
+	}
+	return status
+}
+
+// Finish stops reporting the stage. Finishing more than once does nothing.
+func (s *Stage) Finish() {
+	if s == nil {
+		return
+	}
+	s.finished.Do(func() {
+		close(s.stop)
+		<-s.stopped
+		s.emit(true)
+		switch {
+		case s.bar != nil:
+			s.bar.Finish()
+		case s.reporter.out != nil:
+			elapsed := s.reporter.now().Sub(s.start)
+			fmt.Fprintf(s.reporter.out, "%s: %d/%d done in %s\n", s.name, atomic.LoadInt64(&s.done), atomic.LoadInt64(&s.total), elapsed.Round(time.Millisecond))
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := New(&bytes.Buffer{}, false)
+	r.now = func() time.Time { return now }
+	s := r.Start("link validation", 500)
+	defer s.Finish()
+
+	assert.Equal(t, "link validation: 0/500 (0%)", s.Status())
+
+	for i := 0; i < 120; i++ {
+		s.Increment()
+	}
+	now = now.Add(10 * time.Second)
+	assert.Equal(t, "link validation: 120/500 (24%), 12.0/s, ETA 32s", s.Status())
+
+	for i := 0; i < 380; i++ {
+		s.Increment()
+	}
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, "link validation: 500/500 (100%), 12.5/s", s.Status())
+}
+
+func TestAddTotal(t *testing.T) {
+	r := New(&bytes.Buffer{}, false)
+	s := r.Start("link validation", 0)
+	defer s.Finish()
+
+	assert.Equal(t, "link validation: 0/0", s.Status())
+	s.AddTotal(4)
+	s.Increment()
+	assert.Contains(t, s.Status(), "link validation: 1/4 (25%)")
+}
+
+func TestPlainTextStatusLines(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out, false)
+	r.interval = 10 * time.Millisecond
+	s := r.Start("parsing", 2)
+	s.Increment()
+	time.Sleep(35 * time.Millisecond)
+	s.Increment()
+	s.Finish()
+	s.Finish()
+
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	assert.GreaterOrEqual(t, len(lines), 2)
+	assert.Contains(t, string(lines[0]), "parsing: 1/2 (50%)")
+	assert.Contains(t, string(lines[len(lines)-1]), "parsing: 2/2 done in")
+}
+
+func TestNilReporter(t *testing.T) {
+	var r *Reporter
+	s := r.Start("parsing", 2)
+	s.Increment()
+	s.AddTotal(1)
+	s.Finish()
+	assert.Nil(t, s)
+}
+
+func TestEvents(t *testing.T) {
+	var events bytes.Buffer
+	r := NewEvents(&events)
+	r.eventInterval = 10 * time.Millisecond
+	s := r.Start("link validation", 4)
+	s.Increment()
+	time.Sleep(35 * time.Millisecond)
+	s.Increment()
+	s.Finish()
+
+	dec := json.NewDecoder(&events)
+	var all []Event
+	for dec.More() {
+		var event Event
+		assert.NoError(t, dec.Decode(&event))
+		all = append(all, event)
+	}
+	assert.GreaterOrEqual(t, len(all), 3)
+	first, last := all[0], all[len(all)-1]
+	assert.Equal(t, Event{Type: "progress", Time: first.Time, Stage: "link validation", Total: 4}, first)
+	assert.Equal(t, int64(1), all[1].Completed)
+	assert.Equal(t, int64(2), last.Completed)
+	assert.True(t, last.Done)
+	assert.Greater(t, last.Rate, 0.0)
+}
+
+func TestEventsWithStatusLines(t *testing.T) {
+	var out, events bytes.Buffer
+	r := New(&out, false).WithEvents(&events)
+	s := r.Start("parsing", 1)
+	s.Increment()
+	s.Finish()
+	assert.Contains(t, out.String(), "parsing: 1/1 done in")
+	assert.Contains(t, events.String(), `"completed":1,"total":1`)
+	assert.Contains(t, events.String(), `"done":true`)
+}
@@ -0,0 +1,102 @@
+// Package rpc serves JSON-RPC 2.0 over a pair of streams, a message per line,
+// so editors and bots can keep checker running as a child process.
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// Error codes from the JSON-RPC 2.0 spec.
+const (
+	ParseError     = -32700
+	InvalidRequest = -32600
+	MethodNotFound = -32601
+	InvalidParams  = -32602
+	InternalError  = -32603
+)
+
+// Error is a JSON-RPC error. Handlers return one to choose its code; any
+// other error is an InternalError.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Handler answers a call with params, the call's raw params or nil.
+type Handler func(params json.RawMessage) (interface{}, error)
+
+type request struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type response struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Serve answers the calls read from in, a JSON object a line, with handlers,
+// writing a response a line to out, one call at a time, until in ends.
+// Notifications, calls without an id, are handled but not answered.
+func Serve(in io.Reader, out io.Writer, handlers map[string]Handler) error {
+	enc := json.NewEncoder(out)
+	scanner := bufio.NewScanner(in)
+	// a call can carry a whole file
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			if err := enc.Encode(response{Version: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: ParseError, Message: err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+		res := call(req, handlers)
+		if len(req.ID) == 0 {
+			continue
+		}
+		res.ID = req.ID
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func call(req request, handlers map[string]Handler) response {
+	res := response{Version: "2.0"}
+	if req.Version != "2.0" || req.Method == "" {
+		res.Error = &Error{Code: InvalidRequest, Message: "not a JSON-RPC 2.0 request"}
+		return res
+	}
+	handler, ok := handlers[req.Method]
+	if !ok {
+		res.Error = &Error{Code: MethodNotFound, Message: "no method " + req.Method}
+		return res
+	}
+	result, err := handler(req.Params)
+	if err != nil {
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &Error{Code: InternalError, Message: err.Error()}
+		}
+		res.Error = rpcErr
+		return res
+	}
+	res.Result = result
+	return res
+}
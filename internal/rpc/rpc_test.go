@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServe(t *testing.T) {
+	handlers := map[string]Handler{
+		"echo": func(params json.RawMessage) (interface{}, error) {
+			var p struct {
+				Text string `json:"text"`
+			}
+			if err := json.Unmarshal(params, &p); err != nil || p.Text == "" {
+				return nil, &Error{Code: InvalidParams, Message: "echo needs text"}
+			}
+			return map[string]string{"text": p.Text}, nil
+		},
+		"fail": func(params json.RawMessage) (interface{}, error) {
+			return nil, errors.New("it broke")
+		},
+	}
+	in := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"echo","params":{"text":"hi"}}`,
+		`{"jsonrpc":"2.0","method":"echo","params":{"text":"nobody's listening"}}`,
+		``,
+		`{"jsonrpc":"2.0","id":"two","method":"echo","params":{}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"fail"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"nope"}`,
+		`{"id":5,"method":"echo"}`,
+		`not json`,
+	}, "\n")
+	var out bytes.Buffer
+	assert.NoError(t, Serve(strings.NewReader(in), &out, handlers))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, []string{
+		`{"jsonrpc":"2.0","id":1,"result":{"text":"hi"}}`,
+		`{"jsonrpc":"2.0","id":"two","error":{"code":-32602,"message":"echo needs text"}}`,
+		`{"jsonrpc":"2.0","id":3,"error":{"code":-32603,"message":"it broke"}}`,
+		`{"jsonrpc":"2.0","id":4,"error":{"code":-32601,"message":"no method nope"}}`,
+		`{"jsonrpc":"2.0","id":5,"error":{"code":-32600,"message":"not a JSON-RPC 2.0 request"}}`,
+		`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"invalid character 'o' in literal null (expecting 'u')"}}`,
+	}, lines)
+}
@@ -0,0 +1,272 @@
+package sources
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// CheckerConfig is checker's own project configuration, read from checker.toml
+// next to snooty.toml. Every table is optional.
+type CheckerConfig struct {
+	// RstSpecVersion pins the snooty-parser release whose rstspec.toml roles
+	// are validated against, like v0.13.7, instead of the latest
+	RstSpecVersion string `toml:"rstspec_version"`
+	// Deprecated maps deprecated ref targets and urls to their replacements
+	Deprecated map[string]string `toml:"deprecated"`
+	// Roles maps link role names to url templates, with %s standing for the
+	// target, adding to or overriding rstspec.toml's
+	Roles map[string]string `toml:"roles"`
+	// RefRoles are domain roles, like java:method, that resolve through the
+	// ref and intersphinx maps like :ref: does, in addition to DefaultRefRoles
+	RefRoles []string `toml:"ref_roles"`
+	// RefAliases renames ref targets, replacing each key at the start of a
+	// target's name with its value
+	RefAliases map[string]string `toml:"ref_aliases"`
+	// CaseInsensitiveRefs resolves refs whose target matches one only when
+	// ignoring case, warning about them rather than failing
+	CaseInsensitiveRefs bool `toml:"case_insensitive_refs"`
+	// AllowedSchemes are the schemes links may use, DefaultAllowedSchemes if
+	// empty
+	AllowedSchemes []string `toml:"allowed_schemes"`
+	// TargetNaming is the project's naming convention for ref targets
+	TargetNaming TargetNaming `toml:"target_naming"`
+	// Soft404 tunes how links to pages that return 200 but say they weren't
+	// found are detected
+	Soft404 Soft404 `toml:"soft_404"`
+	// LoginWalls tunes how links that redirect to a login or consent page are
+	// detected
+	LoginWalls LoginWalls `toml:"login_walls"`
+	// Render is where anchors are checked after rendering pages in headless
+	// Chrome
+	Render Render `toml:"render"`
+	// Plugins are executables that run the project's own checks
+	Plugins []Plugin `toml:"plugins"`
+	// SkipDomains are domains whose links aren't checked for now
+	SkipDomains []SkipDomain `toml:"skip_domains"`
+	// SharedSources are where sharedincludes are fetched from, in addition
+	// to snooty.toml's sharedinclude_root
+	SharedSources []SharedSource `toml:"shared_sources"`
+}
+
+// SharedSource is where the sharedincludes whose paths start with Prefix are
+// read from, like a second shared repo, or a local checkout of one whose
+// changes aren't published yet.
+type SharedSource struct {
+	// Prefix is removed from the sharedinclude's path, and the rest is read
+	// from Root. An empty Prefix matches every sharedinclude.
+	Prefix string `toml:"prefix"`
+	// Root is a url, or a directory relative to the project
+	Root string `toml:"root"`
+}
+
+// Local reports whether the source is a directory rather than a url.
+func (s SharedSource) Local() bool {
+	return !strings.HasPrefix(s.Root, "http://") && !strings.HasPrefix(s.Root, "https://")
+}
+
+// SkipDomain stops links to a domain that's known to be failing from being
+// checked until a date, so a run isn't failed by an outage out of the
+// project's hands.
+type SkipDomain struct {
+	// Domain, including its subdomains, to skip links to
+	Domain string `toml:"domain"`
+	// Until is the last day links to Domain are skipped
+	Until time.Time `toml:"until"`
+	// Reason is why Domain is skipped, like a link to the outage
+	Reason string `toml:"reason"`
+}
+
+// Expired reports whether the skip has ended by now.
+func (s SkipDomain) Expired(now time.Time) bool {
+	return !now.Before(s.Until.AddDate(0, 0, 1))
+}
+
+// Plugin is an executable that's given every file checked, with what checker
+// parsed from it, and reports problems it finds, like house-style rules.
+type Plugin struct {
+	// Name is what the plugin's findings are reported as coming from
+	Name string `toml:"name"`
+	// Command is the executable and its arguments, run from the project
+	Command []string `toml:"command"`
+}
+
+// Render lists the domains whose pages are built by scripts, so anchors in
+// links to them are checked against the page headless Chrome renders.
+type Render struct {
+	// Domains, including their subdomains, to render pages of
+	Domains []string `toml:"domains"`
+	// Chrome is the Chrome or Chromium binary, found on the PATH if empty
+	Chrome string `toml:"chrome"`
+}
+
+// LoginWalls silences checker's detection of links that redirect to a login
+// or consent page for known cases.
+type LoginWalls struct {
+	// Allow are domains, including their subdomains, whose links may
+	// redirect to a login or consent page
+	Allow []string `toml:"allow"`
+}
+
+// Soft404 adds to checker's heuristics for pages that return 200 but say
+// they weren't found.
+type Soft404 struct {
+	// Phrases mark a page as not found wherever they appear in it
+	Phrases []string `toml:"phrases"`
+}
+
+// TargetNaming allows characters in ref target names that checker warns
+// about by default, since they make anchors easy to get wrong.
+type TargetNaming struct {
+	Spaces      bool `toml:"spaces"`
+	Uppercase   bool `toml:"uppercase"`
+	Underscores bool `toml:"underscores"`
+}
+
+// DefaultAllowedSchemes are the schemes links may use unless the project
+// lists its own.
+var DefaultAllowedSchemes = []string{"http", "https", "mailto"}
+
+// DefaultRefRoles are the domain roles that are always checked like :ref:.
+var DefaultRefRoles = []string{"py:meth", "py:class"}
+
+func NewCheckerConfig(input []byte) (*CheckerConfig, error) {
+	var cfg CheckerConfig
+	_, err := toml.Decode(string(input), &cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, skip := range cfg.SkipDomains {
+		if skip.Domain == "" || skip.Until.IsZero() {
+			return nil, fmt.Errorf("every skip_domains entry needs a domain and the date it's skipped until")
+		}
+	}
+	for _, source := range cfg.SharedSources {
+		if source.Root == "" {
+			return nil, fmt.Errorf("every shared_sources entry needs a root")
+		}
+	}
+	for name, template := range cfg.Roles {
+		if strings.Count(template, "%s") != 1 {
+			return nil, fmt.Errorf("the url template for role %s must contain %%s exactly once, got %q", name, template)
+		}
+	}
+	return &cfg, nil
+}
+
+// RefRoleNames returns DefaultRefRoles and the configured ref roles.
+func (cfg *CheckerConfig) RefRoleNames() []string {
+	return append(append([]string{}, DefaultRefRoles...), cfg.RefRoles...)
+}
+
+// IsRefRole reports whether name is :ref: or a domain role checked like it.
+func (cfg *CheckerConfig) IsRefRole(name string) bool {
+	if name == "ref" {
+		return true
+	}
+	for _, refRole := range cfg.RefRoleNames() {
+		if name == refRole {
+			return true
+		}
+	}
+	return false
+}
+
+// TargetNameProblems describes what's wrong with a ref target's name under
+// the project's naming convention, like "contains uppercase", if anything.
+func (cfg *CheckerConfig) TargetNameProblems(name string) []string {
+	problems := make([]string, 0)
+	if !cfg.TargetNaming.Spaces && strings.ContainsAny(name, " \t") {
+		problems = append(problems, "contains spaces")
+	}
+	if !cfg.TargetNaming.Uppercase && strings.ToLower(name) != name {
+		problems = append(problems, "contains uppercase")
+	}
+	if !cfg.TargetNaming.Underscores && strings.Contains(name, "_") {
+		problems = append(problems, "contains underscores")
+	}
+	return problems
+}
+
+// SchemeProblem describes why a link target's scheme isn't allowed, like a
+// file: link that only works on the writer's machine, or returns "" if it is
+// or the target is relative.
+func (cfg *CheckerConfig) SchemeProblem(target string) string {
+	allowed := cfg.AllowedSchemes
+	if len(allowed) == 0 {
+		allowed = DefaultAllowedSchemes
+	}
+	if strings.HasPrefix(target, "//") {
+		return fmt.Sprintf("%s is protocol-relative, so it takes the scheme of wherever the page is read; give it one of %s", target, strings.Join(allowed, ", "))
+	}
+	scheme, _, ok := strings.Cut(target, ":")
+	// a single letter is more likely a Windows drive than a scheme
+	if !ok || len(scheme) < 2 || strings.IndexFunc(scheme, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.')
+	}) >= 0 {
+		return ""
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(scheme, a) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s uses %s:, which isn't one of the allowed schemes, %s", target, strings.ToLower(scheme), strings.Join(allowed, ", "))
+}
+
+// SkippedDomain returns the unexpired skip of host's domain, if there is one.
+func (cfg *CheckerConfig) SkippedDomain(host string, now time.Time) (SkipDomain, bool) {
+	host = strings.ToLower(host)
+	for _, skip := range cfg.SkipDomains {
+		domain := strings.ToLower(strings.TrimPrefix(skip.Domain, "."))
+		if (host == domain || strings.HasSuffix(host, "."+domain)) && !skip.Expired(now) {
+			return skip, true
+		}
+	}
+	return SkipDomain{}, false
+}
+
+// SharedSource returns where the sharedinclude at path is read from, the
+// configured source with the longest matching prefix or else root, and the
+// rest of path under it.
+func (cfg *CheckerConfig) SharedSource(path string, root string) (SharedSource, string) {
+	found := SharedSource{Root: root}
+	matched := false
+	for _, source := range cfg.SharedSources {
+		if strings.HasPrefix(path, source.Prefix) && (!matched || len(source.Prefix) > len(found.Prefix)) {
+			found, matched = source, true
+		}
+	}
+	return found, strings.TrimPrefix(path, found.Prefix)
+}
+
+// ConventionalTargetName is name with the characters the project's naming
+// convention doesn't allow replaced, spaces and underscores by hyphens.
+func (cfg *CheckerConfig) ConventionalTargetName(name string) string {
+	if !cfg.TargetNaming.Spaces {
+		name = strings.Join(strings.Fields(name), "-")
+	}
+	if !cfg.TargetNaming.Uppercase {
+		name = strings.ToLower(name)
+	}
+	if !cfg.TargetNaming.Underscores {
+		name = strings.ReplaceAll(name, "_", "-")
+	}
+	return name
+}
+
+// Replacement returns the replacement for a deprecated ref target or url.
+// Urls match with or without a trailing slash.
+func (cfg *CheckerConfig) Replacement(target string) (string, bool) {
+	if replacement, ok := cfg.Deprecated[target]; ok {
+		return replacement, true
+	}
+	if strings.HasSuffix(target, "/") {
+		replacement, ok := cfg.Deprecated[strings.TrimSuffix(target, "/")]
+		return replacement, ok
+	}
+	replacement, ok := cfg.Deprecated[target+"/"]
+	return replacement, ok
+}
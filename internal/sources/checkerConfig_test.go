@@ -0,0 +1,212 @@
+package sources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const checkerConfigInput = `
+rstspec_version = "v0.13.7"
+case_insensitive_refs = true
+ref_roles = ["java:method", "java:class"]
+
+[ref_aliases]
+"install-old" = "install-new"
+
+[roles]
+jira = "https://jira.mongodb.org/browse/%s"
+
+[deprecated]
+"nodejs-ssl" = "nodejs-tls"
+"https://docs.mongodb.com/manual/core/ssl/" = "https://www.mongodb.com/docs/manual/core/security-transport-encryption/"
+"https://mongodb.github.io/node-mongodb-native/3.6" = "https://mongodb.github.io/node-mongodb-native/4.0"
+`
+
+func TestCheckerConfigEmpty(t *testing.T) {
+	cfg, err := NewCheckerConfig(nil)
+	assert.NoError(t, err)
+	_, ok := cfg.Replacement("anything")
+	assert.False(t, ok)
+	assert.Equal(t, "", cfg.RstSpecVersion)
+}
+
+func TestCheckerConfigRstSpecVersion(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(checkerConfigInput))
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.13.7", cfg.RstSpecVersion)
+}
+
+func TestCheckerConfigReplacement(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(checkerConfigInput))
+	if err != nil {
+		t.Fatalf("error parsing toml in test\n: %v", err)
+	}
+
+	cases := []struct {
+		target   string
+		expected string
+		ok       bool
+	}{{
+		target:   "nodejs-ssl",
+		expected: "nodejs-tls",
+		ok:       true,
+	}, {
+		target:   "https://docs.mongodb.com/manual/core/ssl",
+		expected: "https://www.mongodb.com/docs/manual/core/security-transport-encryption/",
+		ok:       true,
+	}, {
+		target:   "https://mongodb.github.io/node-mongodb-native/3.6/",
+		expected: "https://mongodb.github.io/node-mongodb-native/4.0",
+		ok:       true,
+	}, {
+		target:   "nodejs-tls",
+		expected: "",
+		ok:       false,
+	}}
+
+	for _, c := range cases {
+		actual, ok := cfg.Replacement(c.target)
+		assert.Equal(t, c.ok, ok, "Replacement(%q) found should be %v", c.target, c.ok)
+		assert.Equal(t, c.expected, actual, "Replacement(%q) should return %s, got %s", c.target, c.expected, actual)
+	}
+}
+
+func TestCheckerConfigRoles(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(checkerConfigInput))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"jira": "https://jira.mongodb.org/browse/%s"}, cfg.Roles)
+
+	_, err = NewCheckerConfig([]byte("[roles]\njira = \"https://jira.mongodb.org/browse/\"\n"))
+	assert.Error(t, err)
+}
+
+func TestCheckerConfigRefRoles(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(checkerConfigInput))
+	assert.NoError(t, err)
+
+	for _, name := range []string{"ref", "py:meth", "py:class", "java:method", "java:class"} {
+		assert.True(t, cfg.IsRefRole(name), "%s should be a ref role", name)
+	}
+	for _, name := range []string{"doc", "java:field", "guilabel"} {
+		assert.False(t, cfg.IsRefRole(name), "%s shouldn't be a ref role", name)
+	}
+
+	empty, err := NewCheckerConfig(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultRefRoles, empty.RefRoleNames())
+}
+
+func TestCheckerConfigRefAliases(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(checkerConfigInput))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"install-old": "install-new"}, cfg.RefAliases)
+}
+
+func TestCheckerConfigCaseInsensitiveRefs(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte(checkerConfigInput))
+	assert.NoError(t, err)
+	assert.True(t, cfg.CaseInsensitiveRefs)
+
+	cfg, err = NewCheckerConfig(nil)
+	assert.NoError(t, err)
+	assert.False(t, cfg.CaseInsensitiveRefs)
+}
+
+func TestCheckerConfigTargetNaming(t *testing.T) {
+	cfg, err := NewCheckerConfig(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.TargetNameProblems("install-mongodb-4.4"))
+	assert.Equal(t, []string{"contains spaces", "contains uppercase", "contains underscores"}, cfg.TargetNameProblems("Install mongodb_4.4"))
+	assert.Equal(t, "install-mongodb-4.4", cfg.ConventionalTargetName("Install mongodb_4.4"))
+
+	cfg, err = NewCheckerConfig([]byte("[target_naming]\nunderscores = true\n"))
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.TargetNameProblems("install_mongodb"))
+	assert.Equal(t, []string{"contains uppercase"}, cfg.TargetNameProblems("Install_mongodb"))
+	assert.Equal(t, "install_mongodb", cfg.ConventionalTargetName("Install_mongodb"))
+}
+
+func TestCheckerConfigAllowedSchemes(t *testing.T) {
+	cfg, err := NewCheckerConfig(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "", cfg.SchemeProblem("https://www.mongodb.com"))
+	assert.Equal(t, "", cfg.SchemeProblem("MAILTO:docs@example.com"))
+	assert.Equal(t, "", cfg.SchemeProblem("/install"))
+	assert.Equal(t, "", cfg.SchemeProblem("{+api+}/classes"))
+	assert.Equal(t, "ftp://ftp.example.com/faq.txt uses ftp:, which isn't one of the allowed schemes, http, https, mailto", cfg.SchemeProblem("ftp://ftp.example.com/faq.txt"))
+	assert.Equal(t, "//example.com is protocol-relative, so it takes the scheme of wherever the page is read; give it one of http, https, mailto", cfg.SchemeProblem("//example.com"))
+
+	cfg, err = NewCheckerConfig([]byte("allowed_schemes = [\"https\", \"tel\"]\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "", cfg.SchemeProblem("tel:+1-212-555-0100"))
+	assert.Equal(t, "http://example.com uses http:, which isn't one of the allowed schemes, https, tel", cfg.SchemeProblem("http://example.com"))
+}
+
+func TestCheckerConfigSoft404(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte("[soft_404]\nphrases = [\"Sorry, we couldn't find that page\"]\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Sorry, we couldn't find that page"}, cfg.Soft404.Phrases)
+}
+
+func TestCheckerConfigLoginWalls(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte("[login_walls]\nallow = [\"wiki.example.com\"]\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"wiki.example.com"}, cfg.LoginWalls.Allow)
+}
+
+func TestCheckerConfigRender(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte("[render]\ndomains = [\"spa.example.com\"]\nchrome = \"/usr/bin/chromium\"\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, Render{Domains: []string{"spa.example.com"}, Chrome: "/usr/bin/chromium"}, cfg.Render)
+}
+
+func TestCheckerConfigInvalid(t *testing.T) {
+	_, err := NewCheckerConfig([]byte("[deprecated\n"))
+	assert.Error(t, err)
+}
+
+func TestCheckerConfigPlugins(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte("[[plugins]]\nname = \"trademarks\"\ncommand = [\"./scripts/trademarks.py\", \"--strict\"]\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []Plugin{{Name: "trademarks", Command: []string{"./scripts/trademarks.py", "--strict"}}}, cfg.Plugins)
+}
+
+func TestCheckerConfigSkipDomains(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte("[[skip_domains]]\ndomain = \"example.com\"\nuntil = 2026-11-01\nreason = \"down for a migration\"\n"))
+	assert.NoError(t, err)
+	assert.Len(t, cfg.SkipDomains, 1)
+	assert.Equal(t, "down for a migration", cfg.SkipDomains[0].Reason)
+
+	skip, ok := cfg.SkippedDomain("docs.Example.com", time.Date(2026, 11, 1, 23, 0, 0, 0, time.Local))
+	assert.True(t, ok)
+	assert.Equal(t, "example.com", skip.Domain)
+	_, ok = cfg.SkippedDomain("example.com", time.Date(2026, 11, 2, 0, 0, 0, 0, time.Local))
+	assert.False(t, ok)
+	_, ok = cfg.SkippedDomain("notexample.com", time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local))
+	assert.False(t, ok)
+
+	_, err = NewCheckerConfig([]byte("[[skip_domains]]\ndomain = \"example.com\"\n"))
+	assert.Error(t, err)
+}
+
+func TestCheckerConfigSharedSources(t *testing.T) {
+	cfg, err := NewCheckerConfig([]byte("[[shared_sources]]\nprefix = \"atlas/\"\nroot = \"https://example.com/atlas/\"\n\n[[shared_sources]]\nprefix = \"atlas/cli/\"\nroot = \"../cli-shared/\"\n"))
+	assert.NoError(t, err)
+
+	source, rest := cfg.SharedSource("atlas/cli/install.rst", "https://example.com/shared/")
+	assert.Equal(t, "../cli-shared/", source.Root)
+	assert.True(t, source.Local())
+	assert.Equal(t, "install.rst", rest)
+	source, rest = cfg.SharedSource("atlas/connect.rst", "https://example.com/shared/")
+	assert.Equal(t, "https://example.com/atlas/", source.Root)
+	assert.False(t, source.Local())
+	assert.Equal(t, "connect.rst", rest)
+	source, rest = cfg.SharedSource("drivers/connect.rst", "https://example.com/shared/")
+	assert.Equal(t, "https://example.com/shared/", source.Root)
+	assert.Equal(t, "drivers/connect.rst", rest)
+
+	_, err = NewCheckerConfig([]byte("[[shared_sources]]\nprefix = \"atlas/\"\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type openAPIDocument struct {
+	OpenAPI    string                 `yaml:"openapi"`
+	Swagger    string                 `yaml:"swagger"`
+	Info       map[string]interface{} `yaml:"info"`
+	Paths      map[string]interface{} `yaml:"paths"`
+	Webhooks   map[string]interface{} `yaml:"webhooks"`
+	Components map[string]interface{} `yaml:"components"`
+}
+
+// ValidateOpenAPI checks that input, in json or yaml, looks like an OpenAPI 3
+// or Swagger 2 document. It doesn't validate the schema of each operation, only
+// what's needed for the openapi directive to render something.
+func ValidateOpenAPI(input []byte) error {
+	var doc openAPIDocument
+	if err := yaml.Unmarshal(input, &doc); err != nil {
+		return fmt.Errorf("not valid json or yaml: %v", err)
+	}
+
+	switch {
+	case strings.HasPrefix(doc.OpenAPI, "3."):
+	case doc.Swagger == "2.0":
+	case doc.OpenAPI == "" && doc.Swagger == "":
+		return errors.New("missing openapi version field")
+	default:
+		return fmt.Errorf("unsupported openapi version %q", doc.OpenAPI+doc.Swagger)
+	}
+
+	if doc.Info == nil {
+		return errors.New("missing info object")
+	}
+	for _, field := range []string{"title", "version"} {
+		if _, ok := doc.Info[field]; !ok {
+			return fmt.Errorf("info object is missing %s", field)
+		}
+	}
+
+	// 3.1 documents may only define webhooks or components
+	if doc.Paths == nil && !(strings.HasPrefix(doc.OpenAPI, "3.1") && (doc.Webhooks != nil || doc.Components != nil)) {
+		return errors.New("missing paths object")
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateOpenAPI(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		valid bool
+	}{{
+		name: "openapi 3 yaml",
+		input: `openapi: 3.0.1
+info:
+  title: Atlas Admin API
+  version: "1.0"
+paths:
+  /groups:
+    get:
+      summary: Return all projects
+`,
+		valid: true,
+	}, {
+		name:  "swagger 2 json",
+		input: `{"swagger": "2.0", "info": {"title": "Data API", "version": "1"}, "paths": {}}`,
+		valid: true,
+	}, {
+		name:  "openapi 3.1 webhooks only",
+		input: `{"openapi": "3.1.0", "info": {"title": "Hooks", "version": "1"}, "webhooks": {}}`,
+		valid: true,
+	}, {
+		name:  "not yaml",
+		input: "openapi: [3.0.1",
+		valid: false,
+	}, {
+		name:  "html error page",
+		input: "<html><body>404 Not Found</body></html>",
+		valid: false,
+	}, {
+		name:  "missing version",
+		input: `{"info": {"title": "Data API", "version": "1"}, "paths": {}}`,
+		valid: false,
+	}, {
+		name:  "unsupported version",
+		input: `{"swagger": "1.2", "info": {"title": "Data API", "version": "1"}, "paths": {}}`,
+		valid: false,
+	}, {
+		name:  "missing info title",
+		input: `{"openapi": "3.0.0", "info": {"version": "1"}, "paths": {}}`,
+		valid: false,
+	}, {
+		name:  "missing paths",
+		input: `{"openapi": "3.0.0", "info": {"title": "Data API", "version": "1"}}`,
+		valid: false,
+	}}
+
+	for _, c := range cases {
+		err := ValidateOpenAPI([]byte(c.input))
+		assert.Equal(t, c.valid, err == nil, "%s: ValidateOpenAPI returned %v", c.name, err)
+	}
+}
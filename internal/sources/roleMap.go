@@ -1,10 +1,10 @@
 package sources
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/BurntSushi/toml"
-	log "github.com/sirupsen/logrus"
 )
 
 type RawRstSpec struct {
@@ -17,7 +17,13 @@ type RstSpec struct {
 	Roles      RolesMap
 	RawRoles   map[string]bool
 	Directives map[string]bool
-	RstObjects map[string]bool
+	// DirectiveOptions are the options of the directives that list them, by
+	// directive
+	DirectiveOptions map[string]map[string]bool
+	RstObjects       map[string]bool
+	// Callables are the rstobjects, like method, referenced by name without
+	// their arguments
+	Callables map[string]bool
 }
 
 // RolesMap contains roles from rstspec.toml
@@ -26,7 +32,7 @@ type RolesMap map[string]string
 // OtherRoleMap contains other roles from rstspec.toml, like guilabel
 type OtherRoleMap map[string]string
 
-func NewRoleMap(input []byte) *RstSpec {
+func NewRoleMap(input []byte) (*RstSpec, error) {
 
 	var rstSpec RstSpec
 
@@ -34,15 +40,13 @@ func NewRoleMap(input []byte) *RstSpec {
 	var rawmap RawRstSpec
 	_, err := toml.Decode(string(input), &rawmap)
 	if err != nil {
-		log.Fatalf("error: %v", err)
+		return nil, err
 	}
 
-	// log.SetLevel(log.DebugLevel)
-	// log.Debugf("rstspec: %v", rawmap.Directives)
 	rstSpec.populateRoles(&rawmap)
 	rstSpec.populateDirectives(&rawmap)
 	rstSpec.populateRstObjects(&rawmap)
-	return &rstSpec
+	return &rstSpec, nil
 }
 
 func (r *RstSpec) populateRoles(raw *RawRstSpec) {
@@ -78,20 +82,66 @@ func (r *RstSpec) populateRoles(raw *RawRstSpec) {
 func (r *RstSpec) populateDirectives(raw *RawRstSpec) {
 	r.Directives = make(map[string]bool, len(raw.Directives))
 
-	for k := range raw.Directives {
+	r.DirectiveOptions = make(map[string]map[string]bool)
+
+	for k, v := range raw.Directives {
 		r.Directives[k] = true
+		fields, _ := v.(map[string]interface{})
+		options, _ := fields["options"].(map[string]interface{})
+		if len(options) == 0 {
+			continue
+		}
+		r.DirectiveOptions[k] = make(map[string]bool, len(options))
+		for option := range options {
+			r.DirectiveOptions[k][option] = true
+		}
 	}
 }
 
 func (r *RstSpec) populateRstObjects(raw *RawRstSpec) {
 	r.RstObjects = make(map[string]bool, len(raw.RstObjects))
+	r.Callables = make(map[string]bool)
+
+	// objects can be referenced with or without their domain
+	for k, v := range raw.RstObjects {
+		names := []string{k}
+		if target := strings.Split(k, ":"); len(target) > 1 {
+			names = append(names, target[1])
+		}
+		fields, _ := v.(map[string]interface{})
+		for _, name := range names {
+			r.RstObjects[name] = true
+			if fields["type"] == "callable" {
+				r.Callables[name] = true
+			}
+		}
+	}
+}
 
-	for k := range raw.RstObjects {
-		target := strings.Split(k, ":")
-		if len(target) > 1 {
-			r.RstObjects[target[1]] = true
-		} else {
-			r.RstObjects[k] = true
+// AddRoles adds link roles, mapping role names to url templates, replacing any
+// rstspec.toml defines with the same names.
+func (r *RstSpec) AddRoles(roles map[string]string) {
+	for name, template := range roles {
+		r.Roles[name] = template
+		r.RawRoles[name] = true
+	}
+}
+
+// RoleNames returns every role name rstspec.toml knows about, sorted.
+func (r *RstSpec) RoleNames() []string {
+	seen := make(map[string]bool, len(r.RawRoles)+len(r.RstObjects))
+	for _, names := range []map[string]bool{r.RawRoles, r.RstObjects} {
+		for name := range names {
+			seen[name] = true
 		}
 	}
+	for name := range r.Roles {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
@@ -21,6 +21,10 @@ deprecated = true
 argument_type = "string"
 content_type = "block"
 
+[directive.meta]
+options.keywords = "string"
+options.description = "string"
+
 [foo]
 rfc = "https://tools.ietf.org/html/%s"
 
@@ -70,14 +74,54 @@ fields = [["returns", "Returns"]]
 
 func TestRoleMap(t *testing.T) {
 
-	roleMap := NewRoleMap([]byte(rstSpec))
+	roleMap, err := NewRoleMap([]byte(rstSpec))
+	assert.NoError(t, err)
 
 	expected := &RstSpec{
 		Roles:      map[string]string{"rfc": "https://tools.ietf.org/html/%s", "wikipedia": "https://en.wikipedia.org/wiki/%s"},
 		RawRoles:   map[string]bool{"abbr": true, "file": true, "icon-fa4": true, "rfc": true, "wikipedia": true},
-		Directives: map[string]bool{"div": true, "container": true, "default-domain": true},
-		RstObjects: map[string]bool{"class": true, "meth": true, "func": true, "projection": true, "method": true, "authrole": true, "authaction": true},
+		Directives: map[string]bool{"div": true, "container": true, "default-domain": true, "meta": true},
+		DirectiveOptions: map[string]map[string]bool{
+			"meta": {"keywords": true, "description": true},
+		},
+		RstObjects: map[string]bool{
+			"class": true, "meth": true, "func": true, "projection": true, "method": true, "authrole": true, "authaction": true,
+			"py:class": true, "py:meth": true, "js:func": true, "mongodb:projection": true, "mongodb:method": true, "mongodb:authrole": true, "mongodb:authaction": true,
+		},
+		Callables: map[string]bool{"meth": true, "py:meth": true, "method": true, "mongodb:method": true},
 	}
 
 	assert.EqualValues(t, expected, roleMap)
 }
+
+func TestRoleMapRejectsInvalidToml(t *testing.T) {
+	_, err := NewRoleMap([]byte("[role\n"))
+	assert.Error(t, err)
+}
+
+func TestAddRoles(t *testing.T) {
+	roleMap, err := NewRoleMap([]byte(rstSpec))
+	assert.NoError(t, err)
+	roleMap.AddRoles(map[string]string{
+		"rfc":  "https://datatracker.ietf.org/doc/html/%s",
+		"jira": "https://jira.mongodb.org/browse/%s",
+	})
+
+	assert.Equal(t, "https://datatracker.ietf.org/doc/html/%s", roleMap.Roles["rfc"])
+	assert.Equal(t, "https://jira.mongodb.org/browse/%s", roleMap.Roles["jira"])
+	assert.True(t, roleMap.RawRoles["jira"])
+	assert.Equal(t, "https://en.wikipedia.org/wiki/%s", roleMap.Roles["wikipedia"])
+}
+
+func TestRoleNames(t *testing.T) {
+	roleMap, err := NewRoleMap([]byte(rstSpec))
+	assert.NoError(t, err)
+
+	expected := []string{
+		"abbr", "authaction", "authrole", "class", "file", "func", "icon-fa4", "js:func", "meth", "method",
+		"mongodb:authaction", "mongodb:authrole", "mongodb:method", "mongodb:projection", "projection",
+		"py:class", "py:meth", "rfc", "wikipedia",
+	}
+
+	assert.Equal(t, expected, roleMap.RoleNames())
+}
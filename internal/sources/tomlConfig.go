@@ -1,6 +1,7 @@
 package sources
 
 import (
+	"fmt"
 	"regexp"
 
 	"github.com/BurntSushi/toml"
@@ -14,6 +15,9 @@ type TomlConfig struct {
 	Constants   map[string]string `toml:"constants"`
 	Intersphinx []string          `toml:"intersphinx"`
 	SharedPath  string            `toml:"sharedinclude_root"`
+	Extlinks    map[string]string `toml:"extlinks"`
+	// MDXRouteBase is the docusaurus routeBasePath MDX docs are served under
+	MDXRouteBase string `toml:"mdx_route_base"`
 }
 
 func NewTomlConfig(input []byte) (*TomlConfig, error) {
@@ -24,10 +28,33 @@ func NewTomlConfig(input []byte) (*TomlConfig, error) {
 	}
 
 	cfg.Constants = cfg.resolveConstants()
+	cfg.Extlinks = cfg.resolveExtlinks()
+	if cfg.MDXRouteBase == "" {
+		cfg.MDXRouteBase = "/docs"
+	}
 
 	return &cfg, nil
 }
 
+// ExtlinkURL expands the extlinks template configured for role with target.
+// The bool is false if the project doesn't define an extlink for role.
+func (cfg *TomlConfig) ExtlinkURL(role, target string) (string, bool) {
+	template, ok := cfg.Extlinks[role]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf(template, target), true
+}
+
+// extlinks templates may use constants, like {+api+}/issues/%s
+func (cfg *TomlConfig) resolveExtlinks() map[string]string {
+	newMap := make(map[string]string, len(cfg.Extlinks))
+	for k, v := range cfg.Extlinks {
+		newMap[k] = descendConstants(cfg.Constants, v, 0)
+	}
+	return newMap
+}
+
 func (cfg *TomlConfig) resolveConstants() map[string]string {
 	newMap := make(map[string]string, len(cfg.Constants))
 	re := regexp.MustCompile(`\{\+([\w\s\-\.\d_=+!@#$%^&*(\)]*)\+\}`)
@@ -30,6 +30,11 @@ api = "https://pkg.go.dev/go.mongodb.org/mongo-driver@{+version+}"
 foo = "{+bar+}@{+version+}"
 bar = "{+docs-branch+}"
 baz = "baz+{+foo+}"
+
+[extlinks]
+issue = "https://jira.mongodb.org/browse/%s"
+pr = "https://github.com/mongodb/docs-golang/pull/%s"
+api-link = "{+api+}/%s"
 `
 
 func TestSnootyToml(t *testing.T) {
@@ -57,3 +62,43 @@ func TestSnootyToml(t *testing.T) {
 	}
 	assert.EqualValues(t, constants, cfg.Constants, "expected constants to be %v, got %v\n", constants, cfg.Constants)
 }
+
+func TestExtlinks(t *testing.T) {
+	cfg, err := NewTomlConfig([]byte(tomlConfigInput))
+	if err != nil {
+		t.Fatalf("error parsing toml in test\n: %v", err)
+	}
+
+	cases := []struct {
+		role     string
+		target   string
+		expected string
+		ok       bool
+	}{{
+		role:     "issue",
+		target:   "DOCSP-1234",
+		expected: "https://jira.mongodb.org/browse/DOCSP-1234",
+		ok:       true,
+	}, {
+		role:     "pr",
+		target:   "42",
+		expected: "https://github.com/mongodb/docs-golang/pull/42",
+		ok:       true,
+	}, {
+		role:     "api-link",
+		target:   "mongo",
+		expected: "https://pkg.go.dev/go.mongodb.org/mongo-driver@v1.8.0/mongo",
+		ok:       true,
+	}, {
+		role:     "nope",
+		target:   "42",
+		expected: "",
+		ok:       false,
+	}}
+
+	for _, c := range cases {
+		actual, ok := cfg.ExtlinkURL(c.role, c.target)
+		assert.Equal(t, c.ok, ok, "ExtlinkURL(%q, %q) found should be %v", c.role, c.target, c.ok)
+		assert.Equal(t, c.expected, actual, "ExtlinkURL(%q, %q) should return %s, got %s", c.role, c.target, c.expected, actual)
+	}
+}
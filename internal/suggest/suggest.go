@@ -0,0 +1,81 @@
+package suggest
+
+import (
+	"sort"
+	"strings"
+)
+
+// Levenshtein returns the edit distance between a and b.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+type match struct {
+	candidate string
+	distance  int
+}
+
+// Closest returns up to n candidates that are plausibly what target meant,
+// closest first. Candidates within a quarter of target's length in edits match,
+// as do candidates that target is a prefix of.
+func Closest(target string, candidates []string, n int) []string {
+	threshold := len(target) / 4
+	if threshold < 2 {
+		threshold = 2
+	}
+	matches := make([]match, 0)
+	for _, candidate := range candidates {
+		if candidate == target {
+			continue
+		}
+		diff := len(candidate) - len(target)
+		if diff <= threshold && -diff <= threshold {
+			if d := Levenshtein(target, candidate); d <= threshold {
+				matches = append(matches, match{candidate, d})
+				continue
+			}
+		}
+		if strings.HasPrefix(candidate, target) {
+			// rank long prefix matches after every close typo
+			matches = append(matches, match{candidate, threshold + 1})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].candidate < matches[j].candidate
+	})
+	closest := make([]string, 0, n)
+	for i := 0; i < len(matches) && i < n; i++ {
+		closest = append(closest, matches[i].candidate)
+	}
+	return closest
+}
@@ -0,0 +1,57 @@
+package suggest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"ref", "", 3},
+		{"ref", "ref", 0},
+		{"red", "ref", 1},
+		{"kitten", "sitting", 3},
+		{"gridfs-upload-file", "gridfs-upload-files", 1},
+		{"écrire", "ecrire", 1},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, Levenshtein(c.a, c.b), "Levenshtein(%q, %q)", c.a, c.b)
+	}
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{
+		"gridfs-create-bucket",
+		"gridfs-delete-bucket",
+		"gridfs-delete-files",
+		"gridfs-download-files",
+		"gridfs-upload-files",
+		"nodejs-aggregation-overview",
+	}
+
+	cases := []struct {
+		target   string
+		expected []string
+	}{{
+		target:   "gridfs-upload-file",
+		expected: []string{"gridfs-upload-files"},
+	}, {
+		target:   "gridfs-delete-buckets",
+		expected: []string{"gridfs-delete-bucket", "gridfs-create-bucket", "gridfs-delete-files"},
+	}, {
+		target:   "nodejs-aggregation",
+		expected: []string{"nodejs-aggregation-overview"},
+	}, {
+		target:   "completely-unrelated",
+		expected: []string{},
+	}}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, Closest(c.target, candidates, 3), "Closest(%q)", c.target)
+	}
+}
@@ -0,0 +1,395 @@
+// Package tracing records the stages of a run and its network requests as
+// OpenTelemetry spans, to export them with OTLP over HTTP or summarize them,
+// so a slow run can be explained stage by stage.
+//
+// checker's pipeline runs one stage at a time, so rather than threading a
+// context through it, a Tracer tracks the current stage and parents requests
+// to it. A nil *Tracer records nothing, so callers don't need to check whether
+// tracing is enabled.
+package tracing
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/terakilobyte/checker/internal/utils"
+)
+
+// Span is a timed operation within a run.
+type Span struct {
+	tracer     *Tracer
+	id         string
+	parent     string
+	name       string
+	start      time.Time
+	end        time.Time
+	attributes map[string]string
+	failed     bool
+}
+
+// Tracer records the spans of a single run.
+type Tracer struct {
+	mu      sync.Mutex
+	traceID string
+	root    *Span
+	stage   *Span
+	spans   []*Span
+}
+
+func randomID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// NewTracer starts the trace of a run, with a root span called name.
+func NewTracer(name string) *Tracer {
+	t := &Tracer{traceID: randomID(16)}
+	t.root = t.start(name, "")
+	return t
+}
+
+func (t *Tracer) start(name string, parent string) *Span {
+	s := &Span{tracer: t, id: randomID(8), parent: parent, name: name, start: time.Now(), attributes: make(map[string]string)}
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return s
+}
+
+// Stage ends the current stage, if any, and starts the next one.
+func (t *Tracer) Stage(name string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	previous := t.stage
+	t.mu.Unlock()
+	previous.End()
+	s := t.start(name, t.root.id)
+	t.mu.Lock()
+	t.stage = s
+	t.mu.Unlock()
+}
+
+// Start starts a span within the current stage.
+func (t *Tracer) Start(name string) *Span {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	parent := t.root.id
+	if t.stage != nil {
+		parent = t.stage.id
+	}
+	t.mu.Unlock()
+	return t.start(name, parent)
+}
+
+// SetAttribute annotates s, like with the url a request was for.
+func (s *Span) SetAttribute(key string, value string) {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	s.attributes[key] = value
+	s.tracer.mu.Unlock()
+}
+
+// Fail marks s as failed with err.
+func (s *Span) Fail(err error) {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	s.failed = true
+	s.attributes["error"] = err.Error()
+	s.tracer.mu.Unlock()
+}
+
+// End ends s. Ending a span more than once keeps the first end.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	if s.end.IsZero() {
+		s.end = time.Now()
+	}
+	s.tracer.mu.Unlock()
+}
+
+// Transport wraps next, recording a span for every request.
+func (t *Tracer) Transport(next http.RoundTripper) http.RoundTripper {
+	return utils.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		s := t.Start(req.Method + " " + req.URL.Hostname())
+		defer s.End()
+		s.SetAttribute("http.method", req.Method)
+		s.SetAttribute("http.url", req.URL.String())
+		resp, err := next.RoundTrip(req)
+		if err != nil {
+			s.Fail(err)
+			return resp, err
+		}
+		s.SetAttribute("http.status_code", strconv.Itoa(resp.StatusCode))
+		if resp.StatusCode >= 400 {
+			s.Fail(fmt.Errorf("%s returned a status of %d", req.URL, resp.StatusCode))
+		}
+		return resp, err
+	})
+}
+
+// End ends the current stage and the run.
+func (t *Tracer) End() {
+	if t == nil {
+		return
+	}
+	t.stage.End()
+	t.root.End()
+}
+
+// Timing is how long a stage or request took.
+type Timing struct {
+	Name     string
+	Duration time.Duration
+}
+
+func (s *Span) duration() time.Duration {
+	end := s.end
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return end.Sub(s.start)
+}
+
+// Stages returns how long each stage took, in the order they ran.
+func (t *Tracer) Stages() []Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stages := make([]Timing, 0)
+	for _, s := range t.spans {
+		if s.parent == t.root.id {
+			stages = append(stages, Timing{Name: s.name, Duration: s.duration()})
+		}
+	}
+	return stages
+}
+
+// Requests returns how long each request took, by url, slowest first.
+func (t *Tracer) Requests() []Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	requests := make([]Timing, 0)
+	for _, s := range t.spans {
+		if url, ok := s.attributes["http.url"]; ok {
+			requests = append(requests, Timing{Name: url, Duration: s.duration()})
+		}
+	}
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].Duration > requests[j].Duration
+	})
+	return requests
+}
+
+// HostTiming is how long the requests to a host took in total, and how long
+// a typical and a slow one took.
+type HostTiming struct {
+	Host     string
+	Requests int
+	Total    time.Duration
+	P50      time.Duration
+	P95      time.Duration
+	Slowest  time.Duration
+}
+
+// Hosts returns the time spent on each host's requests, most time first.
+func (t *Tracer) Hosts() []HostTiming {
+	byHost := make(map[string]*HostTiming)
+	durations := make(map[string][]time.Duration)
+	for _, request := range t.Requests() {
+		host := request.Name
+		if u, err := url.Parse(request.Name); err == nil {
+			host = u.Hostname()
+		}
+		h, ok := byHost[host]
+		if !ok {
+			h = &HostTiming{Host: host}
+			byHost[host] = h
+		}
+		h.Requests++
+		h.Total += request.Duration
+		if request.Duration > h.Slowest {
+			h.Slowest = request.Duration
+		}
+		durations[host] = append(durations[host], request.Duration)
+	}
+	hosts := make([]HostTiming, 0, len(byHost))
+	for host, h := range byHost {
+		// requests are slowest first
+		h.P50 = percentile(durations[host], 50)
+		h.P95 = percentile(durations[host], 95)
+		hosts = append(hosts, *h)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].Total != hosts[j].Total {
+			return hosts[i].Total > hosts[j].Total
+		}
+		return hosts[i].Host < hosts[j].Host
+	})
+	return hosts
+}
+
+// percentile is the nearest-rank percentile p of durations, which are sorted
+// slowest first.
+func percentile(durations []time.Duration, p int) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	rank := (p*len(durations) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return durations[len(durations)-rank]
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            struct {
+		Code int `json:"code,omitempty"`
+	} `json:"status"`
+}
+
+func attributes(values map[string]string, keys ...string) []otlpAttribute {
+	attrs := make([]otlpAttribute, 0, len(values))
+	if len(keys) == 0 {
+		for key := range values {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		if value, ok := values[key]; ok {
+			var attr otlpAttribute
+			attr.Key = key
+			attr.Value.StringValue = value
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+const (
+	spanKindInternal = 1
+	spanKindClient   = 3
+	statusError      = 2
+)
+
+// OTLP encodes the trace as an OTLP/JSON ExportTraceServiceRequest.
+func (t *Tracer) OTLP(service string) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spans := make([]otlpSpan, 0, len(t.spans))
+	for _, s := range t.spans {
+		end := s.end
+		if end.IsZero() {
+			end = time.Now()
+		}
+		span := otlpSpan{
+			TraceID:           t.traceID,
+			SpanID:            s.id,
+			ParentSpanID:      s.parent,
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+			Attributes:        attributes(s.attributes, "http.method", "http.url", "http.status_code", "error"),
+		}
+		if _, ok := s.attributes["http.url"]; ok {
+			span.Kind = spanKindClient
+		}
+		if s.failed {
+			span.Status.Code = statusError
+		}
+		spans = append(spans, span)
+	}
+
+	type scopeSpans struct {
+		Scope struct {
+			Name string `json:"name"`
+		} `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	type resourceSpans struct {
+		Resource struct {
+			Attributes []otlpAttribute `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	var rs resourceSpans
+	rs.Resource.Attributes = attributes(map[string]string{"service.name": service})
+	ss := scopeSpans{Spans: spans}
+	ss.Scope.Name = "checker"
+	rs.ScopeSpans = []scopeSpans{ss}
+
+	return json.Marshal(struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}{[]resourceSpans{rs}})
+}
+
+// exportClient gives up on a collector that doesn't answer, so a finished run
+// doesn't hang exporting its trace.
+var exportClient = &http.Client{Timeout: 30 * time.Second}
+
+// Export sends the trace to an OTLP/HTTP traces endpoint, like
+// http://localhost:4318/v1/traces, with extra headers for authentication.
+func (t *Tracer) Export(endpoint string, service string, headers map[string]string) error {
+	if t == nil {
+		return nil
+	}
+	body, err := t.OTLP(service)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := exportClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returned a status of %d", endpoint, resp.StatusCode)
+	}
+	return nil
+}
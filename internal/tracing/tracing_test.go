@@ -0,0 +1,136 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type decoded struct {
+	ResourceSpans []struct {
+		ScopeSpans []struct {
+			Spans []otlpSpan `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+func spansByName(t *testing.T, data []byte) map[string]otlpSpan {
+	var d decoded
+	assert.NoError(t, json.Unmarshal(data, &d))
+	spans := make(map[string]otlpSpan)
+	for _, s := range d.ResourceSpans[0].ScopeSpans[0].Spans {
+		spans[s.Name] = s
+	}
+	return spans
+}
+
+func TestTracer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	tracer := NewTracer("checker")
+	tracer.Stage("collect")
+	tracer.Stage("validate")
+	client := &http.Client{Transport: tracer.Transport(http.DefaultTransport)}
+	resp, err := client.Get(server.URL + "/missing")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	tracer.End()
+
+	data, err := tracer.OTLP("checker")
+	assert.NoError(t, err)
+	spans := spansByName(t, data)
+
+	assert.Len(t, spans, 4)
+	root := spans["checker"]
+	assert.Empty(t, root.ParentSpanID)
+	assert.Equal(t, root.SpanID, spans["collect"].ParentSpanID)
+	assert.Equal(t, root.SpanID, spans["validate"].ParentSpanID)
+	request := spans["GET 127.0.0.1"]
+	assert.Equal(t, spans["validate"].SpanID, request.ParentSpanID)
+	assert.Equal(t, root.TraceID, request.TraceID)
+	assert.Equal(t, spanKindClient, request.Kind)
+	assert.Equal(t, statusError, request.Status.Code)
+	assert.Equal(t, "http.method", request.Attributes[0].Key)
+	assert.Equal(t, "404", request.Attributes[2].Value.StringValue)
+	assert.LessOrEqual(t, spans["collect"].EndTimeUnixNano, spans["validate"].StartTimeUnixNano)
+}
+
+func TestNilTracer(t *testing.T) {
+	var tracer *Tracer
+	tracer.Stage("collect")
+	span := tracer.Start("request")
+	span.SetAttribute("http.url", "https://example.com")
+	span.End()
+	tracer.End()
+	assert.NoError(t, tracer.Export("http://localhost:4318/v1/traces", "checker", nil))
+}
+
+func TestExport(t *testing.T) {
+	var path, auth string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	tracer := NewTracer("checker")
+	tracer.End()
+
+	assert.NoError(t, tracer.Export(server.URL+"/v1/traces", "checker", map[string]string{"Authorization": "Bearer x"}))
+	assert.Equal(t, "/v1/traces", path)
+	assert.Equal(t, "Bearer x", auth)
+	assert.Contains(t, spansByName(t, body), "checker")
+}
+
+func timedSpan(tracer *Tracer, name string, parent string, seconds int, url string) *Span {
+	s := tracer.start(name, parent)
+	s.start = time.Unix(0, 0)
+	s.end = s.start.Add(time.Duration(seconds) * time.Second)
+	if url != "" {
+		s.attributes["http.url"] = url
+	}
+	return s
+}
+
+func TestTimings(t *testing.T) {
+	tracer := NewTracer("checker")
+	collect := timedSpan(tracer, "collect", tracer.root.id, 2, "")
+	validate := timedSpan(tracer, "validate", tracer.root.id, 30, "")
+	timedSpan(tracer, "GET a.com", validate.id, 5, "https://a.com/slow")
+	timedSpan(tracer, "GET a.com", validate.id, 1, "https://a.com/fast")
+	timedSpan(tracer, "GET b.com", validate.id, 4, "https://b.com/")
+	timedSpan(tracer, "GET c.com", collect.id, 3, "https://c.com/objects.inv")
+
+	assert.Equal(t, []Timing{{Name: "collect", Duration: 2 * time.Second}, {Name: "validate", Duration: 30 * time.Second}}, tracer.Stages())
+	assert.Equal(t, []Timing{
+		{Name: "https://a.com/slow", Duration: 5 * time.Second},
+		{Name: "https://b.com/", Duration: 4 * time.Second},
+		{Name: "https://c.com/objects.inv", Duration: 3 * time.Second},
+		{Name: "https://a.com/fast", Duration: 1 * time.Second},
+	}, tracer.Requests())
+	assert.Equal(t, []HostTiming{
+		{Host: "a.com", Requests: 2, Total: 6 * time.Second, P50: 1 * time.Second, P95: 5 * time.Second, Slowest: 5 * time.Second},
+		{Host: "b.com", Requests: 1, Total: 4 * time.Second, P50: 4 * time.Second, P95: 4 * time.Second, Slowest: 4 * time.Second},
+		{Host: "c.com", Requests: 1, Total: 3 * time.Second, P50: 3 * time.Second, P95: 3 * time.Second, Slowest: 3 * time.Second},
+	}, tracer.Hosts())
+}
+
+func TestPercentile(t *testing.T) {
+	durations := make([]time.Duration, 0, 20)
+	for i := 20; i > 0; i-- {
+		durations = append(durations, time.Duration(i)*time.Second)
+	}
+	assert.Equal(t, 10*time.Second, percentile(durations, 50))
+	assert.Equal(t, 19*time.Second, percentile(durations, 95))
+	assert.Equal(t, 20*time.Second, percentile(durations, 100))
+	assert.Equal(t, time.Duration(0), percentile(nil, 50))
+}
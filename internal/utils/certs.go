@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// certExpiries is when the certificate each host served expires, by host,
+// once RecordCertificates is called.
+var certExpiries sync.Map
+
+// RecordCertificates remembers when the certificate each host serves
+// expires, for CertificateExpiry. Call it before WrapTransport.
+func RecordCertificates() {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = &certTransport{next: next}
+}
+
+// certTransport records the certificate of every host it gets a response
+// from over TLS.
+type certTransport struct {
+	next http.RoundTripper
+}
+
+func (t *certTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		certExpiries.Store(req.URL.Hostname(), resp.TLS.PeerCertificates[0].NotAfter)
+	}
+	return resp, err
+}
+
+// CertificateExpiry is when the certificate host served expires, and whether
+// it served one since RecordCertificates was called.
+func CertificateExpiry(host string) (time.Time, bool) {
+	expiry, ok := certExpiries.Load(host)
+	if !ok {
+		return time.Time{}, false
+	}
+	return expiry.(time.Time), true
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordCertificates(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	defer func(transport http.RoundTripper) { client.Transport = transport }(client.Transport)
+	u, _ := url.Parse(server.URL)
+
+	client.Transport = server.Client().Transport
+	RecordCertificates()
+	_, ok := CertificateExpiry(u.Hostname())
+	assert.False(t, ok, "nothing is recorded before a request")
+
+	assert.True(t, CheckLink(server.URL).OK)
+	expiry, ok := CertificateExpiry(u.Hostname())
+	assert.True(t, ok)
+	assert.Equal(t, server.Certificate().NotAfter, expiry)
+	certExpiries.Delete(u.Hostname())
+}
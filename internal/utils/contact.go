@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+// telSeparators are the visual separators RFC 3966 allows between digits.
+const telSeparators = "-.()"
+
+// MailtoAddresses returns the addresses a mailto: link sends to, from its
+// path and its to= field, percent-decoded, as RFC 6068 has them.
+func MailtoAddresses(link string) ([]string, error) {
+	rest := strings.TrimPrefix(link, "mailto:")
+	to, query, _ := strings.Cut(rest, "?")
+	fields, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("its fields are malformed: %v", err)
+	}
+	addresses := make([]string, 0)
+	for _, list := range append([]string{to}, fields["to"]...) {
+		if list, err = url.PathUnescape(list); err != nil {
+			return nil, fmt.Errorf("it's malformed: %v", err)
+		}
+		for _, address := range strings.Split(list, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// CheckMailto reports whether every address in a mailto: link is a bare
+// RFC 5322 address, like docs@example.com.
+func CheckMailto(link string) error {
+	addresses, err := MailtoAddresses(link)
+	if err != nil {
+		return err
+	}
+	if len(addresses) == 0 {
+		return errors.New("it has no address")
+	}
+	for _, address := range addresses {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil || parsed.Address != address || parsed.Name != "" {
+			return fmt.Errorf("%s isn't an email address", address)
+		}
+	}
+	return nil
+}
+
+// CheckMailDomain reports whether the domain of address can receive mail: it
+// has an MX record, or failing that an address of its own.
+func CheckMailDomain(address string) error {
+	domain := address[strings.LastIndex(address, "@")+1:]
+	if mx, err := net.LookupMX(domain); err == nil && len(mx) > 0 {
+		return nil
+	}
+	if _, err := net.LookupHost(domain); err != nil {
+		return fmt.Errorf("%s doesn't accept mail: %w", domain, err)
+	}
+	return nil
+}
+
+// CheckTel reports whether a tel: link is a well-formed RFC 3966 number: a
+// global number, like +1-212-555-0100, or a local one with a phone-context.
+func CheckTel(link string) error {
+	number, params, _ := strings.Cut(strings.TrimPrefix(link, "tel:"), ";")
+	global := strings.HasPrefix(number, "+")
+	digits := 0
+	for _, r := range strings.TrimPrefix(number, "+") {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case strings.ContainsRune(telSeparators, r):
+		case !global && strings.ContainsRune("*#ABCDEFabcdef", r):
+			digits++
+		default:
+			return fmt.Errorf("%q isn't allowed in a phone number", r)
+		}
+	}
+	if digits == 0 {
+		return errors.New("it has no number")
+	}
+	if global {
+		if digits > 15 {
+			return errors.New("international numbers are at most 15 digits")
+		}
+		return nil
+	}
+	for _, param := range strings.Split(params, ";") {
+		if strings.HasPrefix(strings.ToLower(param), "phone-context=") {
+			return nil
+		}
+	}
+	return errors.New("local numbers need a ;phone-context, write it as +country code and number instead")
+}
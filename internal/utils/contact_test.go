@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailtoAddresses(t *testing.T) {
+	addresses, err := MailtoAddresses("mailto:docs@example.com,%20team@example.com?subject=Hi&to=help@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"docs@example.com", "team@example.com", "help@example.com"}, addresses)
+}
+
+func TestCheckMailto(t *testing.T) {
+	cases := map[string]string{
+		"mailto:docs@example.com":                "",
+		"mailto:docs@example.com?subject=Hello":  "",
+		"mailto:?to=docs@example.com":            "",
+		"mailto:first.last+tag@mail.example.com": "",
+		"mailto:":                                "it has no address",
+		"mailto:docs.example.com":                "docs.example.com isn't an email address",
+		"mailto:docs@@example.com":               "docs@@example.com isn't an email address",
+		"mailto:Docs%20%3Cdocs@example.com%3E":   "Docs <docs@example.com> isn't an email address",
+	}
+	for link, expected := range cases {
+		err := CheckMailto(link)
+		if expected == "" {
+			assert.NoError(t, err, link)
+		} else {
+			assert.EqualError(t, err, expected, link)
+		}
+	}
+}
+
+func TestCheckTel(t *testing.T) {
+	cases := map[string]string{
+		"tel:+1-212-555-0100":                "",
+		"tel:+44(20)7946.0018":               "",
+		"tel:7042;phone-context=example.com": "",
+		"tel:555-0100":                       "local numbers need a ;phone-context, write it as +country code and number instead",
+		"tel:+1-212-555-0100x12":             "'x' isn't allowed in a phone number",
+		"tel:+":                              "it has no number",
+		"tel:+1234567890123456":              "international numbers are at most 15 digits",
+	}
+	for link, expected := range cases {
+		err := CheckTel(link)
+		if expected == "" {
+			assert.NoError(t, err, link)
+		} else {
+			assert.EqualError(t, err, expected, link)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+// StatusError is a link that returned a status other than 200.
+type StatusError struct {
+	URL  string
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned a status of %d", e.URL, e.Code)
+}
+
+// CertificateReason reports whether reason is a certificate that didn't
+// verify.
+func CertificateReason(reason diagnostics.Reason) bool {
+	switch reason {
+	case diagnostics.CertExpired, diagnostics.CertWrongHost, diagnostics.CertUnknownAuthority:
+		return true
+	}
+	return false
+}
+
+// FailureReason tells why checking a link failed with err, for triage, or
+// returns "" if it's some other failure, like a soft 404. Errors replayed from
+// cassettes recorded before reasons were kept only have their text, so that's
+// matched too.
+func FailureReason(err error) diagnostics.Reason {
+	if err == nil {
+		return ""
+	}
+	var replayed interface{ FailureReason() diagnostics.Reason }
+	if errors.As(err, &replayed) && replayed.FailureReason() != "" {
+		return replayed.FailureReason()
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		switch {
+		case status.Code >= 500:
+			return diagnostics.ServerError
+		case status.Code >= 400:
+			return diagnostics.ClientError
+		}
+		return ""
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && !dnsErr.IsTimeout {
+		return diagnostics.DNS
+	}
+	var unknownAuthority x509.UnknownAuthorityError
+	if errors.As(err, &unknownAuthority) {
+		return diagnostics.CertUnknownAuthority
+	}
+	var hostname x509.HostnameError
+	if errors.As(err, &hostname) {
+		return diagnostics.CertWrongHost
+	}
+	var invalid x509.CertificateInvalidError
+	if errors.As(err, &invalid) {
+		if invalid.Reason == x509.Expired {
+			return diagnostics.CertExpired
+		}
+		return diagnostics.TLS
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return diagnostics.Timeout
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "no such host"):
+		return diagnostics.DNS
+	case strings.Contains(msg, "x509: certificate signed by unknown authority"):
+		return diagnostics.CertUnknownAuthority
+	case strings.Contains(msg, "x509: certificate is valid for") || strings.Contains(msg, "x509: certificate is not valid for any names"):
+		return diagnostics.CertWrongHost
+	case strings.Contains(msg, "x509: certificate has expired or is not yet valid"):
+		return diagnostics.CertExpired
+	case strings.Contains(msg, "x509: ") || strings.Contains(msg, "tls: "):
+		return diagnostics.TLS
+	case strings.Contains(msg, "Timeout exceeded") || strings.Contains(msg, "deadline exceeded") || strings.Contains(msg, "i/o timeout"):
+		return diagnostics.Timeout
+	}
+	return ""
+}
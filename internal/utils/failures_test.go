@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/terakilobyte/checker/internal/diagnostics"
+)
+
+func TestFailureReason(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		reason diagnostics.Reason
+	}{
+		{"none", nil, ""},
+		{"404", &StatusError{URL: "https://example.com", Code: 404}, diagnostics.ClientError},
+		{"503", &StatusError{URL: "https://example.com", Code: 503}, diagnostics.ServerError},
+		{"dns", &url.Error{Op: "Get", URL: "https://nope.example", Err: &net.DNSError{Err: "no such host", Name: "nope.example"}}, diagnostics.DNS},
+		{"timeout", &url.Error{Op: "Get", URL: "https://example.com", Err: context.DeadlineExceeded}, diagnostics.Timeout},
+		{"unknown ca", &url.Error{Op: "Get", URL: "https://example.com", Err: x509.UnknownAuthorityError{}}, diagnostics.CertUnknownAuthority},
+		{"wrong host", &url.Error{Op: "Get", URL: "https://example.com", Err: x509.HostnameError{Host: "example.com", Certificate: &x509.Certificate{}}}, diagnostics.CertWrongHost},
+		{"expired", &url.Error{Op: "Get", URL: "https://example.com", Err: x509.CertificateInvalidError{Reason: x509.Expired}}, diagnostics.CertExpired},
+		{"tls", &url.Error{Op: "Get", URL: "https://example.com", Err: errors.New("remote error: tls: protocol version not supported")}, diagnostics.TLS},
+		{"replayed dns", errors.New(`Get "https://nope.example": dial tcp: lookup nope.example: no such host`), diagnostics.DNS},
+		{"replayed expired", errors.New(`Get "https://example.com": x509: certificate has expired or is not yet valid`), diagnostics.CertExpired},
+		{"replayed wrong host", errors.New(`Get "https://example.com": x509: certificate is valid for example.net, not example.com`), diagnostics.CertWrongHost},
+		{"replayed unknown ca", errors.New(`Get "https://example.com": x509: certificate signed by unknown authority`), diagnostics.CertUnknownAuthority},
+		{"soft 404", fmt.Errorf("%s returned a status of 200, but %s", "https://example.com", "it looks like a page that wasn't found"), ""},
+	}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.reason, FailureReason(test.err))
+		})
+	}
+}
+
+func TestCheckLinkStatusError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	res := CheckLink(server.URL + "/missing")
+	assert.False(t, res.OK)
+	assert.Equal(t, server.URL+"/missing returned a status of 404", res.Err.Error())
+	assert.Equal(t, diagnostics.ClientError, FailureReason(res.Err))
+}
+
+func TestAllowInsecureHosts(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	defer func(transport http.RoundTripper, hosts []string) {
+		client.Transport, insecureHosts = transport, hosts
+	}(client.Transport, insecureHosts)
+
+	res := CheckLink(server.URL)
+	assert.False(t, res.OK)
+	assert.Equal(t, diagnostics.CertUnknownAuthority, FailureReason(res.Err))
+
+	AllowInsecureHosts([]string{"localhost"})
+	res = CheckLink(server.URL)
+	assert.Equal(t, diagnostics.CertUnknownAuthority, FailureReason(res.Err), "only the listed hosts are insecure")
+
+	AllowInsecureHosts([]string{"127.0.0.1"})
+	assert.True(t, CheckLink(server.URL).OK)
+
+	// insecure hosts are still dialed like any other, over HTTP/2
+	h2 := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	h2.EnableHTTP2 = true
+	h2.StartTLS()
+	defer h2.Close()
+	resp, err := client.Get(h2.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 2, resp.ProtoMajor)
+}
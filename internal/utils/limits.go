@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ResponseTooLargeError is a response bigger than LimitResponses allows, like
+// a link to a large download.
+type ResponseTooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("%s is larger than %s, the most checker reads of a response", e.URL, FormatByteSize(e.Limit))
+}
+
+// errDownloadSpent is what reading a response returns once every response
+// together has been read as much as LimitResponses allows.
+var errDownloadSpent = fmt.Errorf("checker has read as much as it's allowed to")
+
+// LimitResponses caps how much checker reads of any one response at
+// perResponse bytes, and of every response together at total bytes, 0 for no
+// cap. Links are still checked past the total, by their status alone, without
+// reading their pages. Call it before WrapTransport.
+func LimitResponses(perResponse int64, total int64) {
+	if perResponse == 0 && total == 0 {
+		return
+	}
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = &limitTransport{next: next, perResponse: perResponse, total: total}
+}
+
+// limitTransport wraps the body of every response in a limitedBody.
+type limitTransport struct {
+	next        http.RoundTripper
+	perResponse int64
+	total       int64
+	// read is how much of every response together has been read
+	read  int64
+	spent sync.Once
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	body := &limitedBody{ReadCloser: resp.Body, transport: t, url: req.URL.String()}
+	if t.perResponse > 0 && resp.ContentLength > t.perResponse {
+		// there's no use downloading what won't be read
+		body.err = &ResponseTooLargeError{URL: body.url, Limit: t.perResponse}
+	}
+	resp.Body = body
+	return resp, nil
+}
+
+// limitedBody is a response body that stops being read at its transport's
+// limits.
+type limitedBody struct {
+	io.ReadCloser
+	transport *limitTransport
+	url       string
+	read      int64
+	err       error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	t := b.transport
+	if t.total > 0 && atomic.LoadInt64(&t.read) >= t.total {
+		t.spent.Do(func() {
+			log.Warnf("checker has read %s of responses, the most it's allowed to, so it's checking the rest of the links by their status alone", FormatByteSize(t.total))
+		})
+		b.err = errDownloadSpent
+		return 0, b.err
+	}
+	if t.perResponse > 0 && int64(len(p)) > t.perResponse-b.read+1 {
+		// read one byte past the limit, to tell a response that's exactly
+		// the limit from one that's over it
+		p = p[:t.perResponse-b.read+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	atomic.AddInt64(&t.read, int64(n))
+	if t.perResponse > 0 && b.read > t.perResponse {
+		b.err = &ResponseTooLargeError{URL: b.url, Limit: t.perResponse}
+		return n - int(b.read-t.perResponse), b.err
+	}
+	return n, err
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatByteSize(t *testing.T) {
+	for n, want := range map[int64]string{0: "0B", 10: "10B", 4096: "4KiB", 1536: "1536B", 64 << 20: "64MiB", 2 << 30: "2GiB"} {
+		assert.Equal(t, want, FormatByteSize(n))
+	}
+}
+
+func TestLimitResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			// no Content-Length, so the size is only found out by reading
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte(strings.Repeat("a", 100)))
+	}))
+	defer server.Close()
+	defer func(transport http.RoundTripper) { client.Transport = transport }(client.Transport)
+
+	LimitResponses(100, 0)
+	body, err := FetchNetworkFile(server.URL + "/exact")
+	assert.NoError(t, err)
+	assert.Len(t, body, 100)
+
+	client.Transport = nil
+	LimitResponses(99, 0)
+	var tooLarge *ResponseTooLargeError
+	_, err = FetchNetworkFile(server.URL + "/sized")
+	assert.True(t, errors.As(err, &tooLarge), "%v", err)
+	_, err = FetchNetworkFile(server.URL + "/chunked")
+	assert.True(t, errors.As(err, &tooLarge), "%v", err)
+	assert.EqualError(t, err, server.URL+"/chunked is larger than 99B, the most checker reads of a response")
+
+	client.Transport = nil
+	LimitResponses(0, 150)
+	_, err = FetchNetworkFile(server.URL + "/first")
+	assert.NoError(t, err)
+	_, err = FetchNetworkFile(server.URL + "/second")
+	assert.NoError(t, err, "the total is only checked before each read")
+	_, err = FetchNetworkFile(server.URL + "/third")
+	assert.ErrorIs(t, err, errDownloadSpent)
+	// links are still checked by their status
+	assert.True(t, checkLink(server.URL+"/fourth", 0).OK)
+}
@@ -0,0 +1,55 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// defaultPorts are the ports a scheme uses when a url doesn't give one.
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// trackingParams are query parameters that only tell the linked site where a
+// reader came from, so don't change the page.
+var trackingParams = map[string]bool{
+	"gclid":   true,
+	"fbclid":  true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+}
+
+// URLKey is the same for every spelling of a link, so each link is only
+// checked once: it's link as NormalizeURL requests it, with its scheme and
+// host lowercase, without its scheme's default port, and with its path's .
+// and .. segments resolved. With stripTracking, utm_ and other tracking
+// parameters are dropped from its query too. Links that don't parse are
+// returned as is.
+func URLKey(link string, stripTracking bool) string {
+	u, err := url.Parse(NormalizeURL(link))
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return link
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); port != "" && port == defaultPorts[u.Scheme] {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	// resolving a url against itself resolves its dot segments
+	u = u.ResolveReference(u)
+	if stripTracking && u.RawQuery != "" {
+		kept := make([]string, 0)
+		for _, param := range strings.Split(u.RawQuery, "&") {
+			name, _, _ := strings.Cut(param, "=")
+			if name, err := url.QueryUnescape(name); err == nil && (trackingParams[strings.ToLower(name)] || strings.HasPrefix(strings.ToLower(name), "utm_")) {
+				continue
+			}
+			kept = append(kept, param)
+		}
+		u.RawQuery = strings.Join(kept, "&")
+		u.ForceQuery = false
+	}
+	return u.String()
+}
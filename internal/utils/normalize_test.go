@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLKey(t *testing.T) {
+	cases := []struct {
+		link          string
+		stripTracking bool
+		expected      string
+	}{
+		{"https://www.mongodb.com/docs/", false, "https://www.mongodb.com/docs/"},
+		{"HTTPS://WWW.MongoDB.com:443/docs/", false, "https://www.mongodb.com/docs/"},
+		{"http://example.com:80", false, "http://example.com/"},
+		{"http://example.com:8080/a", false, "http://example.com:8080/a"},
+		{"https://example.com/a/./b/../c", false, "https://example.com/a/c"},
+		{"https://example.com/Docs#Install", false, "https://example.com/Docs#Install"},
+		{"https://example.com/?utm_source=docs&page=2&gclid=abc", false, "https://example.com/?utm_source=docs&page=2&gclid=abc"},
+		{"https://example.com/?utm_source=docs&page=2&gclid=abc", true, "https://example.com/?page=2"},
+		{"https://example.com/?utm_campaign=launch", true, "https://example.com/"},
+		{"https://B\u00dcCHER.example/stra\u00dfe", false, "https://xn--bcher-kva.example/stra%C3%9Fe"},
+		{"mailto:docs@example.com", false, "mailto:docs@example.com"},
+		{"/relative/path", false, "/relative/path"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, URLKey(c.link, c.stripTracking), c.link)
+	}
+}
@@ -0,0 +1,212 @@
+package utils
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxInspectedBytes is how much of a page is read to tell whether it's
+// really there.
+const maxInspectedBytes = 256 << 10
+
+// maxRefreshes is how many meta refreshes a link can go through, like the
+// 10 http redirects the client follows.
+const maxRefreshes = 10
+
+// tinyPage is how little visible text a page can have before saying it
+// wasn't found is enough to call it a soft 404.
+const tinyPage = 512
+
+var (
+	// SoftNotFound turns on detecting pages that return 200 but say they
+	// weren't found.
+	SoftNotFound = true
+	// SoftNotFoundPhrases mark a page as not found wherever they appear in
+	// it, in addition to the built-in heuristics. Matching ignores case.
+	SoftNotFoundPhrases []string
+
+	// ParkedDomains turns on detecting domains parked by a registrar or put
+	// up for sale, which return 200 for any page.
+	ParkedDomains = true
+
+	// parkingHosts are the domain parking and marketplace services parked
+	// domains redirect to
+	parkingHosts = []string{"sedo.com", "sedoparking.com", "parkingcrew.net", "bodis.com", "dan.com", "afternic.com", "hugedomains.com", "above.com", "parklogic.com", "undeveloped.com", "domainmarket.com", "buydomains.com"}
+	// parkedRegex finds the scripts parking services serve and the wording
+	// of their for-sale landers
+	parkedRegex = regexp.MustCompile(`(?i)(sedoparking\.com|parkingcrew\.net|bodis\.com/|img\.sedoparking|parking\.namecheap|cdn\.dan\.com|window\.park\s*=|domain\s+(?:name\s+)?(?:[\w.-]+\s+)?(?:is|may\s+be)\s+for\s+sale|buy\s+this\s+domain|this\s+domain\s+(?:has\s+been|is)\s+(?:registered|parked)|parked\s+(?:free|domain)\s+courtesy)`)
+
+	// LoginWalls turns on detecting links that redirect to a login or
+	// consent page instead of the content linked to.
+	LoginWalls = true
+	// LoginWallsAllowed are domains whose links may redirect to a login or
+	// consent page, like an intranet every reader is signed in to.
+	LoginWallsAllowed []string
+
+	// wallHosts only serve login and consent pages
+	wallHosts = []string{"accounts.google.com", "consent.google.com", "consent.youtube.com", "login.microsoftonline.com", "login.live.com", "consent.yahoo.com", "guce.yahoo.com", "signin.aws.amazon.com", "id.atlassian.com"}
+	// wallPathRegex matches the paths sites commonly serve their login pages on
+	wallPathRegex = regexp.MustCompile(`(?i)/(?:log-?in|sign-?in|sign_in|users/sign_in|sso|auth/?login|oauth2?/authorize|consent)(?:/|\.\w+)?$`)
+
+	metaRefreshRegex = regexp.MustCompile(`(?is)<meta\s[^>]*\bhttp-equiv\s*=\s*["']?refresh\b[^>]*>`)
+	contentRegex     = regexp.MustCompile(`(?is)\bcontent\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	refreshURLRegex  = regexp.MustCompile(`(?i)^\s*[\d.]*\s*[;,]?\s*url\s*=\s*['"]?([^'"]+)`)
+	canonicalRegex   = regexp.MustCompile(`(?is)<link\s[^>]*\brel\s*=\s*["']?canonical\b[^>]*>`)
+	hrefRegex        = regexp.MustCompile(`(?is)\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+
+	titleRegex    = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	invisibleTags = regexp.MustCompile(`(?is)<script.*?</script>|<style.*?</style>|<[^>]*>`)
+	// a title that starts or ends with not found, like "404 - Page Not
+	// Found" or "MongoDB | Page Not Found", not one that mentions it
+	notFoundTitleRegex = regexp.MustCompile(`(?i)^(?:error\s*)?(?:404\b|(?:page\s+)?not\s+found|page\s+(?:does\s+not|doesn't)\s+exist)|(?:\b404|(?:page\s+)?not\s+found)\s*$`)
+	notFoundTextRegex  = regexp.MustCompile(`(?i)\b404\b|page\s+not\s+found|not\s+be\s+found|(?:does\s+not|doesn't)\s+exist|no\s+longer\s+(?:exists|available)`)
+)
+
+// inspectPage looks at a page that returned 200 for a request to linked,
+// and the start of its body if it's html, and reports why it's effectively
+// dead, if it is.
+func inspectPage(linked *url.URL, response *http.Response, body []byte) (string, bool) {
+	if LoginWalls && !onDomains(linked.Hostname(), LoginWallsAllowed) {
+		if final := response.Request.URL; loginWall(linked, final) {
+			return fmt.Sprintf("redirects to a login or consent page, %s", final.Redacted()), true
+		}
+	}
+	if ParkedDomains && !parkingHost(linked.Hostname()) {
+		if host := response.Request.URL.Hostname(); parkingHost(host) {
+			return fmt.Sprintf("looks like a parked domain: it ends up at %s", host), true
+		}
+	}
+	if ParkedDomains {
+		if reason, ok := parked(body); ok {
+			return "looks like a parked domain: " + reason, true
+		}
+	}
+	if SoftNotFound {
+		if reason, ok := softNotFound(body); ok {
+			return "looks like a page that wasn't found: " + reason, true
+		}
+	}
+	return "", false
+}
+
+func isHTML(response *http.Response) bool {
+	contentType := response.Header.Get("Content-Type")
+	return contentType == "" || strings.Contains(contentType, "html")
+}
+
+// onDomains reports whether host is one of domains or a subdomain of one.
+func onDomains(host string, domains []string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// parkingHost reports whether host belongs to a domain parking service.
+func parkingHost(host string) bool {
+	return onDomains(host, parkingHosts)
+}
+
+// loginWall reports whether a request to linked was redirected to final, a
+// login or consent page, when linked wasn't one itself.
+func loginWall(linked *url.URL, final *url.URL) bool {
+	if final.String() == linked.String() || onDomains(linked.Hostname(), wallHosts) || wallPathRegex.MatchString(linked.Path) {
+		return false
+	}
+	return onDomains(final.Hostname(), wallHosts) || wallPathRegex.MatchString(final.Path)
+}
+
+// parked reports why a page looks like a parking or for-sale lander, if it
+// does.
+func parked(body []byte) (string, bool) {
+	if m := parkedRegex.Find(body); m != nil {
+		return fmt.Sprintf("it has %q", strings.Join(strings.Fields(string(m)), " ")), true
+	}
+	return "", false
+}
+
+// softNotFound reports why a page looks like one that wasn't found, if it
+// does: it has one of SoftNotFoundPhrases, its title says so, or it's nearly
+// empty apart from saying so.
+func softNotFound(body []byte) (string, bool) {
+	page := strings.ToLower(string(body))
+	for _, phrase := range SoftNotFoundPhrases {
+		if phrase != "" && strings.Contains(page, strings.ToLower(phrase)) {
+			return fmt.Sprintf("it says %q", phrase), true
+		}
+	}
+	if m := titleRegex.FindSubmatch(body); m != nil {
+		title := strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " ")
+		if notFoundTitleRegex.MatchString(title) {
+			return fmt.Sprintf("its title is %q", title), true
+		}
+	}
+	text := strings.Join(strings.Fields(html.UnescapeString(invisibleTags.ReplaceAllString(string(body), " "))), " ")
+	if len(text) < tinyPage && notFoundTextRegex.MatchString(text) {
+		return "it's nearly empty and says the page wasn't found", true
+	}
+	return "", false
+}
+
+// canonicalMismatch returns the rel=canonical url page declares in body,
+// when it's a different page than page: on another host or path. Scheme,
+// query, fragment, www., and a trailing slash or index.html don't count.
+func canonicalMismatch(page *url.URL, body []byte) string {
+	link := canonicalRegex.Find(body)
+	if link == nil {
+		return ""
+	}
+	m := hrefRegex.FindSubmatch(link)
+	if m == nil {
+		return ""
+	}
+	href := strings.TrimSpace(html.UnescapeString(string(m[1]) + string(m[2]) + string(m[3])))
+	canonical, err := page.Parse(href)
+	if href == "" || err != nil {
+		return ""
+	}
+	if samePage(page, canonical) {
+		return ""
+	}
+	return canonical.String()
+}
+
+func samePage(a *url.URL, b *url.URL) bool {
+	host := func(u *url.URL) string {
+		return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	}
+	path := func(u *url.URL) string {
+		return strings.TrimSuffix(strings.TrimSuffix(u.Path, "index.html"), "/")
+	}
+	return host(a) == host(b) && path(a) == path(b)
+}
+
+// metaRefresh returns the url a page's meta refresh redirects to, resolved
+// against page. A refresh that reloads the page isn't a redirect.
+func metaRefresh(page *url.URL, body []byte) string {
+	meta := metaRefreshRegex.Find(body)
+	if meta == nil {
+		return ""
+	}
+	content := contentRegex.FindSubmatch(meta)
+	if content == nil {
+		return ""
+	}
+	m := refreshURLRegex.FindStringSubmatch(html.UnescapeString(string(content[1]) + string(content[2])))
+	if m == nil {
+		return ""
+	}
+	target, err := page.Parse(strings.TrimSpace(m[1]))
+	if err != nil || target.String() == page.String() {
+		return ""
+	}
+	return target.String()
+}
@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoftNotFound(t *testing.T) {
+	article := "<p>" + strings.Repeat("Handling missing documents in your application. ", 20) + "</p>"
+	cases := []struct {
+		name string
+		body string
+		dead bool
+	}{
+		{"title", "<html><head><title>404 - Page Not Found</title></head><body>" + article + "</body></html>", true},
+		{"title suffix", "<title>MongoDB | Page not found</title>" + article, true},
+		{"title mentioning 404s", "<title>Handling 404 errors in Express</title>" + article, false},
+		{"tiny page", "<html><body><h1>Oops</h1><p>The page you requested does not exist.</p></body></html>", true},
+		{"long page mentioning 404s", "<title>Errors</title>" + article + "<p>The server returns a 404 when a document does not exist.</p>", false},
+		{"script text is ignored", "<title>Docs</title><script>var error = 'page not found';</script>" + article, false},
+		{"real page", "<title>Insert Documents</title>" + article, false},
+	}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			_, dead := softNotFound([]byte(test.body))
+			assert.Equal(t, test.dead, dead)
+		})
+	}
+
+	SoftNotFoundPhrases = []string{"We couldn't find that"}
+	defer func() { SoftNotFoundPhrases = nil }()
+	reason, dead := softNotFound([]byte("<title>Docs</title>" + article + "<p>Sorry, we COULDN'T find that page.</p>"))
+	assert.True(t, dead)
+	assert.Equal(t, `it says "We couldn't find that"`, reason)
+}
+
+func TestIsReachableSoftNotFound(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte("<html><head><title>Page Not Found</title></head><body></body></html>"))
+	})
+	mux.HandleFunc("/data", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"error": "page not found"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	err, ok := IsReachable(server.URL + "/missing")
+	assert.False(t, ok)
+	assert.Contains(t, err.Error(), `its title is "Page Not Found"`)
+
+	err, ok = IsReachable(server.URL + "/data")
+	assert.NoError(t, err)
+	assert.True(t, ok, "only html pages are inspected")
+
+	SoftNotFound = false
+	defer func() { SoftNotFound = true }()
+	err, ok = IsReachable(server.URL + "/missing")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestParked(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		dead bool
+	}{
+		{"parking script", `<html><head><script src="https://img.sedoparking.com/js/park.js"></script></head><body></body></html>`, true},
+		{"for sale lander", "<title>example.net</title><h1>This domain is for sale!</h1><p>Make an offer.</p>", true},
+		{"buy this domain", "<title>example.net</title><a href=\"/buy\">Buy this domain</a>", true},
+		{"real page", "<title>Install MongoDB</title><p>Install the server, then connect to it.</p>", false},
+	}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			_, dead := parked([]byte(test.body))
+			assert.Equal(t, test.dead, dead)
+		})
+	}
+
+	assert.True(t, parkingHost("www.HugeDomains.com"))
+	assert.True(t, parkingHost("dan.com"))
+	assert.False(t, parkingHost("jordan.com"))
+}
+
+func TestIsReachableParked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><head><title>mongo-tools.net</title></head><body>The domain mongo-tools.net may be for sale.</body></html>"))
+	}))
+	defer server.Close()
+
+	err, ok := IsReachable(server.URL)
+	assert.False(t, ok)
+	assert.Contains(t, err.Error(), "looks like a parked domain")
+
+	ParkedDomains = false
+	defer func() { ParkedDomains = true }()
+	err, ok = IsReachable(server.URL)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestLoginWall(t *testing.T) {
+	parse := func(raw string) *url.URL {
+		u, err := url.Parse(raw)
+		assert.NoError(t, err)
+		return u
+	}
+	cases := []struct {
+		linked, final string
+		wall          bool
+	}{
+		{"https://wiki.example.com/spaces/DOCS", "https://wiki.example.com/login", true},
+		{"https://example.com/report", "https://accounts.google.com/ServiceLogin?continue=https://example.com/report", true},
+		{"https://www.youtube.com/watch?v=1", "https://consent.youtube.com/m?continue=https://www.youtube.com/watch", true},
+		{"https://app.example.com/", "https://app.example.com/users/sign_in", true},
+		{"https://example.com/docs", "https://example.com/docs/", false},
+		{"https://example.com/docs/login", "https://example.com/docs/login", false},
+		{"https://example.com/login", "https://example.com/login/", false},
+		{"https://example.com/blog/logins-explained", "https://example.com/blog/logins-explained/", false},
+	}
+	for _, test := range cases {
+		assert.Equal(t, test.wall, loginWall(parse(test.linked), parse(test.final)), "%s redirected to %s", test.linked, test.final)
+	}
+}
+
+func TestIsReachableLoginWall(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login?next=/private", http.StatusFound)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><head><title>Sign in</title></head><body><form></form></body></html>"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	err, ok := IsReachable(server.URL + "/private")
+	assert.False(t, ok)
+	assert.Contains(t, err.Error(), "redirects to a login or consent page")
+
+	LoginWallsAllowed = []string{"127.0.0.1"}
+	defer func() { LoginWallsAllowed = nil }()
+	err, ok = IsReachable(server.URL + "/private")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestCanonicalMismatch(t *testing.T) {
+	page, err := url.Parse("https://www.example.com/docs/v4.4/tutorial/")
+	assert.NoError(t, err)
+	cases := []struct {
+		name      string
+		body      string
+		canonical string
+	}{
+		{"no canonical", "<title>Tutorial</title>", ""},
+		{"same page", `<link rel="canonical" href="https://example.com/docs/v4.4/tutorial">`, ""},
+		{"index.html", `<link rel="canonical" href="http://www.example.com/docs/v4.4/tutorial/index.html">`, ""},
+		{"current version", `<link rel="canonical" href="https://www.example.com/docs/current/tutorial/">`, "https://www.example.com/docs/current/tutorial/"},
+		{"relative", `<link href='/docs/current/tutorial/' rel='canonical' />`, "https://www.example.com/docs/current/tutorial/"},
+		{"other host", `<LINK REL=canonical HREF=https://docs.example.org/tutorial/>`, "https://docs.example.org/tutorial/"},
+		{"other rel", `<link rel="alternate" href="https://www.example.com/docs/fr/tutorial/">`, ""},
+	}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.canonical, canonicalMismatch(page, []byte(test.body)))
+		})
+	}
+}
+
+func TestCheckLinkCanonical(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><title>Tutorial</title><link rel="canonical" href="/docs/current/tutorial/"></head></html>`))
+	}))
+	defer server.Close()
+
+	res := CheckLink(server.URL + "/docs/v4.4/tutorial/")
+	assert.NoError(t, res.Err)
+	assert.True(t, res.OK)
+	assert.Equal(t, server.URL+"/docs/current/tutorial/", res.Canonical)
+}
+
+func TestMetaRefresh(t *testing.T) {
+	page, err := url.Parse("https://vendor.example.com/docs/old/")
+	assert.NoError(t, err)
+	cases := []struct {
+		name   string
+		body   string
+		target string
+	}{
+		{"absolute", `<meta http-equiv="refresh" content="0; url=https://vendor.example.com/docs/new/">`, "https://vendor.example.com/docs/new/"},
+		{"relative and quoted", `<META HTTP-EQUIV="Refresh" CONTENT="5;URL='../new/'">`, "https://vendor.example.com/docs/new/"},
+		{"reload", `<meta http-equiv="refresh" content="300">`, ""},
+		{"itself", `<meta http-equiv="refresh" content="60; url=https://vendor.example.com/docs/old/">`, ""},
+		{"none", `<meta name="viewport" content="width=device-width">`, ""},
+	}
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.target, metaRefresh(page, []byte(test.body)))
+		})
+	}
+}
+
+func TestCheckLinkMetaRefresh(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0; url=/moved"></head></html>`))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0; url=/new"></head></html>`))
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><head><title>New</title></head><body>" + strings.Repeat("Content. ", 100) + "</body></html>"))
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0; url=/missing"></head></html>`))
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0; url=/looped"></head></html>`))
+	})
+	mux.HandleFunc("/looped", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0; url=/loop"></head></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	res := CheckLink(server.URL + "/old")
+	assert.True(t, res.OK)
+	assert.Equal(t, server.URL+"/new", res.Refreshed)
+
+	res = CheckLink(server.URL + "/gone")
+	assert.False(t, res.OK)
+	assert.Contains(t, res.Err.Error(), "/missing returned a status of 404")
+
+	res = CheckLink(server.URL + "/loop")
+	assert.False(t, res.OK)
+	assert.Contains(t, res.Err.Error(), "stopped after 10 meta refreshes")
+}
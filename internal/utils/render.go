@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// renderTimeout is how long headless Chrome gets to load and render a page.
+const renderTimeout = 30 * time.Second
+
+var (
+	// RenderDomains are domains, including their subdomains, whose pages are
+	// rendered in headless Chrome before their anchors are checked, since
+	// they're built by scripts. Anchors of other domains aren't checked.
+	RenderDomains []string
+	// ChromePath is the Chrome or Chromium binary to render pages with, found
+	// on the PATH if empty.
+	ChromePath string
+
+	chromeNames = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome"}
+
+	// renders is a slot for each Chrome allowed to run at once
+	renders   = make(chan struct{}, runtime.NumCPU())
+	rendersMu sync.Mutex
+	// renderedDOMs holds each page rendered by this process, by url, so a
+	// page linked to with several anchors is only rendered once
+	renderedDOMs sync.Map
+)
+
+// LimitRenders lets at most n pages render in headless Chrome at once, like
+// the workers checking links, rather than a Chrome for every link at once.
+// It's the number of CPUs until it's called. Chrome has no way to share one
+// browser across command line renders without a DevTools client, so each
+// page still gets its own process.
+func LimitRenders(n int) {
+	if n < 1 {
+		n = 1
+	}
+	rendersMu.Lock()
+	defer rendersMu.Unlock()
+	renders = make(chan struct{}, n)
+}
+
+// chrome returns the binary to render pages with.
+func chrome() (string, error) {
+	if ChromePath != "" {
+		return ChromePath, nil
+	}
+	for _, name := range chromeNames {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("couldn't find Chrome or Chromium to render pages with, set chrome in checker.toml's [render] table")
+}
+
+// renderDOM loads uri in headless Chrome, letting its scripts run, and
+// returns the html of the rendered page.
+func renderDOM(uri string) ([]byte, error) {
+	if dom, ok := renderedDOMs.Load(uri); ok {
+		return dom.([]byte), nil
+	}
+	binary, err := chrome()
+	if err != nil {
+		return nil, err
+	}
+	rendersMu.Lock()
+	slots := renders
+	rendersMu.Unlock()
+	slots <- struct{}{}
+	defer func() { <-slots }()
+	ctx, cancel := context.WithTimeout(context.Background(), renderTimeout)
+	defer cancel()
+	// Chrome keeps its sandbox, since the pages are anyone's
+	cmd := exec.CommandContext(ctx, binary, "--headless", "--disable-gpu", "--virtual-time-budget=10000", "--dump-dom", uri)
+	dom, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not render %s: %w", uri, err)
+	}
+	renderedDOMs.Store(uri, dom)
+	return dom, nil
+}
+
+// hasAnchor reports whether an element in dom has fragment as its id, or as
+// its name, like old-style <a name> anchors.
+func hasAnchor(dom []byte, fragment string) bool {
+	tokens := html.NewTokenizer(bytes.NewReader(dom))
+	for {
+		switch tokens.Next() {
+		case html.ErrorToken:
+			return false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			for {
+				key, value, more := tokens.TagAttr()
+				if (string(key) == "id" || string(key) == "name") && string(value) == fragment {
+					return true
+				}
+				if !more {
+					break
+				}
+			}
+		}
+	}
+}
+
+// checkRenderedAnchor renders page and reports an error if it has no anchor
+// for its fragment. Only pages with a fragment on RenderDomains are checked.
+func checkRenderedAnchor(page string, host string, fragment string) error {
+	if fragment == "" || !onDomains(host, RenderDomains) {
+		return nil
+	}
+	dom, err := renderDOM(page)
+	if err != nil {
+		return err
+	}
+	if !hasAnchor(dom, fragment) {
+		return fmt.Errorf("%s has no #%s anchor once rendered", page, fragment)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasAnchor(t *testing.T) {
+	dom := []byte(`<html><body><h2 id="install">Install</h2><a name=legacy></a><div data-id="other"></div></body></html>`)
+	assert.True(t, hasAnchor(dom, "install"))
+	assert.True(t, hasAnchor(dom, "legacy"))
+	assert.False(t, hasAnchor(dom, "other"))
+	assert.False(t, hasAnchor(dom, "instal"))
+
+	dom = []byte(`<p>id="text"</p><SECTION ID='caps'></SECTION><h3 id="a&amp;b"/>`)
+	assert.False(t, hasAnchor(dom, "text"), "only attributes are anchors")
+	assert.True(t, hasAnchor(dom, "caps"))
+	assert.True(t, hasAnchor(dom, "a&b"))
+}
+
+func TestCheckLinkRenderedAnchor(t *testing.T) {
+	// a stand-in for Chrome that prints the page its scripts would render
+	ChromePath = filepath.Join(t.TempDir(), "chrome")
+	script := "#!/bin/sh\necho '<html><body><div id=\"root\"><h2 id=\"rendered\">Rendered</h2></div></body></html>'\n"
+	assert.NoError(t, os.WriteFile(ChromePath, []byte(script), 0755))
+	RenderDomains = []string{"127.0.0.1"}
+	defer func() { ChromePath, RenderDomains = "", nil }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<html><body><div id="root"></div><script src="/app.js"></script></body></html>`))
+	}))
+	defer server.Close()
+
+	res := CheckLink(server.URL + "/guide#rendered")
+	assert.NoError(t, res.Err)
+	assert.True(t, res.OK)
+
+	res = CheckLink(server.URL + "/guide#missing")
+	assert.False(t, res.OK)
+	assert.Contains(t, res.Err.Error(), "has no #missing anchor once rendered")
+
+	RenderDomains = nil
+	res = CheckLink(server.URL + "/guide#missing")
+	assert.True(t, res.OK, "anchors are only checked on render domains")
+}
+
+func TestRenderDOMLimited(t *testing.T) {
+	// a stand-in for Chrome that notes each render, and whether another was
+	// running at the same time
+	dir := t.TempDir()
+	ChromePath = filepath.Join(dir, "chrome")
+	script := "#!/bin/sh\necho rendered >> " + filepath.Join(dir, "renders") + "\n" +
+		"if mkdir " + filepath.Join(dir, "running") + "; then sleep 0.05; rmdir " + filepath.Join(dir, "running") + "; else echo overlap >> " + filepath.Join(dir, "renders") + "; fi\n" +
+		"echo '<html></html>'\n"
+	assert.NoError(t, os.WriteFile(ChromePath, []byte(script), 0755))
+	LimitRenders(1)
+	defer func() { ChromePath = ""; LimitRenders(runtime.NumCPU()) }()
+
+	var wg sync.WaitGroup
+	for _, page := range []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"} {
+		wg.Add(1)
+		go func(page string) {
+			defer wg.Done()
+			_, err := renderDOM(page)
+			assert.NoError(t, err)
+		}(page)
+	}
+	wg.Wait()
+	_, err := renderDOM("https://example.com/a")
+	assert.NoError(t, err)
+
+	renders, err := os.ReadFile(filepath.Join(dir, "renders"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(renders), "rendered"), "each page is rendered once")
+	assert.NotContains(t, string(renders), "overlap")
+}
@@ -2,15 +2,25 @@ package utils
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
+	"path"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/go-github/v41/github"
-	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
 )
 
 const (
@@ -29,8 +39,11 @@ func (v validRedirects) contains(i int) bool {
 }
 
 var (
-	httpLinkRegex = regexp.MustCompile(`(https?:\/\/[-a-zA-Z0-9@:%._\+~#=]{1,256}\.[a-zA-Z0-9]{1,6}\b[-a-zA-Z0-9@:%_\+.~#?&//=]*)`)
+	httpLinkRegex = regexp.MustCompile(`(https?:\/\/[-a-zA-Z0-9\p{L}\p{M}@:%._\+~#=]{1,256}\.[a-zA-Z0-9]{1,6}\b[-a-zA-Z0-9\p{L}\p{M}\p{N}@:%_\+.~#?&//=]*)`)
 	client        *http.Client
+	// dialNetwork and insecureHosts are how baseTransport connects
+	dialNetwork   = "tcp"
+	insecureHosts []string
 	redirects     = validRedirects{301, 302, 303, 304, 305, 307, 308}
 )
 
@@ -40,8 +53,101 @@ func init() {
 	}
 }
 
-func GetLatestSnootyParserTag() string {
-	ghClient := github.NewClient(nil)
+// WrapTransport wraps the transport checker's network requests go through, to
+// instrument or replace them. Call it before making any requests.
+func WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	next := client.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	client.Transport = wrap(next)
+}
+
+// RoundTripperFunc is an http.RoundTripper that calls itself, for wrapping a
+// transport with a func.
+type RoundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// UseNetwork makes checker's requests connect with network, "tcp4" for only
+// IPv4 or "tcp6" for only IPv6, rather than racing both, for networks where
+// one is broken and every link to a dual-stack host waits out a timeout. Call
+// it before WrapTransport.
+func UseNetwork(network string) {
+	dialNetwork = network
+	client.Transport = baseTransport()
+}
+
+// AllowInsecureHosts stops checker from verifying the certificates of hosts,
+// and their subdomains, like internal sites with self-signed certificates.
+// Call it before WrapTransport.
+func AllowInsecureHosts(hosts []string) {
+	insecureHosts = hosts
+	client.Transport = baseTransport()
+}
+
+// baseTransport is http.DefaultTransport, connecting with dialNetwork, that
+// doesn't verify the certificates of insecureHosts. Requests to them go
+// through a clone that skips verification, picked by the request's host, since
+// an IP address isn't sent in the handshake for a VerifyConnection to see.
+// Both keep the default transport's TLS dialing, HTTP/2, and proxy.
+func baseTransport() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport.DialContext = func(ctx context.Context, _ string, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, dialNetwork, addr)
+	}
+	if len(insecureHosts) == 0 {
+		return transport
+	}
+	insecure := transport.Clone()
+	if insecure.TLSClientConfig == nil {
+		insecure.TLSClientConfig = &tls.Config{}
+	}
+	insecure.TLSClientConfig.InsecureSkipVerify = true
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if onDomains(req.URL.Hostname(), insecureHosts) {
+			return insecure.RoundTrip(req)
+		}
+		return transport.RoundTrip(req)
+	})
+}
+
+// LatestRstSpecURL is the url of the latest snooty-parser release's
+// rstspec.toml.
+func LatestRstSpecURL() (string, error) {
+	tag, err := LatestSnootyParserTag()
+	if err != nil {
+		return "", err
+	}
+	return RstSpecURL(tag), nil
+}
+
+// TokenTransport authenticates requests to the GitHub API with token, sending
+// them on with next.
+func TokenTransport(token string, next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "token "+token)
+		return next.RoundTrip(req)
+	})
+}
+
+// LatestSnootyParserTag is the latest snooty-parser release, like v0.13.7.
+// The GitHub API is called with GITHUB_TOKEN when it's set, because it rate
+// limits anonymous requests by IP, which busy CI runners share.
+func LatestSnootyParserTag() (string, error) {
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	token := os.Getenv("GITHUB_TOKEN")
+	if token != "" {
+		transport = TokenTransport(token, transport)
+	}
+	ghClient := github.NewClient(&http.Client{Transport: transport})
 
 	gctx, gcancel := context.WithTimeout(context.TODO(), 5*time.Second)
 	defer gcancel()
@@ -49,72 +155,353 @@ func GetLatestSnootyParserTag() string {
 	// get the latest release
 	tags, _, err := ghClient.Repositories.ListTags(gctx, "mongodb", "snooty-parser", nil)
 	if err != nil {
-		log.Fatal(err)
+		return "", githubAPIError(err, token != "")
 	}
+	if len(tags) == 0 {
+		return "", fmt.Errorf("mongodb/snooty-parser has no tags")
+	}
+
+	return tags[0].GetName(), nil
+}
 
-	latest := tags[0].Name
-	return rstSpecBase + *latest + "/snooty/rstspec.toml"
+// githubAPIError explains the GitHub API failures that come down to the token.
+func githubAPIError(err error, authenticated bool) error {
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	var respErr *github.ErrorResponse
+	switch {
+	case errors.As(err, &rateErr) && !authenticated:
+		return fmt.Errorf("the GitHub API rate limit for anonymous requests is used up until %s, set GITHUB_TOKEN to raise it: %w", rateErr.Rate.Reset.Format(time.RFC3339), err)
+	case errors.As(err, &rateErr):
+		return fmt.Errorf("the GitHub API rate limit for GITHUB_TOKEN is used up until %s: %w", rateErr.Rate.Reset.Format(time.RFC3339), err)
+	case errors.As(err, &abuseErr):
+		return fmt.Errorf("the GitHub API is throttling requests, try again later: %w", err)
+	case errors.As(err, &respErr) && respErr.Response != nil && respErr.Response.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("the GitHub API rejected GITHUB_TOKEN, check that it's valid and unexpired: %w", err)
+	}
+	return err
+}
+
+// RstSpecURL is where the rstspec.toml of a snooty-parser release, like
+// v0.13.7, is.
+func RstSpecURL(version string) string {
+	return rstSpecBase + version + "/snooty/rstspec.toml"
+}
+
+// byteUnits are the suffixes GOMEMLIMIT accepts.
+var byteUnits = []struct {
+	suffix string
+	bytes  int64
+}{{"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}, {"B", 1}}
+
+// ParseByteSize parses a size like 512MiB or 2GiB the way GOMEMLIMIT does,
+// where a plain number is bytes.
+func ParseByteSize(size string) (int64, error) {
+	number, unit := size, int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(size, u.suffix) {
+			number, unit = strings.TrimSuffix(size, u.suffix), u.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a size like 512MiB or 2GiB", size)
+	}
+	return n * unit, nil
+}
+
+// FormatByteSize writes n the way ParseByteSize reads it, in the largest unit
+// that divides it evenly.
+func FormatByteSize(n int64) string {
+	for _, u := range byteUnits {
+		if n >= u.bytes && n%u.bytes == 0 {
+			return fmt.Sprintf("%d%s", n/u.bytes, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+// MatchGlob reports whether the slash-separated name matches pattern, where
+// ** matches any number of path segments, including none, and the rest of a
+// segment follows path.Match.
+func MatchGlob(pattern, name string) (bool, error) {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+// ValidateGlob reports a malformed segment of a MatchGlob pattern.
+func ValidateGlob(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("%q is not a valid glob: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func matchSegments(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if ok, err := matchSegments(pattern[1:], name[i:]); ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(name) == 0 {
+			return false, nil
+		}
+		ok, err := path.Match(pattern[0], name[0])
+		if !ok || err != nil {
+			return false, err
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0, nil
 }
 
-func GetNetworkFile(input string) []byte {
-	req, err := http.NewRequest("GET", input, nil)
+// CacheDir is where checker keeps files between runs.
+func CacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
 	if err != nil {
-		log.Fatal(err)
+		return "", err
+	}
+	return filepath.Join(dir, "checker"), nil
+}
+
+// ResolvePermanentRedirect follows the 301 and 308 redirects from uri, returning
+// where they end up. If uri isn't permanently redirected, it's returned as is.
+// A fragment on uri is kept unless where it ends up has its own.
+func ResolvePermanentRedirect(uri string) (string, error) {
+	noFollow := &http.Client{
+		Transport: client.Transport,
+		Timeout:   client.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	current := uri
+	var fragment string
+	for i := 0; i < 10; i++ {
+		req, err := http.NewRequest("GET", current, nil)
+		if err != nil {
+			return uri, err
+		}
+		if i == 0 {
+			fragment = req.URL.Fragment
+		}
+		req.Header.Set("User-Agent", "Mozilla/5.0")
+		resp, err := noFollow.Do(req)
+		if err != nil {
+			return uri, err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMovedPermanently && resp.StatusCode != http.StatusPermanentRedirect {
+			// a Location header rarely has a fragment, so the anchor the
+			// link pointed to is kept
+			if req.URL.Fragment == "" && fragment != "" {
+				req.URL.Fragment = fragment
+				return req.URL.String(), nil
+			}
+			return current, nil
+		}
+		location, err := resp.Location()
+		if err != nil {
+			return uri, err
+		}
+		current = location.String()
+	}
+	return uri, fmt.Errorf("%s stopped after 10 redirects", uri)
+}
+
+// FetchNetworkFile returns the body of input. Non 200 responses are errors.
+func FetchNetworkFile(input string) ([]byte, error) {
+	req, err := http.NewRequest("GET", NormalizeURL(input), nil)
+	if err != nil {
+		return nil, err
 	}
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Panicf("Could not get file %s: %v", input, err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Panic(err)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned a status of %d", input, resp.StatusCode)
 	}
-	return body
+	return ioutil.ReadAll(resp.Body)
 }
 
-func GetLocalFile(input string) []byte {
-	body, err := ioutil.ReadFile(input)
+// FetchNetworkFileIfChanged is FetchNetworkFile for a file that was fetched
+// before with the etag and lastModified validators, either of which may be
+// "". It returns nil if the file hasn't changed since, and otherwise the file
+// and its new validators.
+func FetchNetworkFileIfChanged(input string, etag string, lastModified string) ([]byte, string, string, error) {
+	req, err := http.NewRequest("GET", NormalizeURL(input), nil)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
-		log.Panic(err)
+		return nil, "", "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, etag, lastModified, nil
+	case http.StatusOK:
+		data, err := ioutil.ReadAll(resp.Body)
+		if data == nil {
+			data = []byte{}
+		}
+		return data, resp.Header.Get("ETag"), resp.Header.Get("Last-Modified"), err
+	default:
+		return nil, "", "", &StatusError{URL: input, Code: resp.StatusCode}
+	}
+}
+
+// NormalizeURL turns an IRI, a url with non-ASCII characters like
+// https://bücher.example/straße, into the url to request: its host in
+// punycode, and the rest in Unicode normal form C and percent-encoded. Escapes
+// already in it are kept as they are.
+func NormalizeURL(iri string) string {
+	iri = norm.NFC.String(iri)
+	scheme, rest, ok := strings.Cut(iri, "://")
+	if !ok {
+		return escapeNonASCII(iri)
+	}
+	end := strings.IndexAny(rest, "/?#")
+	if end < 0 {
+		end = len(rest)
+	}
+	authority, rest := rest[:end], rest[end:]
+	userinfo := ""
+	if at := strings.LastIndex(authority, "@"); at >= 0 {
+		userinfo, authority = authority[:at+1], authority[at+1:]
+	}
+	host, port := authority, ""
+	if colon := strings.LastIndex(authority, ":"); colon >= 0 && !strings.Contains(authority[colon:], "]") {
+		host, port = authority[:colon], authority[colon:]
 	}
-	return body
+	if escapeNonASCII(host) != host {
+		if ascii, err := idna.Lookup.ToASCII(host); err == nil {
+			host = ascii
+		}
+	}
+	return scheme + "://" + escapeNonASCII(userinfo) + host + port + escapeNonASCII(rest)
+}
+
+// escapeNonASCII percent-encodes the bytes of s that aren't ASCII.
+func escapeNonASCII(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] < utf8.RuneSelf {
+			b.WriteByte(s[i])
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", s[i])
+	}
+	return b.String()
 }
 
 func IsHTTPLink(input string) bool {
 	return httpLinkRegex.MatchString(input)
 }
 
+// LinkResult is what checking a link found.
+type LinkResult struct {
+	// Err is why the link isn't valid, if it isn't
+	Err error
+	OK  bool
+	// Elapsed is how long checking the link took
+	Elapsed time.Duration
+	// Canonical is the rel=canonical url of the page the link ended up at,
+	// when it's materially different from the page's url
+	Canonical string
+	// Refreshed is where the link ends up after following meta refreshes,
+	// if the page it points to has one
+	Refreshed string
+}
+
+// IsReachable reports whether uri is a valid link, and why not if it isn't.
 func IsReachable(uri string) (error, bool) {
+	res := CheckLink(uri)
+	return res.Err, res.OK
+}
+
+// CheckLink requests uri and reports whether it's a valid link, and what
+// else is worth knowing about the page it ends up at.
+func CheckLink(uri string) LinkResult {
+	start := time.Now()
+	res := checkLink(uri, 0)
+	res.Elapsed = time.Since(start)
+	return res
+}
+
+// checkLink checks uri, which was reached through refreshes meta refreshes.
+func checkLink(uri string, refreshes int) LinkResult {
 	// check to see if there's a way to avoid triggering page viewws
 	// block add blockers
 	// test net.DialTCP
 	// look at muffet to see what they do to make sure a url is valid
 
-	req, err := http.NewRequest("GET", uri, nil)
+	req, err := http.NewRequest("GET", NormalizeURL(uri), nil)
+	if err != nil {
+		return LinkResult{Err: err}
+	}
 	req.Header.Set("Connection", "Keep-Alive")
 	req.Header.Set("Accept-Language", "en-US")
 	req.Header.Set("User-Agent", "Mozilla/5.0")
 	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
 
-	if err != nil {
-		log.Fatal(err)
-	}
 	response, err := client.Do(req)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "stopped after 10 redirects") {
 			if redirects.contains(response.StatusCode) {
-				return nil, true
+				return LinkResult{OK: true}
 			}
 		} else {
-			return err, false
+			return LinkResult{Err: err}
 		}
 	}
+	defer response.Body.Close()
 	if response.StatusCode == 200 {
-		return nil, true
+		var body []byte
+		if isHTML(response) {
+			// a page that can't be read is still there, it just can't be
+			// inspected
+			body, _ = ioutil.ReadAll(io.LimitReader(response.Body, maxInspectedBytes))
+		}
+		// meta refreshes are followed like http redirects
+		if target := metaRefresh(response.Request.URL, body); target != "" {
+			if refreshes >= maxRefreshes {
+				return LinkResult{Err: fmt.Errorf("%s stopped after %d meta refreshes", req.URL, maxRefreshes)}
+			}
+			res := checkLink(target, refreshes+1)
+			if res.Refreshed == "" {
+				res.Refreshed = target
+			}
+			return res
+		}
+		if problem, dead := inspectPage(req.URL, response, body); dead {
+			return LinkResult{Err: fmt.Errorf("%s returned a status of 200, but %s", req.URL, problem)}
+		}
+		page := *response.Request.URL
+		page.Fragment = req.URL.Fragment
+		if err := checkRenderedAnchor(page.String(), page.Hostname(), page.Fragment); err != nil {
+			return LinkResult{Err: err}
+		}
+		return LinkResult{OK: true, Canonical: canonicalMismatch(response.Request.URL, body)}
 	} else {
-		return fmt.Errorf("%s returned a status of %d", req.URL, response.StatusCode), false
+		return LinkResult{Err: &StatusError{URL: req.URL.String(), Code: response.StatusCode}}
 	}
 }
@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,3 +32,204 @@ func TestUrls(t *testing.T) {
 		})
 	}
 }
+
+func TestResolvePermanentRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/older", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/older", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusPermanentRedirect)
+	})
+	mux.HandleFunc("/temporary", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/moved-then-temporary", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/temporary", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/anchored", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new#moved", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cases := []struct {
+		path     string
+		expected string
+	}{{
+		path:     "/new",
+		expected: "/new",
+	}, {
+		path:     "/old",
+		expected: "/new",
+	}, {
+		path:     "/temporary",
+		expected: "/temporary",
+	}, {
+		path:     "/moved-then-temporary",
+		expected: "/temporary",
+	}, {
+		path:     "/old#install",
+		expected: "/new#install",
+	}, {
+		path:     "/new#install",
+		expected: "/new#install",
+	}, {
+		path:     "/anchored#install",
+		expected: "/new#moved",
+	}}
+	for _, test := range cases {
+		t.Run(test.path, func(t *testing.T) {
+			actual, err := ResolvePermanentRedirect(server.URL + test.path)
+			assert.NoError(t, err)
+			assert.Equal(t, server.URL+test.expected, actual)
+		})
+	}
+}
+
+func TestRstSpecURL(t *testing.T) {
+	assert.Equal(t, "https://raw.githubusercontent.com/mongodb/snooty-parser/v0.13.7/snooty/rstspec.toml", RstSpecURL("v0.13.7"))
+}
+
+func TestNormalizeURL(t *testing.T) {
+	cases := map[string]string{
+		"https://www.mongodb.com/docs/":               "https://www.mongodb.com/docs/",
+		"https://de.wikipedia.org/wiki/Stra\u00dfe":   "https://de.wikipedia.org/wiki/Stra%C3%9Fe",
+		"https://b\u00fccher.example:8443/a?q=\u00fc": "https://xn--bcher-kva.example:8443/a?q=%C3%BC",
+		// combining accents are composed first
+		"https://example.com/cafe\u0301#caf\u00e9": "https://example.com/caf%C3%A9#caf%C3%A9",
+		// escapes already there are kept
+		"https://example.com/a%2Fb/\u00e9": "https://example.com/a%2Fb/%C3%A9",
+	}
+	for iri, expected := range cases {
+		assert.Equal(t, expected, NormalizeURL(iri), iri)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	for size, want := range map[string]int64{"1024": 1024, "10B": 10, "4KiB": 4096, "512MiB": 512 << 20, "2GiB": 2 << 30, "1TiB": 1 << 40} {
+		got, err := ParseByteSize(size)
+		assert.Nil(t, err, size)
+		assert.Equal(t, want, got, size)
+	}
+	for _, size := range []string{"", "MiB", "2GB", "-1MiB", "1.5GiB"} {
+		_, err := ParseByteSize(size)
+		assert.NotNil(t, err, size)
+	}
+}
+
+// withGitHubAPI answers checker's requests with status and body, recording
+// the Authorization header they're sent with.
+func withGitHubAPI(t *testing.T, status int, header http.Header, body string) *string {
+	auth := new(string)
+	old := client.Transport
+	t.Cleanup(func() { client.Transport = old })
+	client.Transport = RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		*auth = req.Header.Get("Authorization")
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			StatusCode: status,
+			Header:     header,
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+	return auth
+}
+
+func TestLatestSnootyParserTag(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "secret")
+	auth := withGitHubAPI(t, http.StatusOK, nil, `[{"name": "v0.13.7"}, {"name": "v0.13.6"}]`)
+
+	tag, err := LatestSnootyParserTag()
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.13.7", tag)
+	assert.Equal(t, "token secret", *auth)
+}
+
+func TestLatestSnootyParserTagRateLimited(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "")
+	header := http.Header{}
+	header.Set("X-RateLimit-Limit", "60")
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("X-RateLimit-Reset", "1700000000")
+	auth := withGitHubAPI(t, http.StatusForbidden, header, `{"message": "API rate limit exceeded"}`)
+
+	_, err := LatestSnootyParserTag()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "set GITHUB_TOKEN")
+	assert.Empty(t, *auth)
+}
+
+func TestLatestSnootyParserTagBadToken(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "expired")
+	withGitHubAPI(t, http.StatusUnauthorized, nil, `{"message": "Bad credentials"}`)
+
+	_, err := LatestSnootyParserTag()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected GITHUB_TOKEN")
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"archive/**", "archive", true},
+		{"archive/**", "archive/v1/index.txt", true},
+		{"archive/**", "source/archive/index.txt", false},
+		{"**/archive/**", "source/archive/index.txt", true},
+		{"source/*.txt", "source/index.txt", true},
+		{"source/*.txt", "source/fundamentals/crud.txt", false},
+		{"source/**/*.txt", "source/index.txt", true},
+		{"source/**/*.txt", "source/fundamentals/crud.txt", true},
+		{"source/**/*.txt", "source/fundamentals/crud.rst", false},
+	}
+	for _, test := range tests {
+		match, err := MatchGlob(test.pattern, test.name)
+		assert.NoError(t, err)
+		assert.Equal(t, test.match, match, "%s should match %s: %v", test.pattern, test.name, test.match)
+	}
+
+	assert.NoError(t, ValidateGlob("source/**/*.txt"))
+	assert.Error(t, ValidateGlob("source/[/*.txt"))
+}
+
+func TestUseNetwork(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	defer func(transport http.RoundTripper) { client.Transport = transport }(client.Transport)
+
+	UseNetwork("tcp4")
+	_, err := FetchNetworkFile(server.URL)
+	assert.NoError(t, err)
+
+	UseNetwork("tcp6")
+	_, err = FetchNetworkFile(server.URL)
+	assert.Error(t, err)
+}
+
+func TestFetchNetworkFileIfChanged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("shared"))
+	}))
+	defer server.Close()
+
+	data, etag, _, err := FetchNetworkFileIfChanged(server.URL, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "shared", string(data))
+	assert.Equal(t, `"v1"`, etag)
+
+	data, etag, _, err = FetchNetworkFileIfChanged(server.URL, etag, "")
+	assert.NoError(t, err)
+	assert.Nil(t, data)
+	assert.Equal(t, `"v1"`, etag)
+}